@@ -0,0 +1,102 @@
+package application
+
+import (
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/aging"
+	"github.com/iqbalbachmid/billing-engine/domain/delinquency"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// LoanAging is how late a loan is: the days its oldest overdue schedule is past
+// due and the aging bucket that puts it in.
+type LoanAging struct {
+	LoanID      int
+	DaysPastDue int
+	Bucket      aging.Bucket
+	// Outstanding is what is left to pay, as GetOutstanding reports it.
+	Outstanding money.Money
+}
+
+// AgingBucketTotal is what the loans in one aging bucket add up to.
+type AgingBucketTotal struct {
+	Bucket      aging.Bucket
+	Loans       int
+	Outstanding money.Money
+}
+
+// AgingReport breaks the outstanding amount of every active loan down by
+// aging bucket.
+type AgingReport struct {
+	AsOf time.Time
+	// Buckets has every bucket, from current to the most overdue, including
+	// empty ones.
+	Buckets     []AgingBucketTotal
+	Outstanding money.Money
+}
+
+// GetLoanAging returns how many days past due the loan is as of today and its
+// aging bucket. Days past due are counted from the business day a due date
+// rolls to, as the daily status update counts them.
+func (s *LoanService) GetLoanAging(loanID int) (LoanAging, error) {
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return LoanAging{}, err
+	}
+
+	schedules, err := s.loanScheduleRepo.GetByLoanID(loanID)
+	if err != nil {
+		return LoanAging{}, err
+	}
+
+	return loanAging(loan, s.graceAdjusted(schedules), s.timeNow()), nil
+}
+
+// GetPortfolioAging classifies every loan being repaid into its aging bucket and
+// totals the outstanding amounts per bucket.
+func (s *LoanService) GetPortfolioAging() (AgingReport, error) {
+	loans, err := s.loanRepo.GetAll()
+	if err != nil {
+		return AgingReport{}, err
+	}
+
+	now := s.timeNow()
+	totals := make(map[aging.Bucket]AgingBucketTotal, len(aging.Buckets))
+	report := AgingReport{AsOf: s.today()}
+	for _, loan := range loans {
+		if !loan.IsPayable() {
+			continue
+		}
+
+		schedules, err := s.loanScheduleRepo.GetByLoanID(loan.LoanID)
+		if err != nil {
+			return AgingReport{}, err
+		}
+
+		loanAging := loanAging(loan, s.graceAdjusted(schedules), now)
+		total := totals[loanAging.Bucket]
+		total.Loans++
+		total.Outstanding = total.Outstanding.Add(loanAging.Outstanding)
+		totals[loanAging.Bucket] = total
+		report.Outstanding = report.Outstanding.Add(loanAging.Outstanding)
+	}
+
+	for _, bucket := range aging.Buckets {
+		total := totals[bucket]
+		total.Bucket = bucket
+		report.Buckets = append(report.Buckets, total)
+	}
+
+	return report, nil
+}
+
+func loanAging(loan entity.Loan, schedules []entity.LoanSchedule, now time.Time) LoanAging {
+	daysPastDue := delinquency.DaysPastDue(schedules, now)
+	return LoanAging{
+		LoanID:      loan.LoanID,
+		DaysPastDue: daysPastDue,
+		Bucket:      aging.BucketFor(daysPastDue),
+		Outstanding: netOutstanding(schedulesOutstanding(schedules), loan.CreditBalance),
+	}
+}
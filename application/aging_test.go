@@ -0,0 +1,197 @@
+package application
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/aging"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+)
+
+// weeklySchedules returns a loan's schedules due every week from the first due
+// date, with the given statuses.
+func weeklySchedules(loanID int, firstDueDate time.Time, statuses ...string) []entity.LoanSchedule {
+	var schedules []entity.LoanSchedule
+	for i, status := range statuses {
+		schedules = append(schedules, entity.LoanSchedule{
+			ScheduleID:      loanID*100 + i + 1,
+			LoanID:          loanID,
+			DueDate:         firstDueDate.AddDate(0, 0, 7*i),
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			PaymentStatus:   status,
+		})
+	}
+	return schedules
+}
+
+func TestLoanService_GetLoanAging(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+	)
+
+	tests := []struct {
+		name    string
+		want    LoanAging
+		wantErr bool
+		mock    func()
+	}{
+		{
+			name:    "should return error if loan not found",
+			wantErr: true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{}, repository.ErrNotFound).Once()
+			},
+		},
+		{
+			name: "should count days past due from the oldest overdue schedule",
+			want: LoanAging{
+				LoanID:      1,
+				DaysPastDue: 38,
+				Bucket:      aging.Bucket31To60,
+				Outstanding: money.New(300000),
+			},
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{
+					LoanID:        1,
+					LoanStatus:    entity.LoanStatusActive,
+					CreditBalance: money.New(30000),
+				}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(weeklySchedules(1,
+					time.Date(2024, time.October, 7, 0, 0, 0, 0, time.UTC),
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusUnspecified,
+				), nil).Once()
+			},
+		},
+		{
+			name: "should count days past due from the business day a weekend due date rolls to",
+			want: LoanAging{
+				LoanID:      1,
+				DaysPastDue: 3,
+				Bucket:      aging.Bucket1To30,
+				Outstanding: money.New(220000),
+			},
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{LoanID: 1, LoanStatus: entity.LoanStatusActive}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(weeklySchedules(1,
+					time.Date(2024, time.November, 16, 0, 0, 0, 0, time.UTC),
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusUnspecified,
+				), nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				timeNow: func() time.Time {
+					return time.Date(2024, time.November, 21, 9, 30, 0, 0, time.UTC)
+				},
+			}
+			got, err := s.GetLoanAging(1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetLoanAging() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetLoanAging() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoanService_GetPortfolioAging(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+	)
+
+	loans := []entity.Loan{
+		{LoanID: 1, LoanStatus: entity.LoanStatusActive},
+		{LoanID: 2, LoanStatus: entity.LoanStatusActive},
+		{LoanID: 3, LoanStatus: entity.LoanStatusPaid},
+		{LoanID: 4, LoanStatus: entity.LoanStatusActive},
+	}
+
+	tests := []struct {
+		name    string
+		want    AgingReport
+		wantErr bool
+		mock    func()
+	}{
+		{
+			name:    "should return error if loan repo fail",
+			wantErr: true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetAll().Return(nil, errors.New("failed to get loans")).Once()
+			},
+		},
+		{
+			name: "should total outstanding per bucket",
+			want: AgingReport{
+				AsOf: time.Date(2024, time.November, 21, 0, 0, 0, 0, time.UTC),
+				Buckets: []AgingBucketTotal{
+					{Bucket: aging.BucketCurrent, Loans: 1, Outstanding: money.New(220000)},
+					{Bucket: aging.Bucket1To30, Loans: 1, Outstanding: money.New(330000)},
+					{Bucket: aging.Bucket31To60},
+					{Bucket: aging.Bucket61To90},
+					{Bucket: aging.BucketOver90, Loans: 1, Outstanding: money.New(110000)},
+				},
+				Outstanding: money.New(660000),
+			},
+			mock: func() {
+				mockLoanRepo.EXPECT().GetAll().Return(loans, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(weeklySchedules(1,
+					time.Date(2024, time.November, 18, 0, 0, 0, 0, time.UTC),
+					entity.PaymentStatusDue,
+					entity.PaymentStatusUnspecified,
+				), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(2).Return(weeklySchedules(2,
+					time.Date(2024, time.November, 4, 0, 0, 0, 0, time.UTC),
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusDue,
+				), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(4).Return(weeklySchedules(4,
+					time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC),
+					entity.PaymentStatusOverdue,
+				), nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				timeNow: func() time.Time {
+					return time.Date(2024, time.November, 21, 9, 30, 0, 0, time.UTC)
+				},
+			}
+			got, err := s.GetPortfolioAging()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetPortfolioAging() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPortfolioAging() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
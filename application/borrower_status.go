@@ -0,0 +1,116 @@
+package application
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/iqbalbachmid/billing-engine/domain/delinquency"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+)
+
+// SyncBorrowerStatus derives the borrower's account status from their loans
+// and records the change if it differs from the current one: delinquent while
+// any active loan is delinquent under its product's policy or any loan has
+// defaulted, closed once every loan is paid, rejected or cancelled, and active
+// otherwise. Every change is audited with the reason it was made.
+func (s *LoanService) SyncBorrowerStatus(borrowerID int) (entity.Borrower, error) {
+	borrower, err := s.borrowerRepo.GetByID(borrowerID)
+	if err != nil {
+		return entity.Borrower{}, err
+	}
+
+	loans, err := s.loanRepo.GetByBorrowerID(borrowerID)
+	if err != nil {
+		return entity.Borrower{}, err
+	}
+
+	status, reason, err := s.borrowerStatus(loans)
+	if err != nil {
+		return entity.Borrower{}, err
+	}
+	if status == borrower.AccountStatus {
+		return borrower, nil
+	}
+
+	change := entity.BorrowerStatusChange{
+		BorrowerID:  borrowerID,
+		FromStatus:  borrower.AccountStatus,
+		ToStatus:    status,
+		ChangedDate: s.today(),
+		Reason:      reason,
+	}
+	if _, err = s.borrowerRepo.UpdateAccountStatus(change); err != nil {
+		return entity.Borrower{}, err
+	}
+
+	borrower.AccountStatus = status
+	return borrower, nil
+}
+
+// GetBorrowerStatusChanges returns the audit trail of the borrower's account
+// status, oldest first.
+func (s *LoanService) GetBorrowerStatusChanges(borrowerID int) ([]entity.BorrowerStatusChange, error) {
+	return s.borrowerRepo.GetStatusChanges(borrowerID)
+}
+
+// borrowerStatus returns the account status the borrower of loans should have
+// and why.
+func (s *LoanService) borrowerStatus(loans []entity.Loan) (string, string, error) {
+	if len(loans) == 0 {
+		return entity.AccountStatusActive, "borrower has no loans", nil
+	}
+
+	allSettled := true
+	for _, loan := range loans {
+		switch loan.LoanStatus {
+		case entity.LoanStatusDefaulted, entity.LoanStatusWrittenOff:
+			return entity.AccountStatusDelinquent, fmt.Sprintf("loan %d is %s", loan.LoanID, loan.LoanStatus), nil
+		case entity.LoanStatusPaid, entity.LoanStatusRejected, entity.LoanStatusCancelled:
+			continue
+		}
+		allSettled = false
+		if !loan.IsActive() {
+			continue
+		}
+
+		result, err := s.evaluateDelinquency(loan)
+		if err != nil {
+			return "", "", err
+		}
+		if result.Delinquent {
+			return entity.AccountStatusDelinquent, fmt.Sprintf("loan %d: %s", loan.LoanID, result.Reason), nil
+		}
+	}
+
+	if allSettled {
+		return entity.AccountStatusClosed, "no loan is left to repay", nil
+	}
+	return entity.AccountStatusActive, "no loan is delinquent", nil
+}
+
+// evaluateDelinquency evaluates the loan against the delinquency policy of its
+// product.
+func (s *LoanService) evaluateDelinquency(loan entity.Loan) (delinquency.Result, error) {
+	loanProduct, err := s.products.Get(loan.ProductCode)
+	if err != nil {
+		return delinquency.Result{}, err
+	}
+
+	schedules, err := s.loanScheduleRepo.GetByLoanID(loan.LoanID)
+	if err != nil {
+		return delinquency.Result{}, err
+	}
+
+	return loanProduct.Delinquency.Evaluate(s.graceAdjusted(schedules), loan.Repayment, s.timeNow()), nil
+}
+
+// syncBorrower is SyncBorrowerStatus for callers whose own change is already
+// committed, so a failure is logged rather than reported.
+func (s *LoanService) syncBorrower(borrowerID int) {
+	if s.borrowerRepo == nil {
+		return
+	}
+	if _, err := s.SyncBorrowerStatus(borrowerID); err != nil {
+		log.Printf("Failed to sync status of borrower %d: %v", borrowerID, err)
+	}
+}
@@ -0,0 +1,176 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/product"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+)
+
+func TestLoanService_SyncBorrowerStatus(t *testing.T) {
+	var (
+		mockBorrowerRepo     = mocks.NewBorrowerRepository(t)
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+	)
+
+	today := time.Date(2024, time.November, 21, 0, 0, 0, 0, time.UTC)
+	borrower := func(status string) entity.Borrower {
+		return entity.Borrower{BorrowerID: 10, FirstName: "Siti", AccountStatus: status}
+	}
+	loan := func(loanID int, status string) entity.Loan {
+		return entity.Loan{LoanID: loanID, BorrowerID: 10, ProductCode: product.Standard, LoanStatus: status}
+	}
+	firstDueDate := time.Date(2024, time.October, 7, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+		mock    func()
+	}{
+		{
+			name:    "should return error if borrower not found",
+			wantErr: true,
+			mock: func() {
+				mockBorrowerRepo.EXPECT().GetByID(10).Return(entity.Borrower{}, repository.ErrNotFound).Once()
+			},
+		},
+		{
+			name: "should flag the borrower as delinquent when one of their loans is",
+			want: entity.AccountStatusDelinquent,
+			mock: func() {
+				mockBorrowerRepo.EXPECT().GetByID(10).Return(borrower(entity.AccountStatusActive), nil).Once()
+				mockLoanRepo.EXPECT().GetByBorrowerID(10).Return([]entity.Loan{
+					loan(1, entity.LoanStatusPaid),
+					loan(2, entity.LoanStatusActive),
+					loan(3, entity.LoanStatusActive),
+				}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(2).Return(weeklySchedules(2, firstDueDate,
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusOverdue,
+				), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(3).Return(weeklySchedules(3, firstDueDate,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusOverdue,
+				), nil).Once()
+				mockBorrowerRepo.EXPECT().UpdateAccountStatus(entity.BorrowerStatusChange{
+					BorrowerID:  10,
+					FromStatus:  entity.AccountStatusActive,
+					ToStatus:    entity.AccountStatusDelinquent,
+					ChangedDate: today,
+					Reason:      "loan 3: 2 installments are overdue",
+				}).Return(1, nil).Once()
+			},
+		},
+		{
+			name: "should return the borrower to active once their loans are current",
+			want: entity.AccountStatusActive,
+			mock: func() {
+				mockBorrowerRepo.EXPECT().GetByID(10).Return(borrower(entity.AccountStatusDelinquent), nil).Once()
+				mockLoanRepo.EXPECT().GetByBorrowerID(10).Return([]entity.Loan{loan(3, entity.LoanStatusActive)}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(3).Return(weeklySchedules(3, firstDueDate,
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusDue,
+				), nil).Once()
+				mockBorrowerRepo.EXPECT().UpdateAccountStatus(entity.BorrowerStatusChange{
+					BorrowerID:  10,
+					FromStatus:  entity.AccountStatusDelinquent,
+					ToStatus:    entity.AccountStatusActive,
+					ChangedDate: today,
+					Reason:      "no loan is delinquent",
+				}).Return(2, nil).Once()
+			},
+		},
+		{
+			name: "should close the borrower once none of their loans is left to repay",
+			want: entity.AccountStatusClosed,
+			mock: func() {
+				mockBorrowerRepo.EXPECT().GetByID(10).Return(borrower(entity.AccountStatusActive), nil).Once()
+				mockLoanRepo.EXPECT().GetByBorrowerID(10).Return([]entity.Loan{
+					loan(1, entity.LoanStatusPaid),
+					loan(2, entity.LoanStatusRejected),
+					loan(3, entity.LoanStatusPaid),
+				}, nil).Once()
+				mockBorrowerRepo.EXPECT().UpdateAccountStatus(entity.BorrowerStatusChange{
+					BorrowerID:  10,
+					FromStatus:  entity.AccountStatusActive,
+					ToStatus:    entity.AccountStatusClosed,
+					ChangedDate: today,
+					Reason:      "no loan is left to repay",
+				}).Return(3, nil).Once()
+			},
+		},
+		{
+			name: "should flag the borrower as delinquent when one of their loans has defaulted",
+			want: entity.AccountStatusDelinquent,
+			mock: func() {
+				mockBorrowerRepo.EXPECT().GetByID(10).Return(borrower(entity.AccountStatusActive), nil).Once()
+				mockLoanRepo.EXPECT().GetByBorrowerID(10).Return([]entity.Loan{
+					loan(1, entity.LoanStatusCancelled),
+					loan(2, entity.LoanStatusDefaulted),
+				}, nil).Once()
+				mockBorrowerRepo.EXPECT().UpdateAccountStatus(entity.BorrowerStatusChange{
+					BorrowerID:  10,
+					FromStatus:  entity.AccountStatusActive,
+					ToStatus:    entity.AccountStatusDelinquent,
+					ChangedDate: today,
+					Reason:      "loan 2 is defaulted",
+				}).Return(4, nil).Once()
+			},
+		},
+		{
+			name: "should not record anything if the status is unchanged",
+			want: entity.AccountStatusClosed,
+			mock: func() {
+				mockBorrowerRepo.EXPECT().GetByID(10).Return(borrower(entity.AccountStatusClosed), nil).Once()
+				mockLoanRepo.EXPECT().GetByBorrowerID(10).Return([]entity.Loan{loan(1, entity.LoanStatusPaid)}, nil).Once()
+			},
+		},
+		{
+			name:    "should return error if borrower repo fail",
+			wantErr: true,
+			mock: func() {
+				mockBorrowerRepo.EXPECT().GetByID(10).Return(borrower(entity.AccountStatusClosed), nil).Once()
+				mockLoanRepo.EXPECT().GetByBorrowerID(10).Return([]entity.Loan{loan(4, entity.LoanStatusActive)}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(4).Return(weeklySchedules(4, today,
+					entity.PaymentStatusUnspecified,
+				), nil).Once()
+				mockBorrowerRepo.EXPECT().UpdateAccountStatus(entity.BorrowerStatusChange{
+					BorrowerID:  10,
+					FromStatus:  entity.AccountStatusClosed,
+					ToStatus:    entity.AccountStatusActive,
+					ChangedDate: today,
+					Reason:      "no loan is delinquent",
+				}).Return(0, errors.New("failed to update borrower")).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				borrowerRepo:     mockBorrowerRepo,
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				products:         product.Default(),
+				timeNow: func() time.Time {
+					return today.Add(9 * time.Hour)
+				},
+			}
+			got, err := s.SyncBorrowerStatus(10)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SyncBorrowerStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.AccountStatus != tt.want {
+				t.Errorf("SyncBorrowerStatus() got = %v, want %v", got.AccountStatus, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package application
+
+import (
+	"errors"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// WaiveCharge forgives up to the unpaid part of a charge. The waiver is
+// recorded as an adjustment of the charge and taken off its schedule, which
+// becomes paid, and the loan closed, if nothing else is left outstanding.
+// Only what has not been paid can be waived; paid charges are refunded
+// instead.
+func (s *LoanService) WaiveCharge(chargeID int, amount money.Money, reason string) (entity.ChargeAdjustment, error) {
+	if !amount.IsPositive() {
+		return entity.ChargeAdjustment{}, errors.New("waiver amount must be positive")
+	}
+	if reason == "" {
+		return entity.ChargeAdjustment{}, errors.New("waiver reason is required")
+	}
+
+	charge, err := s.chargeRepo.GetByID(chargeID)
+	if err != nil {
+		return entity.ChargeAdjustment{}, err
+	}
+
+	loan, err := s.loanRepo.GetByID(charge.LoanID)
+	if err != nil {
+		return entity.ChargeAdjustment{}, err
+	}
+
+	schedules, err := s.loanScheduleRepo.GetByLoanID(charge.LoanID)
+	if err != nil {
+		return entity.ChargeAdjustment{}, err
+	}
+
+	schedule := findSchedule(schedules, charge.ScheduleID)
+	if schedule == nil {
+		return entity.ChargeAdjustment{}, errors.New("charge schedule not found")
+	}
+	if amount.GreaterThan(money.Min(charge.Waivable(), schedule.UnpaidFees())) {
+		return entity.ChargeAdjustment{}, ErrWaiverExceedsCharge
+	}
+
+	charge.WaivedAmount = charge.WaivedAmount.Add(amount)
+	schedule.Waive(amount)
+	closing := s.closeIfRepaid(&loan, schedules)
+
+	adjustment := entity.ChargeAdjustment{
+		ChargeID:       chargeID,
+		AdjustmentType: entity.AdjustmentTypeWaiver,
+		Amount:         amount,
+		AdjustmentDate: s.today(),
+		Reason:         reason,
+	}
+	adjustment.AdjustmentID, err = s.chargeRepo.CreateAdjustmentAndUpdateLoanSchedule(adjustment, charge, *schedule, loan)
+	if err != nil {
+		return entity.ChargeAdjustment{}, err
+	}
+
+	if closing {
+		s.publishClosed(loan)
+		s.syncBorrower(loan.BorrowerID)
+	}
+
+	return adjustment, nil
+}
+
+// assessPenalties charges the late payment penalties the loan's product calls
+// for on its overdue schedules and persists them with the schedules, and
+// returns the IDs of the schedules it wrote. Those are updated in place,
+// including their version, so that they can be written again afterwards.
+func (s *LoanService) assessPenalties(loan entity.Loan, schedules []entity.LoanSchedule, now time.Time) ([]int, error) {
+	loanProduct, err := s.products.Get(loan.ProductCode)
+	if err != nil {
+		return nil, err
+	}
+	if loanProduct.Penalty == nil || !hasOverdue(schedules) {
+		return nil, nil
+	}
+
+	existing, err := s.chargeRepo.GetByLoanID(loan.LoanID)
+	if err != nil {
+		return nil, err
+	}
+	charged := make(map[int]money.Money)
+	for _, charge := range existing {
+		if charge.ChargeType == entity.ChargeTypeLatePayment {
+			charged[charge.ScheduleID] = charged[charge.ScheduleID].Add(charge.Amount)
+		}
+	}
+
+	var (
+		charges          []entity.Charge
+		chargedSchedules []int
+	)
+	adjusted := s.graceAdjusted(schedules)
+	for i := range schedules {
+		amount := loanProduct.Penalty.Assess(schedules[i], adjusted[i].DaysPastDue(now), charged[schedules[i].ScheduleID])
+		if !amount.IsPositive() {
+			continue
+		}
+		schedules[i].Charge(amount)
+		charges = append(charges, entity.Charge{
+			LoanID:       loan.LoanID,
+			ScheduleID:   schedules[i].ScheduleID,
+			ChargeType:   entity.ChargeTypeLatePayment,
+			Amount:       amount,
+			AssessedDate: s.today(),
+		})
+		chargedSchedules = append(chargedSchedules, i)
+	}
+	if len(charges) == 0 {
+		return nil, nil
+	}
+
+	loanSchedules := make([]entity.LoanSchedule, 0, len(chargedSchedules))
+	for _, i := range chargedSchedules {
+		loanSchedules = append(loanSchedules, schedules[i])
+	}
+	if _, err = s.chargeRepo.CreateChargesAndUpdateLoanSchedules(charges, loanSchedules); err != nil {
+		return nil, err
+	}
+
+	scheduleIDs := make([]int, 0, len(chargedSchedules))
+	for _, i := range chargedSchedules {
+		schedules[i].Version++
+		scheduleIDs = append(scheduleIDs, schedules[i].ScheduleID)
+	}
+	return scheduleIDs, nil
+}
+
+func hasOverdue(schedules []entity.LoanSchedule) bool {
+	for _, schedule := range schedules {
+		if schedule.IsOverdue() {
+			return true
+		}
+	}
+	return false
+}
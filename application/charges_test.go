@@ -0,0 +1,227 @@
+package application
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/event"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	eventmocks "github.com/iqbalbachmid/billing-engine/mocks/domain/event"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+)
+
+func TestLoanService_WaiveCharge(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		mockChargeRepo       = mocks.NewChargeRepository(t)
+		mockEventPublisher   = eventmocks.NewPublisher(t)
+	)
+
+	today := time.Date(2024, time.October, 23, 0, 0, 0, 0, time.UTC)
+	loan := entity.Loan{LoanID: 1, BorrowerID: 10, LoanStatus: entity.LoanStatusActive}
+	charge := entity.Charge{
+		ChargeID:     7,
+		LoanID:       1,
+		ScheduleID:   2,
+		ChargeType:   entity.ChargeTypeLatePayment,
+		Amount:       money.New(10000),
+		AssessedDate: time.Date(2024, time.October, 22, 0, 0, 0, 0, time.UTC),
+	}
+	paid := entity.LoanSchedule{
+		ScheduleID:      1,
+		LoanID:          1,
+		PrincipalAmount: money.New(100000),
+		InterestAmount:  money.New(10000),
+		TotalDue:        money.New(110000),
+		PrincipalPaid:   money.New(100000),
+		InterestPaid:    money.New(10000),
+		PaymentStatus:   entity.PaymentStatusPaid,
+	}
+	// overdue with the penalty charged and the installment itself paid
+	penalised := entity.LoanSchedule{
+		ScheduleID:      2,
+		LoanID:          1,
+		PrincipalAmount: money.New(100000),
+		InterestAmount:  money.New(10000),
+		FeeAmount:       money.New(10000),
+		TotalDue:        money.New(120000),
+		PrincipalPaid:   money.New(100000),
+		InterestPaid:    money.New(10000),
+		PaymentStatus:   entity.PaymentStatusOverdue,
+	}
+
+	tests := []struct {
+		name      string
+		amount    money.Money
+		reason    string
+		want      entity.ChargeAdjustment
+		wantErr   bool
+		wantErrIs error
+		mock      func()
+	}{
+		{
+			name:    "should return error if reason is empty",
+			amount:  money.New(5000),
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name:      "should return error if charge not found",
+			amount:    money.New(5000),
+			reason:    "first late payment",
+			wantErr:   true,
+			wantErrIs: repository.ErrNotFound,
+			mock: func() {
+				mockChargeRepo.EXPECT().GetByID(7).Return(entity.Charge{}, repository.ErrNotFound).Once()
+			},
+		},
+		{
+			name:      "should return error if waiving more than is left of the charge",
+			amount:    money.New(6000),
+			reason:    "first late payment",
+			wantErr:   true,
+			wantErrIs: ErrWaiverExceedsCharge,
+			mock: func() {
+				waived := charge
+				waived.WaivedAmount = money.New(5000)
+				mockChargeRepo.EXPECT().GetByID(7).Return(waived, nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{paid, penalised}, nil).Once()
+			},
+		},
+		{
+			name:      "should return error if the charge was capitalized by a restructure",
+			amount:    money.New(5000),
+			reason:    "first late payment",
+			wantErr:   true,
+			wantErrIs: ErrWaiverExceedsCharge,
+			mock: func() {
+				capitalized := charge
+				capitalized.ScheduleID = 3
+				capitalized.CapitalizedAmount = money.New(10000)
+				respread := entity.LoanSchedule{
+					ScheduleID:      3,
+					LoanID:          1,
+					PrincipalAmount: money.New(110000),
+					TotalDue:        money.New(110000),
+					PaymentStatus:   entity.PaymentStatusDue,
+				}
+				mockChargeRepo.EXPECT().GetByID(7).Return(capitalized, nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{paid, respread}, nil).Once()
+			},
+		},
+		{
+			name:      "should return error if waiving what was already paid",
+			amount:    money.New(5000),
+			reason:    "first late payment",
+			wantErr:   true,
+			wantErrIs: ErrWaiverExceedsCharge,
+			mock: func() {
+				feePaid := penalised
+				feePaid.FeePaid = money.New(6000)
+				mockChargeRepo.EXPECT().GetByID(7).Return(charge, nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{paid, feePaid}, nil).Once()
+			},
+		},
+		{
+			name:   "should waive part of the charge",
+			amount: money.New(4000),
+			reason: "first late payment",
+			want: entity.ChargeAdjustment{
+				AdjustmentID:   3,
+				ChargeID:       7,
+				AdjustmentType: entity.AdjustmentTypeWaiver,
+				Amount:         money.New(4000),
+				AdjustmentDate: today,
+				Reason:         "first late payment",
+			},
+			mock: func() {
+				mockChargeRepo.EXPECT().GetByID(7).Return(charge, nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{paid, penalised}, nil).Once()
+
+				waived := charge
+				waived.WaivedAmount = money.New(4000)
+				schedule := penalised
+				schedule.FeeAmount = money.New(6000)
+				schedule.TotalDue = money.New(116000)
+				mockChargeRepo.EXPECT().CreateAdjustmentAndUpdateLoanSchedule(entity.ChargeAdjustment{
+					ChargeID:       7,
+					AdjustmentType: entity.AdjustmentTypeWaiver,
+					Amount:         money.New(4000),
+					AdjustmentDate: today,
+					Reason:         "first late payment",
+				}, waived, schedule, loan).Return(3, nil).Once()
+			},
+		},
+		{
+			name:   "should close the loan when the waiver settles what is left",
+			amount: money.New(10000),
+			reason: "hardship",
+			want: entity.ChargeAdjustment{
+				AdjustmentID:   4,
+				ChargeID:       7,
+				AdjustmentType: entity.AdjustmentTypeWaiver,
+				Amount:         money.New(10000),
+				AdjustmentDate: today,
+				Reason:         "hardship",
+			},
+			mock: func() {
+				mockChargeRepo.EXPECT().GetByID(7).Return(charge, nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{paid, penalised}, nil).Once()
+
+				waived := charge
+				waived.WaivedAmount = money.New(10000)
+				schedule := penalised
+				schedule.FeeAmount = money.Money{}
+				schedule.TotalDue = money.New(110000)
+				schedule.PaymentStatus = entity.PaymentStatusPaid
+				closed := loan
+				closed.Close(today)
+				mockChargeRepo.EXPECT().CreateAdjustmentAndUpdateLoanSchedule(entity.ChargeAdjustment{
+					ChargeID:       7,
+					AdjustmentType: entity.AdjustmentTypeWaiver,
+					Amount:         money.New(10000),
+					AdjustmentDate: today,
+					Reason:         "hardship",
+				}, waived, schedule, closed).Return(4, nil).Once()
+				mockEventPublisher.EXPECT().Publish(event.LoanClosed{
+					LoanID:     1,
+					BorrowerID: 10,
+					ClosedDate: today,
+				}).Return(nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				chargeRepo:       mockChargeRepo,
+				eventPublisher:   mockEventPublisher,
+				timeNow: func() time.Time {
+					return today.Add(14 * time.Hour)
+				},
+			}
+			got, err := s.WaiveCharge(7, tt.amount, tt.reason)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("WaiveCharge() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WaiveCharge() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
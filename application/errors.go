@@ -0,0 +1,60 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+)
+
+var (
+	// ErrPaymentAlreadyReversed is returned when reversing a payment that has
+	// already been reversed.
+	ErrPaymentAlreadyReversed = errors.New("payment is already reversed")
+	// ErrPaymentNotPending is returned when settling a payment that has
+	// already been settled.
+	ErrPaymentNotPending = errors.New("payment is not pending")
+	// ErrPaymentNotCompleted is returned when reversing a payment that never
+	// cleared; a pending payment is failed instead.
+	ErrPaymentNotCompleted = errors.New("payment is not completed")
+	// ErrInsufficientCreditBalance is returned when a reversal has to take
+	// back credit that has since been refunded to the borrower.
+	ErrInsufficientCreditBalance = errors.New("credit balance funded by the payment was already refunded")
+	// ErrWaiverExceedsCharge is returned when waiving more of a charge than
+	// is left of it unpaid and not already waived.
+	ErrWaiverExceedsCharge = errors.New("waiver exceeds the unpaid charge")
+	// ErrPayoffQuoteExpired is returned when paying off a loan at a quote
+	// that is no longer honoured.
+	ErrPayoffQuoteExpired = errors.New("payoff quote has expired")
+	// ErrPaymentsPending is returned when paying off a loan that still has
+	// payments waiting to clear.
+	ErrPaymentsPending = errors.New("loan has pending payments")
+	// ErrScheduleSuperseded is returned when reversing a payment allocated to
+	// schedules a restructure has since superseded.
+	ErrScheduleSuperseded = errors.New("payment was allocated to schedules superseded by a restructure")
+)
+
+// IdempotencyConflictError is returned when a payment request reuses the
+// idempotency key of an earlier payment but differs from it.
+type IdempotencyConflictError struct {
+	IdempotencyKey string
+	Original       entity.Payment
+}
+
+func (e *IdempotencyConflictError) Error() string {
+	return fmt.Sprintf(
+		"idempotency key %q was already used for payment %d of %s to loan %d",
+		e.IdempotencyKey, e.Original.PaymentID, e.Original.AmountPaid, e.Original.LoanID,
+	)
+}
+
+// LoanNotPayableError is returned when taking a payment on a loan that is not
+// being repaid: one not yet disbursed, already paid off, or closed otherwise.
+type LoanNotPayableError struct {
+	LoanID int
+	Status string
+}
+
+func (e *LoanNotPayableError) Error() string {
+	return fmt.Sprintf("loan %d is %s and cannot take payments", e.LoanID, e.Status)
+}
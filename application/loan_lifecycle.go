@@ -0,0 +1,94 @@
+package application
+
+import (
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/schedule"
+)
+
+// ApproveLoan approves an applied loan so it can be disbursed.
+func (s *LoanService) ApproveLoan(loanID int) (entity.Loan, error) {
+	return s.transitionLoan(loanID, entity.LoanStatusApproved)
+}
+
+// RejectLoan turns down an applied loan.
+func (s *LoanService) RejectLoan(loanID int) (entity.Loan, error) {
+	return s.transitionLoan(loanID, entity.LoanStatusRejected)
+}
+
+// CancelLoan withdraws a loan that has not been disbursed yet.
+func (s *LoanService) CancelLoan(loanID int) (entity.Loan, error) {
+	return s.transitionLoan(loanID, entity.LoanStatusCancelled)
+}
+
+// DefaultLoan declares an active loan in default. It can still be repaid.
+func (s *LoanService) DefaultLoan(loanID int) (entity.Loan, error) {
+	return s.transitionLoan(loanID, entity.LoanStatusDefaulted)
+}
+
+// WriteOffLoan writes off a defaulted loan as unrecoverable. It takes no
+// further payments.
+func (s *LoanService) WriteOffLoan(loanID int) (entity.Loan, error) {
+	return s.transitionLoan(loanID, entity.LoanStatusWrittenOff)
+}
+
+// DisburseLoan pays out an approved loan today, which makes it active, and
+// persists its repayment schedule, due at the loan's frequency on business days
+// by its product's convention and amortized by its method. Initial schedule
+// statuses are derived the same way the daily status update derives them. A
+// borrower whose account was closed becomes active again.
+func (s *LoanService) DisburseLoan(loanID int) (entity.Loan, error) {
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return entity.Loan{}, err
+	}
+	loanProduct, err := s.products.Get(loan.ProductCode)
+	if err != nil {
+		return entity.Loan{}, err
+	}
+	if err = loan.TransitionTo(entity.LoanStatusActive); err != nil {
+		return entity.Loan{}, err
+	}
+
+	now := s.timeNow()
+	loan.LoanStartDate = s.today()
+	schedules, err := schedule.Generate(loan, s.calendar, loanProduct.BusinessDay)
+	if err != nil {
+		return entity.Loan{}, err
+	}
+	loan.LoanEndDate = schedules[len(schedules)-1].DueDate
+	s.warnUnlistedHolidays(loanID, schedules)
+
+	periodStart := loan.LoanStartDate
+	for i := range schedules {
+		schedules[i].LoanID = loanID
+		schedules[i].PaymentStatus = s.statusAt(schedules[i], periodStart, now)
+		periodStart = schedules[i].DueDate
+	}
+
+	if err = s.loanScheduleRepo.CreateLoanSchedulesAndUpdateLoan(schedules, loan); err != nil {
+		return entity.Loan{}, err
+	}
+
+	s.syncBorrower(loan.BorrowerID)
+
+	return loan, nil
+}
+
+// transitionLoan moves the loan to status and saves it. An illegal move is
+// reported as an *entity.InvalidTransitionError.
+func (s *LoanService) transitionLoan(loanID int, status string) (entity.Loan, error) {
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return entity.Loan{}, err
+	}
+	if err = loan.TransitionTo(status); err != nil {
+		return entity.Loan{}, err
+	}
+	if err = s.loanRepo.Update(loan); err != nil {
+		return entity.Loan{}, err
+	}
+
+	s.syncBorrower(loan.BorrowerID)
+
+	return loan, nil
+}
@@ -0,0 +1,182 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/product"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoanService_DisburseLoan(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		created              []entity.LoanSchedule
+	)
+
+	loanWithStatus := func(status string) entity.Loan {
+		return entity.Loan{
+			LoanID:       100,
+			BorrowerID:   1,
+			ProductCode:  product.Standard,
+			LoanAmount:   money.New(5000000),
+			InterestRate: 10,
+			LoanStatus:   status,
+			Tenor:        50,
+		}
+	}
+	disbursed := loanWithStatus(entity.LoanStatusActive)
+	disbursed.LoanStartDate = time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC)
+	disbursed.LoanEndDate = time.Date(2025, time.October, 13, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		want          entity.Loan
+		wantSchedules int
+		wantErr       bool
+		wantErrAs     bool
+		mock          func()
+	}{
+		{
+			name:    "should return error if loan not found",
+			wantErr: true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(entity.Loan{}, repository.ErrNotFound).Once()
+			},
+		},
+		{
+			name:      "should return error if loan is not approved",
+			wantErr:   true,
+			wantErrAs: true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loanWithStatus(entity.LoanStatusApplied), nil).Once()
+			},
+		},
+		{
+			name:    "should return error if loan schedule repo fail to create the schedules",
+			wantErr: true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loanWithStatus(entity.LoanStatusApproved), nil).Once()
+				mockLoanScheduleRepo.EXPECT().CreateLoanSchedulesAndUpdateLoan(mock.Anything, disbursed).
+					Return(errors.New("failed to create schedules")).Once()
+			},
+		},
+		{
+			name:          "should activate the loan with weekly schedule successfully",
+			want:          disbursed,
+			wantSchedules: 50,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loanWithStatus(entity.LoanStatusApproved), nil).Once()
+				mockLoanScheduleRepo.EXPECT().CreateLoanSchedulesAndUpdateLoan(mock.Anything, disbursed).
+					RunAndReturn(func(schedules []entity.LoanSchedule, loan entity.Loan) error {
+						created = schedules
+						return nil
+					}).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			created = nil
+
+			s := &LoanService{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				products:         product.Default(),
+				timeNow: func() time.Time {
+					return time.Date(2024, time.October, 28, 9, 30, 0, 0, time.UTC)
+				},
+			}
+			got, err := s.DisburseLoan(100)
+			var transitionErr *entity.InvalidTransitionError
+			if (err != nil) != tt.wantErr || errors.As(err, &transitionErr) != tt.wantErrAs {
+				t.Errorf("DisburseLoan() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("DisburseLoan() got = %+v, want %+v", got, tt.want)
+			}
+			if len(created) != tt.wantSchedules {
+				t.Fatalf("DisburseLoan() created %v schedules, want %v", len(created), tt.wantSchedules)
+			}
+			for _, schedule := range created {
+				if schedule.LoanID != 100 || schedule.TotalDue != money.New(110000) {
+					t.Errorf("DisburseLoan() created schedule = %+v", schedule)
+				}
+				if schedule.PaymentStatus != entity.PaymentStatusUnspecified {
+					t.Errorf("DisburseLoan() initial status = %v, want %v", schedule.PaymentStatus, entity.PaymentStatusUnspecified)
+				}
+			}
+		})
+	}
+}
+
+func TestLoanService_transitionLoan(t *testing.T) {
+	mockLoanRepo := mocks.NewLoanRepository(t)
+
+	tests := []struct {
+		name       string
+		transition func(s *LoanService) (entity.Loan, error)
+		wantStatus string
+		wantErr    bool
+		mock       func()
+	}{
+		{
+			name:       "should approve an applied loan",
+			transition: func(s *LoanService) (entity.Loan, error) { return s.ApproveLoan(1) },
+			wantStatus: entity.LoanStatusApproved,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{LoanID: 1, LoanStatus: entity.LoanStatusApplied}, nil).Once()
+				mockLoanRepo.EXPECT().Update(entity.Loan{LoanID: 1, LoanStatus: entity.LoanStatusApproved}).Return(nil).Once()
+			},
+		},
+		{
+			name:       "should not cancel an active loan",
+			transition: func(s *LoanService) (entity.Loan, error) { return s.CancelLoan(1) },
+			wantErr:    true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{LoanID: 1, LoanStatus: entity.LoanStatusActive}, nil).Once()
+			},
+		},
+		{
+			name:       "should return error if loan repo fail to update",
+			transition: func(s *LoanService) (entity.Loan, error) { return s.DefaultLoan(1) },
+			wantErr:    true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{LoanID: 1, LoanStatus: entity.LoanStatusActive}, nil).Once()
+				mockLoanRepo.EXPECT().Update(mock.Anything).Return(repository.ErrNotFound).Once()
+			},
+		},
+		{
+			name:       "should write off a defaulted loan",
+			transition: func(s *LoanService) (entity.Loan, error) { return s.WriteOffLoan(1) },
+			wantStatus: entity.LoanStatusWrittenOff,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{LoanID: 1, LoanStatus: entity.LoanStatusDefaulted}, nil).Once()
+				mockLoanRepo.EXPECT().Update(entity.Loan{LoanID: 1, LoanStatus: entity.LoanStatusWrittenOff}).Return(nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{loanRepo: mockLoanRepo}
+			got, err := tt.transition(s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("transitionLoan() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.LoanStatus != tt.wantStatus {
+				t.Errorf("transitionLoan() status = %v, want %v", got.LoanStatus, tt.wantStatus)
+			}
+		})
+	}
+}
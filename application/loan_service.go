@@ -2,122 +2,452 @@ package application
 
 import (
 	"errors"
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/delinquency"
 	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/event"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/paymentmethod"
+	"github.com/iqbalbachmid/billing-engine/domain/product"
 	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	"github.com/iqbalbachmid/billing-engine/domain/schedule"
+	"log"
+	"strings"
 	"time"
 )
 
-const OverdueLimit = 2
-
 type LoanService struct {
-	loanRepo         repository.LoanRepository
-	loanScheduleRepo repository.LoanScheduleRepository
-	paymentRepo      repository.PaymentRepository
-	timeNow          func() time.Time
+	borrowerRepo        repository.BorrowerRepository
+	loanRepo            repository.LoanRepository
+	loanScheduleRepo    repository.LoanScheduleRepository
+	paymentRepo         repository.PaymentRepository
+	chargeRepo          repository.ChargeRepository
+	eventPublisher      event.Publisher
+	timeNow             func() time.Time
+	allocationOrder     []entity.AllocationComponent
+	paymentMethods      *paymentmethod.Registry
+	products            *product.Catalog
+	calendar            *calendar.Calendar
+	payoffQuoteValidity int
 }
 
 func NewLoanService(
+	borrowerRepo repository.BorrowerRepository,
 	loanRepo repository.LoanRepository,
 	loanScheduleRepo repository.LoanScheduleRepository,
 	paymentRepo repository.PaymentRepository,
+	chargeRepo repository.ChargeRepository,
+	eventPublisher event.Publisher,
 	timeNow func() time.Time,
+	opts ...Option,
 ) *LoanService {
-	return &LoanService{
-		loanRepo:         loanRepo,
-		loanScheduleRepo: loanScheduleRepo,
-		paymentRepo:      paymentRepo,
-		timeNow:          timeNow,
+	s := &LoanService{
+		borrowerRepo:        borrowerRepo,
+		loanRepo:            loanRepo,
+		loanScheduleRepo:    loanScheduleRepo,
+		paymentRepo:         paymentRepo,
+		chargeRepo:          chargeRepo,
+		eventPublisher:      eventPublisher,
+		timeNow:             timeNow,
+		allocationOrder:     entity.DefaultAllocationOrder,
+		paymentMethods:      paymentmethod.Default(),
+		products:            product.Default(),
+		payoffQuoteValidity: defaultPayoffQuoteValidity,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-func (s *LoanService) GetOutstanding(loanID int) (float64, error) {
-	schedules, err := s.loanScheduleRepo.GetByLoanID(loanID)
+// CreateLoan records the borrower's application for a loan of the given
+// product, repaid in tenor installments at the given frequency and amortized
+// by the given method. Interest accrues by the product's day count convention,
+// which the loan keeps even if the product changes. The loan has no repayment
+// schedule until it is approved and disbursed.
+func (s *LoanService) CreateLoan(
+	borrowerID int,
+	productCode string,
+	amount money.Money,
+	interestRate float64,
+	tenor int,
+	amortization entity.Amortization,
+	repayment entity.Repayment,
+) (entity.Loan, error) {
+	loanProduct, err := s.products.Get(productCode)
 	if err != nil {
-		return 0, err
+		return entity.Loan{}, err
+	}
+	if !amount.IsPositive() {
+		return entity.Loan{}, errors.New("loan amount must be positive")
+	}
+	if interestRate < 0 {
+		return entity.Loan{}, errors.New("interest rate must not be negative")
+	}
+	if tenor <= 0 {
+		return entity.Loan{}, errors.New("loan tenor must be at least one installment")
 	}
 
-	outstanding := 0.0
-	for _, schedule := range schedules {
-		if !schedule.IsPaid() {
-			outstanding += schedule.TotalDue
-		}
+	loan := entity.Loan{
+		BorrowerID:   borrowerID,
+		ProductCode:  productCode,
+		LoanAmount:   amount,
+		InterestRate: interestRate,
+		LoanStatus:   entity.LoanStatusApplied,
+		Tenor:        tenor,
+		DayCount:     loanProduct.DayCount,
+		Amortization: amortization,
+		Repayment:    repayment,
+	}
+	if loan.Amortization.Method == "" {
+		loan.Amortization.Method = entity.AmortizationFlat
+	}
+	if loan.Repayment.Frequency == "" {
+		loan.Repayment.Frequency = entity.FrequencyWeekly
+	}
+	if loanProduct.Rounding != nil {
+		loan.Rounding = *loanProduct.Rounding
+	}
+	// reject terms that could not be scheduled once the loan is disbursed
+	if _, err = schedule.Generate(loan, s.calendar, loanProduct.BusinessDay); err != nil {
+		return entity.Loan{}, err
+	}
+
+	if loan.LoanID, err = s.loanRepo.Create(loan); err != nil {
+		return entity.Loan{}, err
 	}
 
-	return outstanding, nil
+	return loan, nil
 }
 
-func (s *LoanService) IsDelinquent(loanID int) (bool, error) {
+// GetOutstanding returns what the borrower still has to pay on the loan: the
+// unpaid amount of every schedule less the loan's credit balance.
+func (s *LoanService) GetOutstanding(loanID int) (money.Money, error) {
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return money.Money{}, err
+	}
+
 	schedules, err := s.loanScheduleRepo.GetByLoanID(loanID)
 	if err != nil {
-		return false, err
+		return money.Money{}, err
 	}
 
-	var overdueCounter int
-	for _, schedule := range schedules {
-		if schedule.IsOverdue() {
-			overdueCounter++
+	return netOutstanding(schedulesOutstanding(schedules), loan.CreditBalance), nil
+}
+
+// IsDelinquent evaluates the loan against the delinquency policy of its
+// product. The result says why a delinquent loan was flagged.
+func (s *LoanService) IsDelinquent(loanID int) (delinquency.Result, error) {
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return delinquency.Result{}, err
+	}
+
+	return s.evaluateDelinquency(loan)
+}
+
+// GetClosedLoans returns the loans that were paid off between from and to,
+// inclusive, with their closing dates.
+func (s *LoanService) GetClosedLoans(from, to time.Time) ([]entity.Loan, error) {
+	return s.loanRepo.GetClosedBetween(from, to)
+}
+
+// MakePayment records a pending payment and reserves it across the oldest
+// billable schedules, each one's components in the configured allocation
+// order. Nothing is marked as paid until ConfirmPayment reports that the funds
+// cleared; FailPayment gives the reservation up instead. What is left once
+// every billable schedule is reserved goes to the credit balance when the
+// payment clears and is applied to later schedules as they become due.
+//
+// The payment method must be registered with the service and accept the
+// amount; the fee it charges is recorded on the payment, and its settlement
+// delay gives the date the payment is expected to clear.
+//
+// The idempotency key, e.g. the bank reference of a transfer, makes retries
+// safe: a request replayed with a key that was already used returns the
+// original payment instead of paying again, or an *IdempotencyConflictError if
+// it does not match the original request. A payment made without a key is
+// never taken for a retry, so retrying it pays again.
+func (s *LoanService) MakePayment(
+	loanID int,
+	paymentAmount money.Money,
+	paymentMethod string,
+	idempotencyKey string,
+) (entity.Payment, error) {
+	if err := s.validate(paymentAmount); err != nil {
+		return entity.Payment{}, err
+	}
+
+	method, err := s.paymentMethods.Get(paymentMethod)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+	if err = method.Validate(paymentAmount); err != nil {
+		return entity.Payment{}, err
+	}
+
+	now := s.timeNow()
+	payment := entity.Payment{
+		LoanID:                 loanID,
+		PaymentDate:            now,
+		AmountPaid:             paymentAmount,
+		PaymentMethod:          paymentMethod,
+		FeeAmount:              method.Fee(paymentAmount),
+		Status:                 entity.StatusPending,
+		ExpectedSettlementDate: now.Add(method.SettlementDelay()),
+		IdempotencyKey:         idempotencyKey,
+	}
+
+	if idempotencyKey != "" {
+		original, err := s.paymentRepo.GetByIdempotencyKey(idempotencyKey)
+		if err == nil {
+			return replay(original, payment)
 		}
-		if overdueCounter == OverdueLimit {
-			return true, nil
+		if !errors.Is(err, repository.ErrNotFound) {
+			return entity.Payment{}, err
 		}
 	}
 
-	return false, nil
-}
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+	if !loan.IsPayable() {
+		return entity.Payment{}, &LoanNotPayableError{LoanID: loanID, Status: loan.LoanStatus}
+	}
 
-func (s *LoanService) MakePayment(loanID int, paymentAmount float64, paymentMethod string) error {
 	schedules, err := s.loanScheduleRepo.GetByLoanID(loanID)
 	if err != nil {
-		return err
+		return entity.Payment{}, err
 	}
 
-	if err = s.validate(schedules, loanID, paymentAmount); err != nil {
-		return err
+	payment.Allocations = s.reserve(schedules, paymentAmount)
+
+	saved, err := s.savePayment(payment, schedules, loan)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// a concurrent request with the same key got there first
+		original, err := s.paymentRepo.GetByIdempotencyKey(idempotencyKey)
+		if err != nil {
+			return entity.Payment{}, err
+		}
+		return replay(original, payment)
 	}
 
-	payment := entity.Payment{
-		LoanID:        loanID,
-		PaymentDate:   s.timeNow(),
-		AmountPaid:    paymentAmount,
-		PaymentMethod: paymentMethod,
-		Status:        entity.Status,
+	return saved, err
+}
+
+// RefundCreditBalance pays the credit balance left on a closed loan back to
+// the borrower.
+func (s *LoanService) RefundCreditBalance(loanID int) (entity.CreditRefund, error) {
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return entity.CreditRefund{}, err
+	}
+	if !loan.IsPaid() {
+		return entity.CreditRefund{}, errors.New("credit balance can only be refunded once the loan is paid")
+	}
+	if !loan.CreditBalance.IsPositive() {
+		return entity.CreditRefund{}, errors.New("loan has no credit balance to refund")
 	}
 
-	var loanSchedulesToBeUpdated []entity.LoanSchedule
-	for _, schedule := range schedules {
-		if !schedule.IsPaid() {
-			schedule.PaymentStatus = entity.PaymentStatusPaid
-			loanSchedulesToBeUpdated = append(loanSchedulesToBeUpdated, schedule)
-			paymentAmount -= schedule.TotalDue
+	refund := entity.CreditRefund{
+		LoanID:     loanID,
+		RefundDate: s.today(),
+		Amount:     loan.CreditBalance,
+	}
+	loan.CreditBalance = money.Money{}
+
+	if refund.RefundID, err = s.paymentRepo.CreateCreditRefund(refund, loan); err != nil {
+		return entity.CreditRefund{}, err
+	}
+
+	return refund, nil
+}
+
+func (s *LoanService) validate(paymentAmount money.Money) error {
+	if !paymentAmount.IsPositive() {
+		return errors.New("payment amount must be positive")
+	}
+
+	return nil
+}
+
+func validateIdempotencyKey(idempotencyKey string) error {
+	if strings.TrimSpace(idempotencyKey) == "" {
+		return errors.New("idempotency key is required")
+	}
+
+	return nil
+}
+
+// replay returns the original payment made with the requested payment's
+// idempotency key, provided the request is the same as the original one.
+func replay(original, requested entity.Payment) (entity.Payment, error) {
+	if original.LoanID != requested.LoanID ||
+		original.AmountPaid.Cmp(requested.AmountPaid) != 0 ||
+		original.PaymentMethod != requested.PaymentMethod {
+		return entity.Payment{}, &IdempotencyConflictError{
+			IdempotencyKey: requested.IdempotencyKey,
+			Original:       original,
 		}
-		if paymentAmount == 0.0 {
+	}
+
+	return original, nil
+}
+
+// allocate applies amount to what is not reserved on the billable schedules
+// oldest first, updating them in place, and returns the allocations made and
+// what is left over.
+func (s *LoanService) allocate(
+	schedules []entity.LoanSchedule,
+	amount money.Money,
+) ([]entity.PaymentAllocation, money.Money) {
+	var allocations []entity.PaymentAllocation
+	for i := range schedules {
+		if !amount.IsPositive() {
 			break
 		}
+		if !schedules[i].IsBillable() || !schedules[i].Unreserved().IsPositive() {
+			continue
+		}
+
+		allocation, _ := schedules[i].Allocate(money.Min(amount, schedules[i].Unreserved()), s.allocationOrder)
+		allocations = append(allocations, allocation)
+		amount = amount.Sub(allocation.Total())
 	}
 
-	if _, err = s.paymentRepo.CreatePaymentAndUpdateLoanSchedules(payment, loanSchedulesToBeUpdated); err != nil {
-		return err
+	return allocations, amount
+}
+
+// reserve sets amount aside on the billable schedules oldest first, updating
+// them in place, and returns the allocations expected once the payment
+// clears.
+func (s *LoanService) reserve(schedules []entity.LoanSchedule, amount money.Money) []entity.PaymentAllocation {
+	var allocations []entity.PaymentAllocation
+	for i := range schedules {
+		if !amount.IsPositive() {
+			break
+		}
+		if !schedules[i].IsBillable() || !schedules[i].Unreserved().IsPositive() {
+			continue
+		}
+
+		var allocation entity.PaymentAllocation
+		allocation, amount = schedules[i].Reserve(amount, s.allocationOrder)
+		allocations = append(allocations, allocation)
 	}
 
-	return nil
+	return allocations
 }
 
-func (s *LoanService) validate(
+// savePayment persists the payment together with the schedules it was
+// allocated to and the loan, closing the loan first if nothing is left
+// outstanding on it, and returns the payment with its ID.
+func (s *LoanService) savePayment(
+	payment entity.Payment,
 	schedules []entity.LoanSchedule,
-	loanID int,
-	paymentAmount float64,
-) error {
-	outstanding, err := s.GetOutstanding(loanID)
+	loan entity.Loan,
+) (entity.Payment, error) {
+	return s.savePaymentAndSchedules(payment, allocatedSchedules(payment, schedules), schedules, loan)
+}
+
+// savePaymentAndSchedules is savePayment writing back the updated schedules,
+// which include the ones the payment is allocated to, in the same
+// transaction.
+func (s *LoanService) savePaymentAndSchedules(
+	payment entity.Payment,
+	updated []entity.LoanSchedule,
+	schedules []entity.LoanSchedule,
+	loan entity.Loan,
+) (entity.Payment, error) {
+	closing := len(payment.Allocations) > 0 && s.closeIfRepaid(&loan, schedules)
+
+	paymentID, err := s.paymentRepo.CreatePaymentAndUpdateLoanSchedules(payment, updated, loan)
 	if err != nil {
-		return err
+		return entity.Payment{}, err
+	}
+	payment.PaymentID = paymentID
+
+	if closing {
+		s.publishClosed(loan)
 	}
-	if paymentAmount > outstanding {
-		return errors.New("payment amount exceeds outstanding balance")
+
+	return payment, nil
+}
+
+// allocatedSchedules returns the schedules the payment is allocated to.
+func allocatedSchedules(payment entity.Payment, schedules []entity.LoanSchedule) []entity.LoanSchedule {
+	allocated := make(map[int]bool, len(payment.Allocations))
+	for _, allocation := range payment.Allocations {
+		allocated[allocation.ScheduleID] = true
 	}
 
-	if int(paymentAmount)%int(schedules[0].TotalDue) != 0 {
-		return errors.New("payment is not a valid multiple of schedule amount")
+	var allocatedSchedules []entity.LoanSchedule
+	for _, schedule := range schedules {
+		if allocated[schedule.ScheduleID] {
+			allocatedSchedules = append(allocatedSchedules, schedule)
+		}
 	}
+	return allocatedSchedules
+}
 
-	return nil
+// closeIfRepaid closes the loan if nothing is left outstanding on its
+// schedules and reports whether it did.
+func (s *LoanService) closeIfRepaid(loan *entity.Loan, schedules []entity.LoanSchedule) bool {
+	if !schedulesOutstanding(schedules).IsZero() {
+		return false
+	}
+	return loan.Close(s.today()) == nil
+}
+
+func (s *LoanService) publishClosed(loan entity.Loan) {
+	s.publish(event.LoanClosed{
+		LoanID:     loan.LoanID,
+		BorrowerID: loan.BorrowerID,
+		ClosedDate: *loan.ClosedDate,
+	})
+}
+
+func schedulesOutstanding(schedules []entity.LoanSchedule) money.Money {
+	var outstanding money.Money
+	for _, schedule := range schedules {
+		if !schedule.IsPaid() {
+			outstanding = outstanding.Add(schedule.Outstanding())
+		}
+	}
+	return outstanding
+}
+
+// netOutstanding is the outstanding amount less credit, never below zero.
+func netOutstanding(outstanding, credit money.Money) money.Money {
+	return money.Max(outstanding.Sub(credit), money.Money{})
+}
+
+// publish is best effort: the state change it announces is already committed,
+// so a failure is logged rather than reported to the caller.
+func (s *LoanService) publish(e event.Event) {
+	if s.eventPublisher == nil {
+		return
+	}
+	if err := s.eventPublisher.Publish(e); err != nil {
+		log.Printf("Failed to publish %s event: %v", e.Name(), err)
+	}
+}
+
+// warnUnlistedHolidays logs the first of the loan's schedules falling due in a
+// year the calendar lists no holidays for, as it may fall due on one. Loans are
+// still serviced meanwhile, on the weekends-only business days of that year.
+func (s *LoanService) warnUnlistedHolidays(loanID int, schedules []entity.LoanSchedule) {
+	for _, loanSchedule := range schedules {
+		if err := s.calendar.CheckYear(loanSchedule.DueDate); err != nil {
+			log.Printf("Loan %d may fall due on a holiday on %s: %v",
+				loanID, loanSchedule.DueDate.Format(time.DateOnly), err)
+			return
+		}
+	}
+}
+
+func (s *LoanService) today() time.Time {
+	return startOfDay(s.timeNow())
 }
@@ -2,15 +2,22 @@ package application
 
 import (
 	"errors"
+	"github.com/iqbalbachmid/billing-engine/domain/delinquency"
 	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/event"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/paymentmethod"
+	"github.com/iqbalbachmid/billing-engine/domain/product"
 	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	"github.com/iqbalbachmid/billing-engine/domain/rounding"
 	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+	"github.com/stretchr/testify/mock"
 	"testing"
 	"time"
 )
 
-func TestLoanService_GetOutstanding(t *testing.T) {
-	mockLoanScheduleRepo := mocks.NewLoanScheduleRepository(t)
+func TestLoanService_CreateLoan(t *testing.T) {
+	mockLoanRepo := mocks.NewLoanRepository(t)
 
 	type fields struct {
 		loanRepo         repository.LoanRepository
@@ -18,67 +25,124 @@ func TestLoanService_GetOutstanding(t *testing.T) {
 		paymentRepo      repository.PaymentRepository
 	}
 	type args struct {
-		loanID int
+		borrowerID   int
+		productCode  string
+		amount       money.Money
+		interestRate float64
+		tenor        int
+		amortization entity.Amortization
+		repayment    entity.Repayment
 	}
-	var tests = []struct {
+	tests := []struct {
 		name    string
 		fields  fields
 		args    args
-		want    float64
+		want    entity.Loan
 		wantErr bool
 		mock    func()
 	}{
 		{
-			name: "should return error if loan not found",
+			name: "should return error if product is unknown",
+			args: args{
+				borrowerID:   1,
+				productCode:  "payday",
+				amount:       money.New(5000000),
+				interestRate: 10,
+				tenor:        50,
+			},
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name: "should return error if tenor is not positive",
+			args: args{
+				borrowerID:   1,
+				productCode:  product.Standard,
+				amount:       money.New(5000000),
+				interestRate: 10,
+			},
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name: "should return error if amortization method is unknown",
+			args: args{
+				borrowerID:   1,
+				productCode:  product.Standard,
+				amount:       money.New(5000000),
+				interestRate: 10,
+				tenor:        50,
+				amortization: entity.Amortization{Method: "balloon"},
+			},
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name: "should return error if anchor day does not fit the frequency",
+			args: args{
+				borrowerID:   1,
+				productCode:  product.Standard,
+				amount:       money.New(5000000),
+				interestRate: 10,
+				tenor:        12,
+				repayment:    entity.Repayment{Frequency: entity.FrequencyWeekly, AnchorDay: 8},
+			},
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name: "should return error if loan repo fail",
 			fields: fields{
-				loanScheduleRepo: mockLoanScheduleRepo,
+				loanRepo: mockLoanRepo,
 			},
 			args: args{
-				loanID: 1,
+				borrowerID:   1,
+				productCode:  product.Standard,
+				amount:       money.New(5000000),
+				interestRate: 10,
+				tenor:        50,
 			},
-			want:    0,
 			wantErr: true,
 			mock: func() {
-				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(nil, errors.New("loan not found")).Once()
+				mockLoanRepo.EXPECT().Create(mock.Anything).Return(0, errors.New("failed to create loan")).Once()
 			},
 		},
 		{
-			name: "should return outstanding successfully",
+			name: "should record the loan application successfully",
 			fields: fields{
-				loanScheduleRepo: mockLoanScheduleRepo,
+				loanRepo: mockLoanRepo,
 			},
 			args: args{
-				loanID: 1,
+				borrowerID:   1,
+				productCode:  product.Standard,
+				amount:       money.New(5000000),
+				interestRate: 10,
+				tenor:        50,
+			},
+			want: entity.Loan{
+				LoanID:       100,
+				BorrowerID:   1,
+				ProductCode:  product.Standard,
+				LoanAmount:   money.New(5000000),
+				InterestRate: 10,
+				LoanStatus:   entity.LoanStatusApplied,
+				Tenor:        50,
+				Rounding:     rounding.Policy{Unit: money.New(1), Residual: rounding.Last},
+				Amortization: entity.Amortization{Method: entity.AmortizationFlat},
+				Repayment:    entity.Repayment{Frequency: entity.FrequencyWeekly},
 			},
-			want:    220000,
-			wantErr: false,
 			mock: func() {
-				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
-					{
-						ScheduleID:      1,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
-					},
-					{
-						ScheduleID:      2,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusDue,
-					},
-					{
-						ScheduleID:      3,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusUnspecified,
-					},
-				}, nil).Once()
+				mockLoanRepo.EXPECT().Create(entity.Loan{
+					BorrowerID:   1,
+					ProductCode:  product.Standard,
+					LoanAmount:   money.New(5000000),
+					InterestRate: 10,
+					LoanStatus:   entity.LoanStatusApplied,
+					Tenor:        50,
+					Rounding:     rounding.Policy{Unit: money.New(1), Residual: rounding.Last},
+					Amortization: entity.Amortization{Method: entity.AmortizationFlat},
+					Repayment:    entity.Repayment{Frequency: entity.FrequencyWeekly},
+				}).Return(100, nil).Once()
 			},
 		},
 	}
@@ -90,20 +154,25 @@ func TestLoanService_GetOutstanding(t *testing.T) {
 				loanRepo:         tt.fields.loanRepo,
 				loanScheduleRepo: tt.fields.loanScheduleRepo,
 				paymentRepo:      tt.fields.paymentRepo,
+				products:         product.Default(),
+				timeNow: func() time.Time {
+					return time.Date(2024, time.October, 28, 9, 30, 0, 0, time.UTC)
+				},
 			}
-			got, err := s.GetOutstanding(tt.args.loanID)
+			got, err := s.CreateLoan(tt.args.borrowerID, tt.args.productCode, tt.args.amount, tt.args.interestRate, tt.args.tenor, tt.args.amortization, tt.args.repayment)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("GetOutstanding() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("CreateLoan() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("GetOutstanding() got = %v, want %v", got, tt.want)
+				t.Errorf("CreateLoan() got = %+v, want %+v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestLoanService_IsDelinquent(t *testing.T) {
+func TestLoanService_GetOutstanding(t *testing.T) {
+	mockLoanRepo := mocks.NewLoanRepository(t)
 	mockLoanScheduleRepo := mocks.NewLoanScheduleRepository(t)
 
 	type fields struct {
@@ -114,122 +183,150 @@ func TestLoanService_IsDelinquent(t *testing.T) {
 	type args struct {
 		loanID int
 	}
-	tests := []struct {
+	var tests = []struct {
 		name    string
 		fields  fields
 		args    args
-		want    bool
+		want    money.Money
 		wantErr bool
 		mock    func()
 	}{
 		{
 			name: "should return error if loan not found",
 			fields: fields{
+				loanRepo:         mockLoanRepo,
 				loanScheduleRepo: mockLoanScheduleRepo,
 			},
 			args: args{
 				loanID: 1,
 			},
-			want:    false,
+			want:    money.Money{},
 			wantErr: true,
 			mock: func() {
-				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(nil, errors.New("loan not found")).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{}, repository.ErrNotFound).Once()
 			},
 		},
 		{
-			name: "should return true if overdue = 2",
+			name: "should return outstanding successfully",
 			fields: fields{
+				loanRepo:         mockLoanRepo,
 				loanScheduleRepo: mockLoanScheduleRepo,
 			},
 			args: args{
 				loanID: 1,
 			},
-			want:    true,
+			want:    money.New(220000),
 			wantErr: false,
 			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{LoanID: 1}, nil).Once()
 				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
 					{
 						ScheduleID:      1,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
 						PaymentStatus:   entity.PaymentStatusPaid,
 					},
 					{
 						ScheduleID:      2,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusOverdue,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
+						PaymentStatus:   entity.PaymentStatusDue,
 					},
 					{
 						ScheduleID:      3,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusOverdue,
-					},
-					{
-						ScheduleID:      4,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
 						PaymentStatus:   entity.PaymentStatusUnspecified,
 					},
 				}, nil).Once()
 			},
 		},
 		{
-			name: "should return false if overdue < 2",
+			name: "should return exact residual of partially paid schedules",
 			fields: fields{
+				loanRepo:         mockLoanRepo,
 				loanScheduleRepo: mockLoanScheduleRepo,
 			},
 			args: args{
 				loanID: 1,
 			},
-			want:    false,
+			want:    money.MustParse("169999.99"),
 			wantErr: false,
 			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{LoanID: 1}, nil).Once()
 				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
 					{
 						ScheduleID:      1,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
+						InterestPaid:    money.New(10000),
+						PrincipalPaid:   money.MustParse("40000.01"),
+						PaymentStatus:   entity.PaymentStatusPartiallyPaid,
 					},
 					{
 						ScheduleID:      2,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusOverdue,
-					},
-					{
-						ScheduleID:      3,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
 						PaymentStatus:   entity.PaymentStatusUnspecified,
 					},
+				}, nil).Once()
+			},
+		},
+		{
+			name: "should deduct credit balance from outstanding",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			args: args{
+				loanID: 1,
+			},
+			want:    money.New(70000),
+			wantErr: false,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{LoanID: 1, CreditBalance: money.New(40000)}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
 					{
-						ScheduleID:      4,
+						ScheduleID:      1,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusUnspecified,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
+						PaymentStatus:   entity.PaymentStatusDue,
 					},
 				}, nil).Once()
 			},
 		},
+		{
+			name: "should not return negative outstanding when credit exceeds it",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			args: args{
+				loanID: 1,
+			},
+			want:    money.Money{},
+			wantErr: false,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{
+					LoanID:        1,
+					LoanStatus:    entity.LoanStatusPaid,
+					CreditBalance: money.New(5000),
+				}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(nil, nil).Once()
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -240,23 +337,54 @@ func TestLoanService_IsDelinquent(t *testing.T) {
 				loanScheduleRepo: tt.fields.loanScheduleRepo,
 				paymentRepo:      tt.fields.paymentRepo,
 			}
-			got, err := s.IsDelinquent(tt.args.loanID)
+			got, err := s.GetOutstanding(tt.args.loanID)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("IsDelinquent() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("GetOutstanding() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("IsDelinquent() got = %v, want %v", got, tt.want)
+				t.Errorf("GetOutstanding() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestLoanService_MakePayment(t *testing.T) {
+func TestLoanService_IsDelinquent(t *testing.T) {
 	var (
-		mockLoanScheduleRepository = mocks.NewLoanScheduleRepository(t)
-		mockPaymentRepository      = mocks.NewPaymentRepository(t)
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+	)
+
+	products, err := product.NewCatalog(
+		product.Product{
+			Code:        product.Standard,
+			Delinquency: delinquency.TotalOverdue{Limit: 2},
+		},
+		product.Product{
+			Code: "micro",
+			Delinquency: delinquency.Any{
+				delinquency.ConsecutiveOverdue{Limit: 2},
+				delinquency.DaysPastDueOver{Threshold: 30},
+			},
+		},
 	)
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	loanOf := func(productCode string) entity.Loan {
+		return entity.Loan{LoanID: 1, ProductCode: productCode, LoanStatus: entity.LoanStatusActive}
+	}
+	overdueSince := func(scheduleID int, dueDate time.Time) entity.LoanSchedule {
+		return entity.LoanSchedule{
+			ScheduleID:      scheduleID,
+			LoanID:          1,
+			DueDate:         dueDate,
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			PaymentStatus:   entity.PaymentStatusOverdue,
+		}
+	}
 
 	type fields struct {
 		loanRepo         repository.LoanRepository
@@ -264,311 +392,1026 @@ func TestLoanService_MakePayment(t *testing.T) {
 		paymentRepo      repository.PaymentRepository
 	}
 	type args struct {
-		loanID        int
-		paymentAmount float64
-		paymentMethod string
+		loanID int
 	}
 	tests := []struct {
 		name    string
 		fields  fields
 		args    args
+		want    delinquency.Result
 		wantErr bool
 		mock    func()
 	}{
 		{
 			name: "should return error if loan not found",
 			fields: fields{
-				loanScheduleRepo: mockLoanScheduleRepository,
+				loanRepo: mockLoanRepo,
 			},
 			args: args{
 				loanID: 1,
 			},
 			wantErr: true,
 			mock: func() {
-				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return(nil, errors.New("loan not found")).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{}, repository.ErrNotFound).Once()
 			},
 		},
 		{
-			name: "should return error if payment amount is more than outstanding",
+			name: "should return error if loan product is unknown",
 			fields: fields{
-				loanScheduleRepo: mockLoanScheduleRepository,
+				loanRepo: mockLoanRepo,
 			},
 			args: args{
-				loanID:        1,
-				paymentAmount: 230000,
+				loanID: 1,
 			},
 			wantErr: true,
 			mock: func() {
-				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
-					{
-						ScheduleID:      1,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
-					},
-					{
-						ScheduleID:      2,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
-					},
-					{
-						ScheduleID:      3,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusDue,
-					},
-					{
-						ScheduleID:      4,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusUnspecified,
-					},
-				}, nil).Twice()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loanOf("payday"), nil).Once()
 			},
 		},
 		{
-			name: "should return error if payment amount is not multiple of schedule amount",
+			name: "should return error if loan schedule repo fail",
 			fields: fields{
-				loanScheduleRepo: mockLoanScheduleRepository,
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
 			},
 			args: args{
-				loanID:        1,
-				paymentAmount: 230000,
+				loanID: 1,
 			},
 			wantErr: true,
 			mock: func() {
-				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
-					{
-						ScheduleID:      1,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
-					},
-					{
-						ScheduleID:      2,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
-					},
-					{
-						ScheduleID:      3,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusDue,
-					},
-					{
-						ScheduleID:      4,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusUnspecified,
-					},
-					{
-						ScheduleID:      5,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusUnspecified,
-					},
-				}, nil).Twice()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loanOf(product.Standard), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(nil, errors.New("failed to get schedules")).Once()
 			},
 		},
 		{
-			name: "should return error if payment repo fail",
+			name: "should return true if overdue = 2",
 			fields: fields{
-				loanScheduleRepo: mockLoanScheduleRepository,
-				paymentRepo:      mockPaymentRepository,
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
 			},
 			args: args{
-				loanID:        1,
-				paymentAmount: 220000,
-				paymentMethod: "bank transfer",
+				loanID: 1,
+			},
+			want: delinquency.Result{
+				Delinquent: true,
+				Reason:     "2 installments are overdue",
 			},
-			wantErr: true,
 			mock: func() {
-				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+				mockLoanRepo.EXPECT().GetByID(1).Return(loanOf(product.Standard), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
 					{
 						ScheduleID:      1,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
 						PaymentStatus:   entity.PaymentStatusPaid,
 					},
 					{
 						ScheduleID:      2,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
+						PaymentStatus:   entity.PaymentStatusOverdue,
 					},
 					{
 						ScheduleID:      3,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusDue,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
+						PaymentStatus:   entity.PaymentStatusOverdue,
 					},
 					{
 						ScheduleID:      4,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusUnspecified,
-					},
-					{
-						ScheduleID:      5,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
 						PaymentStatus:   entity.PaymentStatusUnspecified,
 					},
-				}, nil).Twice()
-
-				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(entity.Payment{
-					LoanID:        1,
-					PaymentDate:   time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
-					AmountPaid:    220000,
-					PaymentMethod: "bank transfer",
-					Status:        entity.Status,
-				}, []entity.LoanSchedule{
-					{
-						ScheduleID:      3,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
-					},
-					{
-						ScheduleID:      4,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
-					},
-				}).Return(0, errors.New("failed to create payment")).Once()
+				}, nil).Once()
 			},
 		},
 		{
-			name: "should not return error and make payment successfully",
+			name: "should return false if overdue < 2",
 			fields: fields{
-				loanScheduleRepo: mockLoanScheduleRepository,
-				paymentRepo:      mockPaymentRepository,
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
 			},
 			args: args{
-				loanID:        1,
-				paymentAmount: 220000,
-				paymentMethod: "bank transfer",
+				loanID: 1,
 			},
-			wantErr: false,
 			mock: func() {
-				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+				mockLoanRepo.EXPECT().GetByID(1).Return(loanOf(product.Standard), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
 					{
 						ScheduleID:      1,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
 						PaymentStatus:   entity.PaymentStatusPaid,
 					},
 					{
 						ScheduleID:      2,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
+						PaymentStatus:   entity.PaymentStatusOverdue,
 					},
 					{
 						ScheduleID:      3,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusDue,
-					},
-					{
-						ScheduleID:      4,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusUnspecified,
-					},
-					{
-						ScheduleID:      5,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
 						PaymentStatus:   entity.PaymentStatusUnspecified,
 					},
-				}, nil).Twice()
-
-				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(entity.Payment{
-					LoanID:        1,
-					PaymentDate:   time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
-					AmountPaid:    220000,
-					PaymentMethod: "bank transfer",
-					Status:        entity.Status,
-				}, []entity.LoanSchedule{
-					{
-						ScheduleID:      3,
-						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
-					},
 					{
 						ScheduleID:      4,
 						LoanID:          1,
-						PrincipalAmount: 100000,
-						InterestAmount:  10000,
-						TotalDue:        110000,
-						PaymentStatus:   entity.PaymentStatusPaid,
+						PrincipalAmount: money.New(100000),
+						InterestAmount:  money.New(10000),
+						TotalDue:        money.New(110000),
+						PaymentStatus:   entity.PaymentStatusUnspecified,
 					},
-				}).Return(200, nil).Once()
+				}, nil).Once()
 			},
 		},
-	}
-	for _, tt := range tests {
-		tt.mock()
-
-		t.Run(tt.name, func(t *testing.T) {
-			s := &LoanService{
-				loanRepo:         tt.fields.loanRepo,
-				loanScheduleRepo: tt.fields.loanScheduleRepo,
-				paymentRepo:      tt.fields.paymentRepo,
-				timeNow: func() time.Time {
-					return time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC)
-				},
-			}
-			if err := s.MakePayment(tt.args.loanID, tt.args.paymentAmount, tt.args.paymentMethod); (err != nil) != tt.wantErr {
-				t.Errorf("MakePayment() error = %v, wantErr %v", err, tt.wantErr)
+		{
+			name: "should return false if 2 installments of a daily loan are overdue",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			args: args{
+				loanID: 1,
+			},
+			mock: func() {
+				daily := loanOf(product.Standard)
+				daily.Repayment.Frequency = entity.FrequencyDaily
+				mockLoanRepo.EXPECT().GetByID(1).Return(daily, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+					overdueSince(1, time.Date(2024, time.November, 12, 0, 0, 0, 0, time.UTC)),
+					overdueSince(2, time.Date(2024, time.November, 13, 0, 0, 0, 0, time.UTC)),
+				}, nil).Once()
+			},
+		},
+		{
+			name: "should return true if a daily loan is as long overdue as 2 weekly installments",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			args: args{
+				loanID: 1,
+			},
+			want: delinquency.Result{
+				Delinquent: true,
+				Reason:     "10 installments are overdue",
+			},
+			mock: func() {
+				daily := loanOf(product.Standard)
+				daily.Repayment.Frequency = entity.FrequencyDaily
+				mockLoanRepo.EXPECT().GetByID(1).Return(daily, nil).Once()
+				var schedules []entity.LoanSchedule
+				for day := 31; len(schedules) < 10; day++ {
+					dueDate := time.Date(2024, time.October, day, 0, 0, 0, 0, time.UTC)
+					if dueDate.Weekday() != time.Saturday && dueDate.Weekday() != time.Sunday {
+						schedules = append(schedules, overdueSince(len(schedules)+1, dueDate))
+					}
+				}
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedules, nil).Once()
+			},
+		},
+		{
+			name: "should return true if an installment of a monthly loan is overdue",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			args: args{
+				loanID: 1,
+			},
+			want: delinquency.Result{
+				Delinquent: true,
+				Reason:     "1 installments are overdue",
+			},
+			mock: func() {
+				monthly := loanOf(product.Standard)
+				monthly.Repayment.Frequency = entity.FrequencyMonthly
+				mockLoanRepo.EXPECT().GetByID(1).Return(monthly, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+					overdueSince(1, time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC)),
+				}, nil).Once()
+			},
+		},
+		{
+			name: "should return false if overdue installments of a micro loan are not consecutive",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			args: args{
+				loanID: 1,
+			},
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(loanOf("micro"), nil).Once()
+				paid := overdueSince(2, time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC))
+				paid.PaymentStatus = entity.PaymentStatusPaid
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+					overdueSince(1, time.Date(2024, time.October, 21, 0, 0, 0, 0, time.UTC)),
+					paid,
+					overdueSince(3, time.Date(2024, time.November, 4, 0, 0, 0, 0, time.UTC)),
+				}, nil).Once()
+			},
+		},
+		{
+			name: "should return true if a micro loan is too many days past due",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			args: args{
+				loanID: 1,
+			},
+			want: delinquency.Result{
+				Delinquent: true,
+				Reason:     "31 days past due",
+			},
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(loanOf("micro"), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+					overdueSince(1, time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC)),
+				}, nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:         tt.fields.loanRepo,
+				loanScheduleRepo: tt.fields.loanScheduleRepo,
+				paymentRepo:      tt.fields.paymentRepo,
+				products:         products,
+				timeNow: func() time.Time {
+					return time.Date(2024, time.November, 14, 9, 30, 0, 0, time.UTC)
+				},
+			}
+			got, err := s.IsDelinquent(tt.args.loanID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IsDelinquent() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("IsDelinquent() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoanService_MakePayment(t *testing.T) {
+	var (
+		mockLoanRepository         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepository = mocks.NewLoanScheduleRepository(t)
+		mockPaymentRepository      = mocks.NewPaymentRepository(t)
+	)
+
+	activeLoan := func(loanID int) entity.Loan {
+		return entity.Loan{
+			LoanID:     loanID,
+			BorrowerID: 10,
+			LoanAmount: money.New(500000),
+			LoanStatus: entity.LoanStatusActive,
+			Tenor:      5,
+		}
+	}
+	newSchedule := func(scheduleID, loanID int, status string) entity.LoanSchedule {
+		return entity.LoanSchedule{
+			ScheduleID:      scheduleID,
+			LoanID:          loanID,
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			PaymentStatus:   status,
+		}
+	}
+	paidSchedule := func(scheduleID, loanID int) entity.LoanSchedule {
+		schedule := newSchedule(scheduleID, loanID, entity.PaymentStatusPaid)
+		schedule.PrincipalPaid = money.New(100000)
+		schedule.InterestPaid = money.New(10000)
+		return schedule
+	}
+	reservedSchedule := func(scheduleID, loanID int, status string, reserved money.Money) entity.LoanSchedule {
+		schedule := newSchedule(scheduleID, loanID, status)
+		schedule.ReservedAmount = reserved
+		return schedule
+	}
+	fullAllocation := func(scheduleID int) entity.PaymentAllocation {
+		return entity.PaymentAllocation{
+			ScheduleID:      scheduleID,
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+		}
+	}
+	// schedules 1 and 2 paid, 3 overdue, 4 due and 5 not yet due
+	fiveWeekSchedules := func(loanID int) []entity.LoanSchedule {
+		return []entity.LoanSchedule{
+			paidSchedule(1, loanID),
+			paidSchedule(2, loanID),
+			newSchedule(3, loanID, entity.PaymentStatusOverdue),
+			newSchedule(4, loanID, entity.PaymentStatusDue),
+			newSchedule(5, loanID, entity.PaymentStatusUnspecified),
+		}
+	}
+	paymentOf := func(loanID int, amount money.Money, allocations ...entity.PaymentAllocation) entity.Payment {
+		return entity.Payment{
+			LoanID:                 loanID,
+			PaymentDate:            time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
+			AmountPaid:             amount,
+			PaymentMethod:          "bank_transfer",
+			FeeAmount:              money.New(2500),
+			Status:                 entity.StatusPending,
+			ExpectedSettlementDate: time.Date(2024, time.October, 29, 0, 0, 0, 0, time.UTC),
+			IdempotencyKey:         "REF-1",
+			Allocations:            allocations,
+		}
+	}
+	closedDate := time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC)
+
+	type fields struct {
+		loanRepo         repository.LoanRepository
+		loanScheduleRepo repository.LoanScheduleRepository
+		paymentRepo      repository.PaymentRepository
+		eventPublisher   event.Publisher
+		allocationOrder  []entity.AllocationComponent
+	}
+	type args struct {
+		loanID         int
+		paymentAmount  money.Money
+		paymentMethod  string
+		idempotencyKey string
+	}
+	tests := []struct {
+		name          string
+		fields        fields
+		args          args
+		wantPaymentID int
+		wantErr       bool
+		mock          func()
+	}{
+		{
+			name: "should return error if loan not found",
+			fields: fields{
+				loanRepo:    mockLoanRepository,
+				paymentRepo: mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(110000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantErr: true,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepository.EXPECT().GetByID(1).Return(entity.Loan{}, repository.ErrNotFound).Once()
+			},
+		},
+		{
+			name: "should return error if loan schedules not found",
+			fields: fields{
+				loanRepo:         mockLoanRepository,
+				loanScheduleRepo: mockLoanScheduleRepository,
+				paymentRepo:      mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(110000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantErr: true,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepository.EXPECT().GetByID(1).Return(activeLoan(1), nil).Once()
+				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return(nil, errors.New("loan not found")).Once()
+			},
+		},
+		{
+			name: "should return error if loan is already paid",
+			fields: fields{
+				loanRepo:    mockLoanRepository,
+				paymentRepo: mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(110000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantErr: true,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				paidLoan := activeLoan(1)
+				paidLoan.Close(closedDate)
+				mockLoanRepository.EXPECT().GetByID(1).Return(paidLoan, nil).Once()
+			},
+		},
+		{
+			name: "should return error if loan is not disbursed yet",
+			fields: fields{
+				loanRepo:    mockLoanRepository,
+				paymentRepo: mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(110000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantErr: true,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				approvedLoan := activeLoan(1)
+				approvedLoan.LoanStatus = entity.LoanStatusApproved
+				mockLoanRepository.EXPECT().GetByID(1).Return(approvedLoan, nil).Once()
+			},
+		},
+		{
+			name: "should leave the amount above billable schedules unreserved",
+			fields: fields{
+				loanRepo:         mockLoanRepository,
+				loanScheduleRepo: mockLoanScheduleRepository,
+				paymentRepo:      mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(230000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantPaymentID: 200,
+			wantErr:       false,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepository.EXPECT().GetByID(1).Return(activeLoan(1), nil).Once()
+				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return(fiveWeekSchedules(1), nil).Once()
+
+				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(
+					paymentOf(1, money.New(230000), fullAllocation(3), fullAllocation(4)),
+					[]entity.LoanSchedule{
+						reservedSchedule(3, 1, entity.PaymentStatusOverdue, money.New(110000)),
+						reservedSchedule(4, 1, entity.PaymentStatusDue, money.New(110000)),
+					},
+					activeLoan(1),
+				).Return(200, nil).Once()
+			},
+		},
+		{
+			name: "should return error if payment amount is not positive",
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.Money{},
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name: "should return error if payment method is unknown",
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(110000),
+				paymentMethod:  "cheque",
+				idempotencyKey: "REF-1",
+			},
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name: "should return error if payment method does not accept the amount",
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(6000000),
+				paymentMethod:  paymentmethod.CashAtAgent,
+				idempotencyKey: "REF-1",
+			},
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name: "should make a payment without an idempotency key without looking for retries",
+			fields: fields{
+				loanRepo:         mockLoanRepository,
+				loanScheduleRepo: mockLoanScheduleRepository,
+				paymentRepo:      mockPaymentRepository,
+			},
+			args: args{
+				loanID:        1,
+				paymentAmount: money.New(110000),
+				paymentMethod: "bank_transfer",
+			},
+			wantPaymentID: 152,
+			wantErr:       false,
+			mock: func() {
+				mockLoanRepository.EXPECT().GetByID(1).Return(activeLoan(1), nil).Once()
+				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return(fiveWeekSchedules(1), nil).Once()
+
+				withoutKey := paymentOf(1, money.New(110000), fullAllocation(3))
+				withoutKey.IdempotencyKey = ""
+				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(
+					withoutKey,
+					[]entity.LoanSchedule{reservedSchedule(3, 1, entity.PaymentStatusOverdue, money.New(110000))},
+					activeLoan(1),
+				).Return(152, nil).Once()
+			},
+		},
+		{
+			name: "should return the original payment when the request is replayed",
+			fields: fields{
+				paymentRepo: mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(110000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantPaymentID: 150,
+			wantErr:       false,
+			mock: func() {
+				original := paymentOf(1, money.New(110000), fullAllocation(3))
+				original.PaymentID = 150
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(original, nil).Once()
+			},
+		},
+		{
+			name: "should return error if a replayed request differs from the original",
+			fields: fields{
+				paymentRepo: mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(120000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantErr: true,
+			mock: func() {
+				original := paymentOf(1, money.New(110000), fullAllocation(3))
+				original.PaymentID = 150
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(original, nil).Once()
+			},
+		},
+		{
+			name: "should return the payment of a concurrent request with the same key",
+			fields: fields{
+				loanRepo:         mockLoanRepository,
+				loanScheduleRepo: mockLoanScheduleRepository,
+				paymentRepo:      mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(110000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantPaymentID: 151,
+			wantErr:       false,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepository.EXPECT().GetByID(1).Return(activeLoan(1), nil).Once()
+				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return(fiveWeekSchedules(1), nil).Once()
+				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(mock.Anything, mock.Anything, mock.Anything).
+					Return(0, repository.ErrAlreadyExists).Once()
+
+				original := paymentOf(1, money.New(110000), fullAllocation(3))
+				original.PaymentID = 151
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(original, nil).Once()
+			},
+		},
+		{
+			name: "should return error if payment repo fail",
+			fields: fields{
+				loanRepo:         mockLoanRepository,
+				loanScheduleRepo: mockLoanScheduleRepository,
+				paymentRepo:      mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(220000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantErr: true,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepository.EXPECT().GetByID(1).Return(activeLoan(1), nil).Once()
+				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return(fiveWeekSchedules(1), nil).Once()
+
+				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(
+					paymentOf(1, money.New(220000), fullAllocation(3), fullAllocation(4)),
+					[]entity.LoanSchedule{
+						reservedSchedule(3, 1, entity.PaymentStatusOverdue, money.New(110000)),
+						reservedSchedule(4, 1, entity.PaymentStatusDue, money.New(110000)),
+					},
+					activeLoan(1),
+				).Return(0, errors.New("failed to create payment")).Once()
+			},
+		},
+		{
+			name: "should reserve the payment without marking schedules as paid",
+			fields: fields{
+				loanRepo:         mockLoanRepository,
+				loanScheduleRepo: mockLoanScheduleRepository,
+				paymentRepo:      mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(220000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantPaymentID: 200,
+			wantErr:       false,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepository.EXPECT().GetByID(1).Return(activeLoan(1), nil).Once()
+				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return(fiveWeekSchedules(1), nil).Once()
+
+				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(
+					paymentOf(1, money.New(220000), fullAllocation(3), fullAllocation(4)),
+					[]entity.LoanSchedule{
+						reservedSchedule(3, 1, entity.PaymentStatusOverdue, money.New(110000)),
+						reservedSchedule(4, 1, entity.PaymentStatusDue, money.New(110000)),
+					},
+					activeLoan(1),
+				).Return(200, nil).Once()
+			},
+		},
+		{
+			name: "should expect a partial payment to go to fees, interest and then principal",
+			fields: fields{
+				loanRepo:         mockLoanRepository,
+				loanScheduleRepo: mockLoanScheduleRepository,
+				paymentRepo:      mockPaymentRepository,
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(135000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantPaymentID: 200,
+			wantErr:       false,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				schedules := fiveWeekSchedules(1)
+				schedules[2].FeeAmount = money.New(5000)
+				schedules[2].TotalDue = money.New(115000)
+				mockLoanRepository.EXPECT().GetByID(1).Return(activeLoan(1), nil).Once()
+				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return(schedules, nil).Once()
+
+				withFee := reservedSchedule(3, 1, entity.PaymentStatusOverdue, money.New(115000))
+				withFee.FeeAmount = money.New(5000)
+				withFee.TotalDue = money.New(115000)
+				partial := reservedSchedule(4, 1, entity.PaymentStatusDue, money.New(20000))
+
+				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(
+					paymentOf(1, money.New(135000),
+						entity.PaymentAllocation{
+							ScheduleID:      3,
+							PrincipalAmount: money.New(100000),
+							InterestAmount:  money.New(10000),
+							FeeAmount:       money.New(5000),
+						},
+						entity.PaymentAllocation{
+							ScheduleID:      4,
+							PrincipalAmount: money.New(10000),
+							InterestAmount:  money.New(10000),
+						},
+					),
+					[]entity.LoanSchedule{withFee, partial},
+					activeLoan(1),
+				).Return(200, nil).Once()
+			},
+		},
+		{
+			name: "should expect the payment to go in the configured order",
+			fields: fields{
+				loanRepo:         mockLoanRepository,
+				loanScheduleRepo: mockLoanScheduleRepository,
+				paymentRepo:      mockPaymentRepository,
+				allocationOrder: []entity.AllocationComponent{
+					entity.AllocationComponentPrincipal,
+					entity.AllocationComponentInterest,
+				},
+			},
+			args: args{
+				loanID:         1,
+				paymentAmount:  money.New(50000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantPaymentID: 200,
+			wantErr:       false,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				schedules := fiveWeekSchedules(1)
+				schedules[2].PaymentStatus = entity.PaymentStatusOverdue
+				mockLoanRepository.EXPECT().GetByID(1).Return(activeLoan(1), nil).Once()
+				mockLoanScheduleRepository.EXPECT().GetByLoanID(1).Return(schedules, nil).Once()
+
+				partial := reservedSchedule(3, 1, entity.PaymentStatusOverdue, money.New(50000))
+
+				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(
+					paymentOf(1, money.New(50000), entity.PaymentAllocation{
+						ScheduleID:      3,
+						PrincipalAmount: money.New(50000),
+					}),
+					[]entity.LoanSchedule{partial},
+					activeLoan(1),
+				).Return(200, nil).Once()
+			},
+		},
+		{
+			name: "should reserve exactly with non-integral amounts",
+			fields: fields{
+				loanRepo:         mockLoanRepository,
+				loanScheduleRepo: mockLoanScheduleRepository,
+				paymentRepo:      mockPaymentRepository,
+			},
+			args: args{
+				loanID:         2,
+				paymentAmount:  money.MustParse("733333.32"),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantPaymentID: 201,
+			wantErr:       false,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				thirds := func(scheduleID int, status string) entity.LoanSchedule {
+					return entity.LoanSchedule{
+						ScheduleID:      scheduleID,
+						LoanID:          2,
+						PrincipalAmount: money.MustParse("333333.33"),
+						InterestAmount:  money.MustParse("33333.33"),
+						TotalDue:        money.MustParse("366666.66"),
+						PaymentStatus:   status,
+					}
+				}
+				reservedThirds := func(scheduleID int, status string) entity.LoanSchedule {
+					schedule := thirds(scheduleID, status)
+					schedule.ReservedAmount = schedule.TotalDue
+					return schedule
+				}
+				last := entity.LoanSchedule{
+					ScheduleID:      8,
+					LoanID:          2,
+					PrincipalAmount: money.MustParse("333333.34"),
+					InterestAmount:  money.MustParse("33333.34"),
+					TotalDue:        money.MustParse("366666.68"),
+					PaymentStatus:   entity.PaymentStatusUnspecified,
+				}
+				mockLoanRepository.EXPECT().GetByID(2).Return(activeLoan(2), nil).Once()
+				mockLoanScheduleRepository.EXPECT().GetByLoanID(2).Return([]entity.LoanSchedule{
+					thirds(6, entity.PaymentStatusOverdue),
+					thirds(7, entity.PaymentStatusDue),
+					last,
+				}, nil).Once()
+
+				allocation := func(scheduleID int) entity.PaymentAllocation {
+					return entity.PaymentAllocation{
+						ScheduleID:      scheduleID,
+						PrincipalAmount: money.MustParse("333333.33"),
+						InterestAmount:  money.MustParse("33333.33"),
+					}
+				}
+				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(
+					paymentOf(2, money.MustParse("733333.32"), allocation(6), allocation(7)),
+					[]entity.LoanSchedule{
+						reservedThirds(6, entity.PaymentStatusOverdue),
+						reservedThirds(7, entity.PaymentStatusDue),
+					},
+					activeLoan(2),
+				).Return(201, nil).Once()
+			},
+		},
+		{
+			name: "should expect the payment to go after what earlier payments reserved",
+			fields: fields{
+				loanRepo:         mockLoanRepository,
+				loanScheduleRepo: mockLoanScheduleRepository,
+				paymentRepo:      mockPaymentRepository,
+			},
+			args: args{
+				loanID:         3,
+				paymentAmount:  money.New(100000),
+				paymentMethod:  "bank_transfer",
+				idempotencyKey: "REF-1",
+			},
+			wantPaymentID: 202,
+			wantErr:       false,
+			mock: func() {
+				mockPaymentRepository.EXPECT().GetByIdempotencyKey("REF-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepository.EXPECT().GetByID(3).Return(activeLoan(3), nil).Once()
+				mockLoanScheduleRepository.EXPECT().GetByLoanID(3).Return([]entity.LoanSchedule{
+					reservedSchedule(9, 3, entity.PaymentStatusOverdue, money.New(50000)),
+					newSchedule(10, 3, entity.PaymentStatusDue),
+				}, nil).Once()
+
+				mockPaymentRepository.EXPECT().CreatePaymentAndUpdateLoanSchedules(
+					paymentOf(3, money.New(100000),
+						entity.PaymentAllocation{
+							ScheduleID:      9,
+							PrincipalAmount: money.New(60000),
+						},
+						entity.PaymentAllocation{
+							ScheduleID:      10,
+							PrincipalAmount: money.New(30000),
+							InterestAmount:  money.New(10000),
+						},
+					),
+					[]entity.LoanSchedule{
+						reservedSchedule(9, 3, entity.PaymentStatusOverdue, money.New(110000)),
+						reservedSchedule(10, 3, entity.PaymentStatusDue, money.New(40000)),
+					},
+					activeLoan(3),
+				).Return(202, nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt.mock()
+
+		t.Run(tt.name, func(t *testing.T) {
+			s := &LoanService{
+				loanRepo:         tt.fields.loanRepo,
+				loanScheduleRepo: tt.fields.loanScheduleRepo,
+				paymentRepo:      tt.fields.paymentRepo,
+				eventPublisher:   tt.fields.eventPublisher,
+				allocationOrder:  tt.fields.allocationOrder,
+				paymentMethods:   paymentmethod.Default(),
+				timeNow: func() time.Time {
+					return time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC)
+				},
+			}
+			got, err := s.MakePayment(tt.args.loanID, tt.args.paymentAmount, tt.args.paymentMethod, tt.args.idempotencyKey)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MakePayment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.PaymentID != tt.wantPaymentID {
+				t.Errorf("MakePayment() got payment %v, want %v", got.PaymentID, tt.wantPaymentID)
+			}
+		})
+	}
+}
+
+func TestLoanService_RefundCreditBalance(t *testing.T) {
+	var (
+		mockLoanRepository    = mocks.NewLoanRepository(t)
+		mockPaymentRepository = mocks.NewPaymentRepository(t)
+	)
+
+	closedDate := time.Date(2024, time.October, 21, 0, 0, 0, 0, time.UTC)
+	closedLoan := func(credit money.Money) entity.Loan {
+		loan := entity.Loan{
+			LoanID:        1,
+			BorrowerID:    10,
+			LoanStatus:    entity.LoanStatusActive,
+			CreditBalance: credit,
+		}
+		loan.Close(closedDate)
+		return loan
+	}
+
+	type fields struct {
+		loanRepo    repository.LoanRepository
+		paymentRepo repository.PaymentRepository
+	}
+	type args struct {
+		loanID int
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    entity.CreditRefund
+		wantErr bool
+		mock    func()
+	}{
+		{
+			name: "should return error if loan not found",
+			fields: fields{
+				loanRepo: mockLoanRepository,
+			},
+			args: args{
+				loanID: 1,
+			},
+			wantErr: true,
+			mock: func() {
+				mockLoanRepository.EXPECT().GetByID(1).Return(entity.Loan{}, repository.ErrNotFound).Once()
+			},
+		},
+		{
+			name: "should return error if loan is still active",
+			fields: fields{
+				loanRepo: mockLoanRepository,
+			},
+			args: args{
+				loanID: 1,
+			},
+			wantErr: true,
+			mock: func() {
+				mockLoanRepository.EXPECT().GetByID(1).Return(entity.Loan{
+					LoanID:        1,
+					LoanStatus:    entity.LoanStatusActive,
+					CreditBalance: money.New(10000),
+				}, nil).Once()
+			},
+		},
+		{
+			name: "should return error if there is no credit balance",
+			fields: fields{
+				loanRepo: mockLoanRepository,
+			},
+			args: args{
+				loanID: 1,
+			},
+			wantErr: true,
+			mock: func() {
+				mockLoanRepository.EXPECT().GetByID(1).Return(closedLoan(money.Money{}), nil).Once()
+			},
+		},
+		{
+			name: "should return error if payment repo fail",
+			fields: fields{
+				loanRepo:    mockLoanRepository,
+				paymentRepo: mockPaymentRepository,
+			},
+			args: args{
+				loanID: 1,
+			},
+			wantErr: true,
+			mock: func() {
+				mockLoanRepository.EXPECT().GetByID(1).Return(closedLoan(money.New(10000)), nil).Once()
+				mockPaymentRepository.EXPECT().CreateCreditRefund(mock.Anything, mock.Anything).
+					Return(0, errors.New("failed to create refund")).Once()
+			},
+		},
+		{
+			name: "should refund the whole credit balance",
+			fields: fields{
+				loanRepo:    mockLoanRepository,
+				paymentRepo: mockPaymentRepository,
+			},
+			args: args{
+				loanID: 1,
+			},
+			want: entity.CreditRefund{
+				RefundID:   5,
+				LoanID:     1,
+				RefundDate: time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
+				Amount:     money.New(10000),
+			},
+			wantErr: false,
+			mock: func() {
+				mockLoanRepository.EXPECT().GetByID(1).Return(closedLoan(money.New(10000)), nil).Once()
+				mockPaymentRepository.EXPECT().CreateCreditRefund(entity.CreditRefund{
+					LoanID:     1,
+					RefundDate: time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
+					Amount:     money.New(10000),
+				}, closedLoan(money.Money{})).Return(5, nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:    tt.fields.loanRepo,
+				paymentRepo: tt.fields.paymentRepo,
+				timeNow: func() time.Time {
+					return time.Date(2024, time.October, 28, 9, 0, 0, 0, time.UTC)
+				},
+			}
+			got, err := s.RefundCreditBalance(tt.args.loanID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RefundCreditBalance() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RefundCreditBalance() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
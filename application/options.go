@@ -0,0 +1,53 @@
+package application
+
+import (
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/paymentmethod"
+	"github.com/iqbalbachmid/billing-engine/domain/product"
+)
+
+// Option configures optional behaviour of a LoanService.
+type Option func(*LoanService)
+
+// WithAllocationOrder sets the order in which a payment is applied to the
+// components of each schedule. Components left out are allocated last, in
+// their default order.
+func WithAllocationOrder(order ...entity.AllocationComponent) Option {
+	return func(s *LoanService) {
+		s.allocationOrder = order
+	}
+}
+
+// WithPaymentMethods replaces the default registry of methods MakePayment
+// accepts.
+func WithPaymentMethods(registry *paymentmethod.Registry) Option {
+	return func(s *LoanService) {
+		s.paymentMethods = registry
+	}
+}
+
+// WithProducts replaces the default catalog of products loans can be
+// originated under.
+func WithProducts(catalog *product.Catalog) Option {
+	return func(s *LoanService) {
+		s.products = catalog
+	}
+}
+
+// WithCalendar sets the holidays due dates are rolled off and that postpone
+// when an installment becomes overdue. Without one only weekends are not
+// business days.
+func WithCalendar(holidays *calendar.Calendar) Option {
+	return func(s *LoanService) {
+		s.calendar = holidays
+	}
+}
+
+// WithPayoffQuoteValidity sets for how many days after the day it is made for
+// PayOff honours a payoff quote. It defaults to a week.
+func WithPayoffQuoteValidity(days int) Option {
+	return func(s *LoanService) {
+		s.payoffQuoteValidity = days
+	}
+}
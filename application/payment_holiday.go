@@ -0,0 +1,148 @@
+package application
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/schedule"
+)
+
+// GrantPaymentHoliday defers every unpaid installment of the loan falling due
+// on or after startDate by the given number of installments: each takes the
+// due date of the unpaid installment that many places later, and the last ones
+// take the due dates the loan's installments would continue on after its
+// current end. Installments already paid ahead keep their due dates. If the
+// loan's product charges DeferralInterest, interest on the deferred principal
+// for the time the first deferred installment is pushed back, accrued like the
+// loan's own interest, is added to that installment. Statuses are derived
+// again, so deferred installments are no longer overdue, and the borrower's
+// account status is synced. Who approved the holiday and why is recorded with
+// it.
+func (s *LoanService) GrantPaymentHoliday(
+	loanID int,
+	startDate time.Time,
+	installments int,
+	approvedBy string,
+	reason string,
+) (entity.PaymentHoliday, error) {
+	if installments <= 0 {
+		return entity.PaymentHoliday{}, errors.New("payment holiday must defer at least one installment")
+	}
+	if strings.TrimSpace(approvedBy) == "" {
+		return entity.PaymentHoliday{}, errors.New("payment holiday approver is required")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return entity.PaymentHoliday{}, errors.New("payment holiday reason is required")
+	}
+
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return entity.PaymentHoliday{}, err
+	}
+	if !loan.IsPayable() {
+		return entity.PaymentHoliday{}, &LoanNotPayableError{LoanID: loanID, Status: loan.LoanStatus}
+	}
+	loanProduct, err := s.products.Get(loan.ProductCode)
+	if err != nil {
+		return entity.PaymentHoliday{}, err
+	}
+
+	schedules, err := s.loanScheduleRepo.GetByLoanID(loanID)
+	if err != nil {
+		return entity.PaymentHoliday{}, err
+	}
+
+	startDate = startOfDay(startDate)
+	first := len(schedules)
+	for i, loanSchedule := range schedules {
+		if !loanSchedule.DueDate.Before(startDate) {
+			first = i
+			break
+		}
+	}
+	var unpaid []int
+	var deferredPrincipal money.Money
+	for i := first; i < len(schedules); i++ {
+		if schedules[i].IsPaid() {
+			continue
+		}
+		unpaid = append(unpaid, i)
+		deferredPrincipal = deferredPrincipal.Add(schedules[i].PrincipalAmount.Sub(schedules[i].PrincipalPaid))
+	}
+	if len(unpaid) == 0 {
+		return entity.PaymentHoliday{}, errors.New("loan has no unpaid installments to defer")
+	}
+
+	terms, err := s.scheduleTerms(loan)
+	if err != nil {
+		return entity.PaymentHoliday{}, err
+	}
+	later, err := schedule.Following(terms, schedules[len(schedules)-1].DueDate, installments, s.calendar, loanProduct.BusinessDay)
+	if err != nil {
+		return entity.PaymentHoliday{}, err
+	}
+	dueDates := make([]time.Time, 0, len(unpaid)+installments)
+	for _, i := range unpaid {
+		dueDates = append(dueDates, schedules[i].DueDate)
+	}
+	dueDates = append(dueDates, later...)
+
+	holiday := entity.PaymentHoliday{
+		LoanID:       loanID,
+		StartDate:    startDate,
+		Installments: installments,
+		ApprovedBy:   approvedBy,
+		Reason:       reason,
+		GrantedDate:  s.today(),
+	}
+	if loanProduct.DeferralInterest && terms.Tenor > 0 {
+		firstUnpaid := &schedules[unpaid[0]]
+		rate, err := schedule.AccrualRate(terms, firstUnpaid.DueDate, dueDates[installments], installments)
+		if err != nil {
+			return entity.PaymentHoliday{}, err
+		}
+		holiday.ExtraInterest = deferredPrincipal.MulRat(rate, money.RoundHalfUp)
+		firstUnpaid.InterestAmount = firstUnpaid.InterestAmount.Add(holiday.ExtraInterest)
+		firstUnpaid.TotalDue = firstUnpaid.TotalDue.Add(holiday.ExtraInterest)
+	}
+
+	for j, i := range unpaid {
+		schedules[i].DueDate = dueDates[j+installments]
+	}
+	now := s.timeNow()
+	deferred := make([]entity.LoanSchedule, 0, len(unpaid))
+	for _, i := range unpaid {
+		// a deferred installment may now fall due after one paid ahead
+		periodStart := terms.LoanStartDate
+		for _, other := range schedules {
+			if other.DueDate.Before(schedules[i].DueDate) && other.DueDate.After(periodStart) {
+				periodStart = other.DueDate
+			}
+		}
+		schedules[i].PaymentStatus = s.statusAt(schedules[i], periodStart, now)
+		deferred = append(deferred, schedules[i])
+	}
+	loan.LoanEndDate = dueDates[len(dueDates)-1]
+	s.warnUnlistedHolidays(loanID, deferred)
+
+	if holiday.HolidayID, err = s.loanScheduleRepo.CreatePaymentHoliday(holiday, deferred, loan); err != nil {
+		return entity.PaymentHoliday{}, err
+	}
+
+	s.syncBorrower(loan.BorrowerID)
+
+	return holiday, nil
+}
+
+// GetPaymentHolidays returns the payment holidays granted on the loan, oldest
+// first.
+func (s *LoanService) GetPaymentHolidays(loanID int) ([]entity.PaymentHoliday, error) {
+	if _, err := s.loanRepo.GetByID(loanID); err != nil {
+		return nil, err
+	}
+
+	return s.loanScheduleRepo.GetPaymentHolidaysByLoanID(loanID)
+}
@@ -0,0 +1,220 @@
+package application
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/delinquency"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/product"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoanService_GrantPaymentHoliday(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		loan, _              = payoffFixture()
+		startDate            = time.Date(2024, time.November, 11, 0, 0, 0, 0, time.UTC)
+		today                = time.Date(2024, time.November, 15, 0, 0, 0, 0, time.UTC)
+		deferred             []entity.LoanSchedule
+	)
+	// a fresh copy for every case, as the holiday defers them in place
+	overdueSchedules := func() []entity.LoanSchedule {
+		_, schedules := payoffFixture()
+		schedules[1].PaymentStatus = entity.PaymentStatusOverdue
+		return schedules
+	}
+
+	deferralInterest, err := product.NewCatalog(product.Product{
+		Code:             product.Standard,
+		Delinquency:      delinquency.TotalOverdue{Limit: 2},
+		DeferralInterest: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withDueDates := func(interest money.Money) []entity.LoanSchedule {
+		want := overdueSchedules()[1:]
+		want[0].DueDate = time.Date(2024, time.November, 25, 0, 0, 0, 0, time.UTC)
+		want[0].PaymentStatus = entity.PaymentStatusDue
+		want[0].InterestAmount = want[0].InterestAmount.Add(interest)
+		want[0].TotalDue = want[0].TotalDue.Add(interest)
+		want[1].DueDate = time.Date(2024, time.December, 2, 0, 0, 0, 0, time.UTC)
+		want[1].PaymentStatus = entity.PaymentStatusUnspecified
+		want[2].DueDate = time.Date(2024, time.December, 9, 0, 0, 0, 0, time.UTC)
+		want[2].PaymentStatus = entity.PaymentStatusUnspecified
+		return want
+	}
+
+	tests := []struct {
+		name         string
+		startDate    time.Time
+		approvedBy   string
+		products     *product.Catalog
+		want         entity.PaymentHoliday
+		wantDeferred []entity.LoanSchedule
+		wantErr      bool
+		mock         func()
+	}{
+		{
+			name:      "should return error if approver is missing",
+			startDate: startDate,
+			wantErr:   true,
+			mock:      func() {},
+		},
+		{
+			name:       "should return error if no unpaid installment falls due from the start date",
+			startDate:  time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+			approvedBy: "branch manager",
+			wantErr:    true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(overdueSchedules(), nil).Once()
+			},
+		},
+		{
+			name:       "should defer installments so they are no longer overdue",
+			startDate:  startDate,
+			approvedBy: "branch manager",
+			want: entity.PaymentHoliday{
+				HolidayID:    5,
+				LoanID:       100,
+				StartDate:    startDate,
+				Installments: 2,
+				ApprovedBy:   "branch manager",
+				Reason:       "harvest gap",
+				GrantedDate:  today,
+			},
+			wantDeferred: withDueDates(money.Money{}),
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(overdueSchedules(), nil).Once()
+			},
+		},
+		{
+			name:       "should charge interest for the deferred periods if the product does",
+			startDate:  startDate,
+			approvedBy: "branch manager",
+			products:   deferralInterest,
+			want: entity.PaymentHoliday{
+				HolidayID:     5,
+				LoanID:        100,
+				StartDate:     startDate,
+				Installments:  2,
+				ExtraInterest: money.New(37500),
+				ApprovedBy:    "branch manager",
+				Reason:        "harvest gap",
+				GrantedDate:   today,
+			},
+			wantDeferred: withDueDates(money.New(37500)),
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(overdueSchedules(), nil).Once()
+			},
+		},
+		{
+			name:       "should charge interest for the deferred periods on the terms of the latest restructure",
+			startDate:  startDate,
+			approvedBy: "branch manager",
+			products:   deferralInterest,
+			want: entity.PaymentHoliday{
+				HolidayID:     5,
+				LoanID:        100,
+				StartDate:     startDate,
+				Installments:  2,
+				ExtraInterest: money.New(20000),
+				ApprovedBy:    "branch manager",
+				Reason:        "harvest gap",
+				GrantedDate:   today,
+			},
+			wantDeferred: withDueDates(money.New(20000)),
+			mock: func() {
+				restructured := loan
+				restructured.Restructured = true
+				mockLoanRepo.EXPECT().GetByID(100).Return(restructured, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(overdueSchedules(), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetRestructuresByLoanID(100).Return([]entity.LoanRestructure{{
+					RestructureID: 3,
+					LoanID:        100,
+					EffectiveDate: time.Date(2024, time.November, 4, 0, 0, 0, 0, time.UTC),
+					Tenor:         3,
+					InterestRate:  4,
+				}}, nil).Once()
+			},
+		},
+		{
+			name:       "should leave installments paid ahead where they are",
+			startDate:  startDate,
+			approvedBy: "branch manager",
+			want: entity.PaymentHoliday{
+				HolidayID:    5,
+				LoanID:       100,
+				StartDate:    startDate,
+				Installments: 2,
+				ApprovedBy:   "branch manager",
+				Reason:       "harvest gap",
+				GrantedDate:  today,
+			},
+			wantDeferred: func() []entity.LoanSchedule {
+				want := overdueSchedules()
+				want[1].DueDate = time.Date(2024, time.December, 2, 0, 0, 0, 0, time.UTC)
+				want[1].PaymentStatus = entity.PaymentStatusUnspecified
+				want[3].DueDate = time.Date(2024, time.December, 9, 0, 0, 0, 0, time.UTC)
+				want[3].PaymentStatus = entity.PaymentStatusUnspecified
+				return []entity.LoanSchedule{want[1], want[3]}
+			}(),
+			mock: func() {
+				paidAhead := overdueSchedules()
+				paidAhead[2].PrincipalPaid = paidAhead[2].PrincipalAmount
+				paidAhead[2].InterestPaid = paidAhead[2].InterestAmount
+				paidAhead[2].PaymentStatus = entity.PaymentStatusPaid
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(paidAhead, nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			deferred = nil
+			if !tt.wantErr {
+				mockLoanScheduleRepo.EXPECT().CreatePaymentHoliday(mock.Anything, mock.Anything, mock.Anything).
+					RunAndReturn(func(_ entity.PaymentHoliday, schedules []entity.LoanSchedule, loan entity.Loan) (int, error) {
+						if !loan.LoanEndDate.Equal(time.Date(2024, time.December, 9, 0, 0, 0, 0, time.UTC)) {
+							t.Errorf("GrantPaymentHoliday() loan end date = %v, want 2024-12-09", loan.LoanEndDate)
+						}
+						deferred = schedules
+						return 5, nil
+					}).Once()
+			}
+
+			s := &LoanService{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				products:         product.Default(),
+				timeNow: func() time.Time {
+					return time.Date(2024, time.November, 15, 9, 0, 0, 0, time.UTC)
+				},
+			}
+			if tt.products != nil {
+				s.products = tt.products
+			}
+			got, err := s.GrantPaymentHoliday(100, tt.startDate, 2, tt.approvedBy, "harvest gap")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GrantPaymentHoliday() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GrantPaymentHoliday() got = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(deferred, tt.wantDeferred) {
+				t.Errorf("GrantPaymentHoliday() deferred = %+v, want %+v", deferred, tt.wantDeferred)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/event"
+)
+
+// ReversePayment undoes a completed payment, e.g. a bounced bank transfer or a
+// chargeback. What the payment allocated is taken back from its schedules,
+// whose statuses are derived again from the clock, and what it added to the
+// credit balance is taken back from the loan. If that credit has already been
+// applied to later schedules, those credit balance payments are reversed as
+// well, newest first. A loan the payment closed is reopened. Payments made
+// towards schedules a restructure has since superseded cannot be reversed.
+func (s *LoanService) ReversePayment(paymentID int, reason string) (entity.PaymentReversal, error) {
+	if strings.TrimSpace(reason) == "" {
+		return entity.PaymentReversal{}, errors.New("reversal reason is required")
+	}
+
+	payment, err := s.paymentRepo.GetByID(paymentID)
+	if err != nil {
+		return entity.PaymentReversal{}, err
+	}
+	if payment.IsReversed() {
+		return entity.PaymentReversal{}, ErrPaymentAlreadyReversed
+	}
+	if !payment.IsCompleted() {
+		return entity.PaymentReversal{}, ErrPaymentNotCompleted
+	}
+	if payment.PaymentMethod == entity.PaymentMethodCreditBalance {
+		return entity.PaymentReversal{}, errors.New("credit balance payments are reversed with the payment that funded them")
+	}
+
+	loan, err := s.loanRepo.GetByID(payment.LoanID)
+	if err != nil {
+		return entity.PaymentReversal{}, err
+	}
+
+	schedules, err := s.loanScheduleRepo.GetByLoanID(payment.LoanID)
+	if err != nil {
+		return entity.PaymentReversal{}, err
+	}
+
+	reversed := []entity.Payment{payment}
+	credit := payment.AmountPaid.Sub(payment.AllocatedAmount())
+	if credit.GreaterThan(loan.CreditBalance) {
+		creditPayments, err := s.creditPaymentsSince(payment)
+		if err != nil {
+			return entity.PaymentReversal{}, err
+		}
+		for _, creditPayment := range creditPayments {
+			if !credit.GreaterThan(loan.CreditBalance) {
+				break
+			}
+			reversed = append(reversed, creditPayment)
+			loan.CreditBalance = loan.CreditBalance.Add(creditPayment.AmountPaid)
+		}
+		if credit.GreaterThan(loan.CreditBalance) {
+			return entity.PaymentReversal{}, ErrInsufficientCreditBalance
+		}
+	}
+	loan.CreditBalance = loan.CreditBalance.Sub(credit)
+
+	now := s.timeNow()
+	reversals := make([]entity.PaymentReversal, 0, len(reversed))
+	touched := make(map[int]bool)
+	for _, p := range reversed {
+		for _, allocation := range p.Allocations {
+			schedule := findSchedule(schedules, allocation.ScheduleID)
+			if schedule == nil {
+				return entity.PaymentReversal{}, ErrScheduleSuperseded
+			}
+			schedule.Unallocate(allocation)
+			touched[allocation.ScheduleID] = true
+		}
+
+		reversal := entity.PaymentReversal{
+			PaymentID:    p.PaymentID,
+			ReversalDate: now,
+			Reason:       reason,
+		}
+		if p.PaymentID != payment.PaymentID {
+			reversal.Reason = fmt.Sprintf("funded by reversed payment %d: %s", payment.PaymentID, reason)
+		}
+		reversals = append(reversals, reversal)
+	}
+
+	terms, err := s.scheduleTerms(loan)
+	if err != nil {
+		return entity.PaymentReversal{}, err
+	}
+
+	var loanSchedulesToBeUpdated []entity.LoanSchedule
+	// installments kept from before a restructure do not start the periods
+	// of those replacing them
+	periodStart := terms.LoanStartDate
+	for _, schedule := range schedules {
+		schedule.PaymentStatus = s.statusAt(schedule, periodStart, now)
+		if schedule.DueDate.After(periodStart) {
+			periodStart = schedule.DueDate
+		}
+		if touched[schedule.ScheduleID] {
+			loanSchedulesToBeUpdated = append(loanSchedulesToBeUpdated, schedule)
+		}
+	}
+
+	reopening := loan.IsPaid() && schedulesOutstanding(schedules).IsPositive() && loan.Reopen() == nil
+
+	reversalIDs, err := s.paymentRepo.CreateReversalsAndUpdateLoanSchedules(reversals, loanSchedulesToBeUpdated, loan)
+	if err != nil {
+		return entity.PaymentReversal{}, err
+	}
+
+	if reopening {
+		s.publish(event.LoanReopened{
+			LoanID:       loan.LoanID,
+			BorrowerID:   loan.BorrowerID,
+			ReopenedDate: s.today(),
+		})
+	}
+
+	s.syncBorrower(loan.BorrowerID)
+
+	reversals[0].ReversalID = reversalIDs[0]
+	return reversals[0], nil
+}
+
+// creditPaymentsSince returns the completed credit balance payments made after
+// payment, newest first.
+func (s *LoanService) creditPaymentsSince(payment entity.Payment) ([]entity.Payment, error) {
+	payments, err := s.paymentRepo.GetByLoanID(payment.LoanID)
+	if err != nil {
+		return nil, err
+	}
+
+	var creditPayments []entity.Payment
+	for i := len(payments) - 1; i >= 0; i-- {
+		if payments[i].PaymentID == payment.PaymentID {
+			break
+		}
+		if payments[i].PaymentMethod == entity.PaymentMethodCreditBalance && payments[i].IsCompleted() {
+			creditPayments = append(creditPayments, payments[i])
+		}
+	}
+
+	return creditPayments, nil
+}
@@ -0,0 +1,273 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/event"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	eventmocks "github.com/iqbalbachmid/billing-engine/mocks/domain/event"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoanService_ReversePayment(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		mockPaymentRepo      = mocks.NewPaymentRepository(t)
+		mockEventPublisher   = eventmocks.NewPublisher(t)
+	)
+
+	now := time.Date(2024, time.October, 28, 9, 0, 0, 0, time.UTC)
+	loan := func() entity.Loan {
+		return entity.Loan{
+			LoanID:        1,
+			BorrowerID:    10,
+			LoanStartDate: time.Date(2024, time.October, 7, 0, 0, 0, 0, time.UTC),
+			LoanStatus:    entity.LoanStatusActive,
+		}
+	}
+	// three weekly schedules due on October 14, 21 and 28
+	schedule := func(scheduleID int, status string) entity.LoanSchedule {
+		return entity.LoanSchedule{
+			ScheduleID:      scheduleID,
+			LoanID:          1,
+			DueDate:         time.Date(2024, time.October, 7+7*scheduleID, 0, 0, 0, 0, time.UTC),
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			PaymentStatus:   status,
+		}
+	}
+	paid := func(schedule entity.LoanSchedule, amount money.Money) (entity.LoanSchedule, entity.PaymentAllocation) {
+		allocation, _ := schedule.Allocate(amount, entity.DefaultAllocationOrder)
+		return schedule, allocation
+	}
+	payment := func(paymentID int, amount money.Money, method string, allocations ...entity.PaymentAllocation) entity.Payment {
+		return entity.Payment{
+			PaymentID:     paymentID,
+			LoanID:        1,
+			PaymentDate:   time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC),
+			AmountPaid:    amount,
+			PaymentMethod: method,
+			Status:        entity.StatusCompleted,
+			Allocations:   allocations,
+		}
+	}
+
+	type args struct {
+		paymentID int
+		reason    string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      entity.PaymentReversal
+		wantErr   bool
+		wantErrIs error
+		mock      func()
+	}{
+		{
+			name: "should return error if reason is empty",
+			args: args{
+				paymentID: 1,
+				reason:    " ",
+			},
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name: "should return error if payment not found",
+			args: args{
+				paymentID: 1,
+				reason:    "bounced",
+			},
+			wantErr:   true,
+			wantErrIs: repository.ErrNotFound,
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByID(1).Return(entity.Payment{}, repository.ErrNotFound).Once()
+			},
+		},
+		{
+			name: "should return error if payment is already reversed",
+			args: args{
+				paymentID: 1,
+				reason:    "bounced",
+			},
+			wantErr:   true,
+			wantErrIs: ErrPaymentAlreadyReversed,
+			mock: func() {
+				reversed := payment(1, money.New(110000), "bank_transfer")
+				reversed.Status = entity.StatusReversed
+				mockPaymentRepo.EXPECT().GetByID(1).Return(reversed, nil).Once()
+			},
+		},
+		{
+			name: "should return error if payment was made from credit balance",
+			args: args{
+				paymentID: 2,
+				reason:    "bounced",
+			},
+			wantErr: true,
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByID(2).Return(payment(2, money.New(40000), entity.PaymentMethodCreditBalance), nil).Once()
+			},
+		},
+		{
+			name: "should restore schedules and reopen the loan the payment closed",
+			args: args{
+				paymentID: 1,
+				reason:    "bounced",
+			},
+			want: entity.PaymentReversal{
+				ReversalID:   7,
+				PaymentID:    1,
+				ReversalDate: now,
+				Reason:       "bounced",
+			},
+			mock: func() {
+				s1, a1 := paid(schedule(1, entity.PaymentStatusOverdue), money.New(110000))
+				s2, a2 := paid(schedule(2, entity.PaymentStatusOverdue), money.New(110000))
+				s3, a3 := paid(schedule(3, entity.PaymentStatusDue), money.New(110000))
+				mockPaymentRepo.EXPECT().GetByID(1).Return(payment(1, money.New(330000), "bank_transfer", a1, a2, a3), nil).Once()
+
+				closed := loan()
+				closed.Close(time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC))
+				mockLoanRepo.EXPECT().GetByID(1).Return(closed, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{s1, s2, s3}, nil).Once()
+
+				mockPaymentRepo.EXPECT().CreateReversalsAndUpdateLoanSchedules(
+					[]entity.PaymentReversal{{PaymentID: 1, ReversalDate: now, Reason: "bounced"}},
+					[]entity.LoanSchedule{
+						schedule(1, entity.PaymentStatusOverdue),
+						schedule(2, entity.PaymentStatusOverdue),
+						schedule(3, entity.PaymentStatusDue),
+					},
+					loan(),
+				).Return([]int{7}, nil).Once()
+				mockEventPublisher.EXPECT().Publish(event.LoanReopened{
+					LoanID:       1,
+					BorrowerID:   10,
+					ReopenedDate: time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
+				}).Return(nil).Once()
+			},
+		},
+		{
+			name: "should also reverse credit balance payments the payment funded",
+			args: args{
+				paymentID: 1,
+				reason:    "bounced",
+			},
+			want: entity.PaymentReversal{
+				ReversalID:   8,
+				PaymentID:    1,
+				ReversalDate: now,
+				Reason:       "bounced",
+			},
+			mock: func() {
+				s1, a1 := paid(schedule(1, entity.PaymentStatusDue), money.New(110000))
+				s2, a2 := paid(schedule(2, entity.PaymentStatusDue), money.New(40000))
+				s2.PaymentStatus = entity.PaymentStatusOverdue
+				overpayment := payment(1, money.New(150000), "bank_transfer", a1)
+				credit := payment(2, money.New(40000), entity.PaymentMethodCreditBalance, a2)
+				mockPaymentRepo.EXPECT().GetByID(1).Return(overpayment, nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan(), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+					s1, s2, schedule(3, entity.PaymentStatusDue),
+				}, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(1).Return([]entity.Payment{overpayment, credit}, nil).Once()
+
+				mockPaymentRepo.EXPECT().CreateReversalsAndUpdateLoanSchedules(
+					[]entity.PaymentReversal{
+						{PaymentID: 1, ReversalDate: now, Reason: "bounced"},
+						{PaymentID: 2, ReversalDate: now, Reason: "funded by reversed payment 1: bounced"},
+					},
+					[]entity.LoanSchedule{
+						schedule(1, entity.PaymentStatusOverdue),
+						schedule(2, entity.PaymentStatusOverdue),
+					},
+					loan(),
+				).Return([]int{8, 9}, nil).Once()
+			},
+		},
+		{
+			name: "should return error if the credit funded by the payment was refunded",
+			args: args{
+				paymentID: 1,
+				reason:    "bounced",
+			},
+			wantErr:   true,
+			wantErrIs: ErrInsufficientCreditBalance,
+			mock: func() {
+				s1, a1 := paid(schedule(1, entity.PaymentStatusOverdue), money.New(110000))
+				overpayment := payment(1, money.New(150000), "bank_transfer", a1)
+				mockPaymentRepo.EXPECT().GetByID(1).Return(overpayment, nil).Once()
+
+				closed := loan()
+				closed.Close(time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC))
+				mockLoanRepo.EXPECT().GetByID(1).Return(closed, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{s1}, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(1).Return([]entity.Payment{overpayment}, nil).Once()
+			},
+		},
+		{
+			name: "should return error if a restructure superseded the payment's schedules",
+			args: args{
+				paymentID: 1,
+				reason:    "bounced",
+			},
+			wantErr:   true,
+			wantErrIs: ErrScheduleSuperseded,
+			mock: func() {
+				_, a1 := paid(schedule(1, entity.PaymentStatusOverdue), money.New(50000))
+				mockPaymentRepo.EXPECT().GetByID(1).Return(payment(1, money.New(50000), "bank_transfer", a1), nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan(), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{schedule(4, entity.PaymentStatusUnspecified)}, nil).Once()
+			},
+		},
+		{
+			name: "should return error if payment repo fail",
+			args: args{
+				paymentID: 1,
+				reason:    "bounced",
+			},
+			wantErr:   true,
+			wantErrIs: repository.ErrConcurrentModification,
+			mock: func() {
+				s1, a1 := paid(schedule(1, entity.PaymentStatusOverdue), money.New(110000))
+				mockPaymentRepo.EXPECT().GetByID(1).Return(payment(1, money.New(110000), "bank_transfer", a1), nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan(), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{s1}, nil).Once()
+				mockPaymentRepo.EXPECT().CreateReversalsAndUpdateLoanSchedules(mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, repository.ErrConcurrentModification).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				paymentRepo:      mockPaymentRepo,
+				eventPublisher:   mockEventPublisher,
+				timeNow: func() time.Time {
+					return now
+				},
+			}
+			got, err := s.ReversePayment(tt.args.paymentID, tt.args.reason)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("ReversePayment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ReversePayment() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
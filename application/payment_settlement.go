@@ -0,0 +1,139 @@
+package application
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// ConfirmPayment completes a pending payment once its funds have cleared. The
+// amount reserved on each schedule is applied to it in the configured
+// allocation order, against what is owed at settlement, and whatever it no
+// longer covers goes to the credit balance together with the part of the
+// payment that was never reserved. A payoff reserves more than it pays, the
+// rest having been taken from the credit balance when it was made. A loan
+// with nothing left outstanding is closed and a LoanClosed event is
+// published. The borrower's account status is synced with the loan afterwards.
+func (s *LoanService) ConfirmPayment(paymentID int) (entity.Payment, error) {
+	payment, loan, schedules, err := s.getPendingPayment(paymentID)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	credit := money.Max(payment.AmountPaid.Sub(payment.AllocatedAmount()), money.Money{})
+	for i, reserved := range payment.Allocations {
+		schedule := findSchedule(schedules, reserved.ScheduleID)
+		if schedule == nil {
+			credit = credit.Add(reserved.Total())
+			continue
+		}
+
+		schedule.Release(reserved)
+		applied, leftover := schedule.Allocate(reserved.Total(), s.allocationOrder)
+		applied.AllocationID = reserved.AllocationID
+		applied.PaymentID = reserved.PaymentID
+		applied.InterestRebate = reserved.InterestRebate
+		payment.Allocations[i] = applied
+		credit = credit.Add(leftover)
+	}
+	loan.CreditBalance = loan.CreditBalance.Add(credit)
+	payment.Status = entity.StatusCompleted
+
+	closing := s.closeIfRepaid(&loan, schedules)
+	if err = s.paymentRepo.SettlePayment(payment, allocatedSchedules(payment, schedules), loan); err != nil {
+		return entity.Payment{}, err
+	}
+
+	if closing {
+		s.publishClosed(loan)
+	}
+	s.syncBorrower(loan.BorrowerID)
+
+	return payment, nil
+}
+
+// FailPayment marks a pending payment whose funds did not clear as failed and
+// releases what it reserved on the schedules. A failed payoff also gives back
+// the interest it rebated and the credit balance it took.
+func (s *LoanService) FailPayment(paymentID int, reason string) (entity.Payment, error) {
+	if strings.TrimSpace(reason) == "" {
+		return entity.Payment{}, errors.New("failure reason is required")
+	}
+
+	payment, loan, schedules, err := s.getPendingPayment(paymentID)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	for _, reserved := range payment.Allocations {
+		if schedule := findSchedule(schedules, reserved.ScheduleID); schedule != nil {
+			schedule.Release(reserved)
+			schedule.RestoreInterest(reserved.InterestRebate)
+		}
+	}
+	loan.CreditBalance = loan.CreditBalance.Add(money.Max(payment.AllocatedAmount().Sub(payment.AmountPaid), money.Money{}))
+	payment.Status = entity.StatusFailed
+	payment.FailureReason = reason
+
+	if err = s.paymentRepo.SettlePayment(payment, allocatedSchedules(payment, schedules), loan); err != nil {
+		return entity.Payment{}, err
+	}
+
+	return payment, nil
+}
+
+// GetPendingAmount returns how much has been paid towards the loan but not
+// cleared yet. It is not deducted from GetOutstanding.
+func (s *LoanService) GetPendingAmount(loanID int) (money.Money, error) {
+	payments, err := s.paymentRepo.GetByLoanID(loanID)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	return pendingAmount(payments), nil
+}
+
+func (s *LoanService) getPendingPayment(
+	paymentID int,
+) (entity.Payment, entity.Loan, []entity.LoanSchedule, error) {
+	payment, err := s.paymentRepo.GetByID(paymentID)
+	if err != nil {
+		return entity.Payment{}, entity.Loan{}, nil, err
+	}
+	if !payment.IsPending() {
+		return entity.Payment{}, entity.Loan{}, nil, ErrPaymentNotPending
+	}
+
+	loan, err := s.loanRepo.GetByID(payment.LoanID)
+	if err != nil {
+		return entity.Payment{}, entity.Loan{}, nil, err
+	}
+
+	schedules, err := s.loanScheduleRepo.GetByLoanID(payment.LoanID)
+	if err != nil {
+		return entity.Payment{}, entity.Loan{}, nil, err
+	}
+
+	return payment, loan, schedules, nil
+}
+
+func findSchedule(schedules []entity.LoanSchedule, scheduleID int) *entity.LoanSchedule {
+	for i := range schedules {
+		if schedules[i].ScheduleID == scheduleID {
+			return &schedules[i]
+		}
+	}
+	return nil
+}
+
+func pendingAmount(payments []entity.Payment) money.Money {
+	var pending money.Money
+	for _, payment := range payments {
+		if payment.IsPending() {
+			pending = pending.Add(payment.AmountPaid)
+		}
+	}
+	return pending
+}
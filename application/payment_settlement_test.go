@@ -0,0 +1,378 @@
+package application
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/event"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	eventmocks "github.com/iqbalbachmid/billing-engine/mocks/domain/event"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoanService_ConfirmPayment(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		mockPaymentRepo      = mocks.NewPaymentRepository(t)
+		mockEventPublisher   = eventmocks.NewPublisher(t)
+	)
+
+	today := time.Date(2024, time.October, 29, 0, 0, 0, 0, time.UTC)
+	loan := func() entity.Loan {
+		return entity.Loan{
+			LoanID:     1,
+			BorrowerID: 10,
+			LoanStatus: entity.LoanStatusActive,
+		}
+	}
+	schedule := func(scheduleID int, status string, reserved money.Money) entity.LoanSchedule {
+		return entity.LoanSchedule{
+			ScheduleID:      scheduleID,
+			LoanID:          1,
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			ReservedAmount:  reserved,
+			PaymentStatus:   status,
+		}
+	}
+	paid := func(scheduleID int) entity.LoanSchedule {
+		paid := schedule(scheduleID, entity.PaymentStatusPaid, money.Money{})
+		paid.PrincipalPaid = money.New(100000)
+		paid.InterestPaid = money.New(10000)
+		return paid
+	}
+	allocation := func(allocationID, scheduleID int, principal, interest int64) entity.PaymentAllocation {
+		return entity.PaymentAllocation{
+			AllocationID:    allocationID,
+			PaymentID:       5,
+			ScheduleID:      scheduleID,
+			PrincipalAmount: money.New(principal),
+			InterestAmount:  money.New(interest),
+		}
+	}
+	rebated := func(allocation entity.PaymentAllocation) entity.PaymentAllocation {
+		allocation.InterestRebate = money.New(5000)
+		return allocation
+	}
+	pending := func(amount money.Money, allocations ...entity.PaymentAllocation) entity.Payment {
+		return entity.Payment{
+			PaymentID:     5,
+			LoanID:        1,
+			AmountPaid:    amount,
+			PaymentMethod: "bank_transfer",
+			Status:        entity.StatusPending,
+			Allocations:   allocations,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		want    entity.Payment
+		wantErr error
+		mock    func()
+	}{
+		{
+			name:    "should return error if payment is not pending",
+			wantErr: ErrPaymentNotPending,
+			mock: func() {
+				completed := pending(money.New(110000))
+				completed.Status = entity.StatusCompleted
+				mockPaymentRepo.EXPECT().GetByID(5).Return(completed, nil).Once()
+			},
+		},
+		{
+			name:    "should return error if payment repo fail",
+			wantErr: repository.ErrConcurrentModification,
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByID(5).Return(pending(money.New(110000), allocation(1, 1, 100000, 10000)), nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan(), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+					schedule(1, entity.PaymentStatusOverdue, money.New(110000)),
+				}, nil).Once()
+				mockPaymentRepo.EXPECT().SettlePayment(mock.Anything, mock.Anything, mock.Anything).
+					Return(repository.ErrConcurrentModification).Once()
+			},
+		},
+		{
+			name: "should apply reservations, credit the rest and close the repaid loan",
+			want: func() entity.Payment {
+				completed := pending(money.New(230000), allocation(1, 1, 100000, 10000), allocation(2, 2, 100000, 10000))
+				completed.Status = entity.StatusCompleted
+				return completed
+			}(),
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByID(5).Return(
+					pending(money.New(230000), allocation(1, 1, 100000, 10000), allocation(2, 2, 100000, 10000)), nil,
+				).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan(), nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+					schedule(1, entity.PaymentStatusOverdue, money.New(110000)),
+					schedule(2, entity.PaymentStatusDue, money.New(110000)),
+				}, nil).Once()
+
+				completed := pending(money.New(230000), allocation(1, 1, 100000, 10000), allocation(2, 2, 100000, 10000))
+				completed.Status = entity.StatusCompleted
+				closed := loan()
+				closed.CreditBalance = money.New(10000)
+				closed.Close(today)
+				mockPaymentRepo.EXPECT().SettlePayment(completed, []entity.LoanSchedule{paid(1), paid(2)}, closed).Return(nil).Once()
+				mockEventPublisher.EXPECT().Publish(event.LoanClosed{
+					LoanID:     1,
+					BorrowerID: 10,
+					ClosedDate: today,
+				}).Return(nil).Once()
+			},
+		},
+		{
+			name: "should credit what a schedule paid in the meantime no longer needs",
+			want: func() entity.Payment {
+				completed := pending(money.New(60000), allocation(1, 1, 50000, 0))
+				completed.Status = entity.StatusCompleted
+				return completed
+			}(),
+			mock: func() {
+				// reserved as interest and principal, but interest was paid
+				// from the credit balance before the payment cleared
+				mockPaymentRepo.EXPECT().GetByID(5).Return(pending(money.New(60000), allocation(1, 1, 50000, 10000)), nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan(), nil).Once()
+				partial := schedule(1, entity.PaymentStatusOverdue, money.New(60000))
+				partial.InterestPaid = money.New(10000)
+				partial.PrincipalPaid = money.New(50000)
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+					partial,
+					schedule(2, entity.PaymentStatusDue, money.Money{}),
+				}, nil).Once()
+
+				completed := pending(money.New(60000), allocation(1, 1, 50000, 0))
+				completed.Status = entity.StatusCompleted
+				withCredit := loan()
+				withCredit.CreditBalance = money.New(10000)
+				mockPaymentRepo.EXPECT().SettlePayment(completed, []entity.LoanSchedule{paid(1)}, withCredit).Return(nil).Once()
+			},
+		},
+		{
+			name: "should close a paid off loan and keep the interest rebated by the payoff",
+			want: func() entity.Payment {
+				completed := pending(money.New(200000), rebated(allocation(1, 1, 100000, 5000)), rebated(allocation(2, 2, 100000, 5000)))
+				completed.Status = entity.StatusCompleted
+				return completed
+			}(),
+			mock: func() {
+				// the payoff took 10000 of the quote from the credit balance
+				mockPaymentRepo.EXPECT().GetByID(5).Return(
+					pending(money.New(200000), rebated(allocation(1, 1, 100000, 5000)), rebated(allocation(2, 2, 100000, 5000))), nil,
+				).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan(), nil).Once()
+				reserved := func(scheduleID int, status string) entity.LoanSchedule {
+					schedule := schedule(scheduleID, status, money.New(105000))
+					schedule.RebateInterest(money.New(5000))
+					return schedule
+				}
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{
+					reserved(1, entity.PaymentStatusDue),
+					reserved(2, entity.PaymentStatusUnspecified),
+				}, nil).Once()
+
+				completed := pending(money.New(200000), rebated(allocation(1, 1, 100000, 5000)), rebated(allocation(2, 2, 100000, 5000)))
+				completed.Status = entity.StatusCompleted
+				settled := func(scheduleID int) entity.LoanSchedule {
+					settled := paid(scheduleID)
+					settled.RebateInterest(money.New(5000))
+					settled.InterestPaid = money.New(5000)
+					return settled
+				}
+				closed := loan()
+				closed.Close(today)
+				mockPaymentRepo.EXPECT().SettlePayment(completed, []entity.LoanSchedule{settled(1), settled(2)}, closed).Return(nil).Once()
+				mockEventPublisher.EXPECT().Publish(event.LoanClosed{
+					LoanID:     1,
+					BorrowerID: 10,
+					ClosedDate: today,
+				}).Return(nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				paymentRepo:      mockPaymentRepo,
+				eventPublisher:   mockEventPublisher,
+				allocationOrder:  entity.DefaultAllocationOrder,
+				timeNow: func() time.Time {
+					return today.Add(10 * time.Hour)
+				},
+			}
+			got, err := s.ConfirmPayment(5)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ConfirmPayment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConfirmPayment() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoanService_FailPayment(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		mockPaymentRepo      = mocks.NewPaymentRepository(t)
+	)
+
+	loan := entity.Loan{LoanID: 1, LoanStatus: entity.LoanStatusActive}
+	schedule := func(reserved money.Money) entity.LoanSchedule {
+		return entity.LoanSchedule{
+			ScheduleID:      1,
+			LoanID:          1,
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			ReservedAmount:  reserved,
+			PaymentStatus:   entity.PaymentStatusOverdue,
+		}
+	}
+	pending := entity.Payment{
+		PaymentID:     5,
+		LoanID:        1,
+		AmountPaid:    money.New(50000),
+		PaymentMethod: "bank_transfer",
+		Status:        entity.StatusPending,
+		Allocations: []entity.PaymentAllocation{
+			{AllocationID: 1, PaymentID: 5, ScheduleID: 1, PrincipalAmount: money.New(40000), InterestAmount: money.New(10000)},
+		},
+	}
+	payoff := entity.Payment{
+		PaymentID:     5,
+		LoanID:        1,
+		AmountPaid:    money.New(90000),
+		PaymentMethod: "bank_transfer",
+		Status:        entity.StatusPending,
+		Allocations: []entity.PaymentAllocation{
+			{
+				AllocationID:    1,
+				PaymentID:       5,
+				ScheduleID:      1,
+				PrincipalAmount: money.New(100000),
+				InterestAmount:  money.New(5000),
+				InterestRebate:  money.New(5000),
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		reason  string
+		want    entity.Payment
+		wantErr bool
+		mock    func()
+	}{
+		{
+			name:    "should return error if reason is empty",
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name:    "should return error if payment not found",
+			reason:  "insufficient funds",
+			wantErr: true,
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByID(5).Return(entity.Payment{}, repository.ErrNotFound).Once()
+			},
+		},
+		{
+			name:   "should release what the payment reserved",
+			reason: "insufficient funds",
+			want: func() entity.Payment {
+				failed := pending
+				failed.Status = entity.StatusFailed
+				failed.FailureReason = "insufficient funds"
+				return failed
+			}(),
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByID(5).Return(pending, nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{schedule(money.New(80000))}, nil).Once()
+
+				failed := pending
+				failed.Status = entity.StatusFailed
+				failed.FailureReason = "insufficient funds"
+				mockPaymentRepo.EXPECT().SettlePayment(failed, []entity.LoanSchedule{schedule(money.New(30000))}, loan).Return(nil).Once()
+			},
+		},
+		{
+			name:   "should give back the interest and credit balance a failed payoff took",
+			reason: "insufficient funds",
+			want: func() entity.Payment {
+				failed := payoff
+				failed.Status = entity.StatusFailed
+				failed.FailureReason = "insufficient funds"
+				return failed
+			}(),
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByID(5).Return(payoff, nil).Once()
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan, nil).Once()
+				rebated := schedule(money.New(105000))
+				rebated.RebateInterest(money.New(5000))
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return([]entity.LoanSchedule{rebated}, nil).Once()
+
+				failed := payoff
+				failed.Status = entity.StatusFailed
+				failed.FailureReason = "insufficient funds"
+				withCredit := loan
+				withCredit.CreditBalance = money.New(15000)
+				mockPaymentRepo.EXPECT().SettlePayment(failed, []entity.LoanSchedule{schedule(money.Money{})}, withCredit).Return(nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				paymentRepo:      mockPaymentRepo,
+			}
+			got, err := s.FailPayment(5, tt.reason)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FailPayment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FailPayment() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoanService_GetPendingAmount(t *testing.T) {
+	mockPaymentRepo := mocks.NewPaymentRepository(t)
+	mockPaymentRepo.EXPECT().GetByLoanID(1).Return([]entity.Payment{
+		{PaymentID: 1, AmountPaid: money.New(110000), Status: entity.StatusCompleted},
+		{PaymentID: 2, AmountPaid: money.New(50000), Status: entity.StatusPending},
+		{PaymentID: 3, AmountPaid: money.New(70000), Status: entity.StatusFailed},
+		{PaymentID: 4, AmountPaid: money.New(20000), Status: entity.StatusPending},
+	}, nil).Once()
+
+	s := &LoanService{paymentRepo: mockPaymentRepo}
+	got, err := s.GetPendingAmount(1)
+	if err != nil {
+		t.Fatalf("GetPendingAmount() error = %v", err)
+	}
+	if want := money.New(70000); got != want {
+		t.Errorf("GetPendingAmount() got = %v, want %v", got, want)
+	}
+}
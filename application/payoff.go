@@ -0,0 +1,277 @@
+package application
+
+import (
+	"errors"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/daycount"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+)
+
+const defaultPayoffQuoteValidity = 7
+
+// PayoffQuote is what it takes to pay a loan off early.
+type PayoffQuote struct {
+	LoanID int
+	// AsOf is the day the quote is made for and ExpiresOn the last day
+	// PayOff honours it.
+	AsOf      time.Time
+	ExpiresOn time.Time
+	// Principal is the principal left unpaid.
+	Principal money.Money
+	// AccruedInterest is the unpaid interest earned by AsOf: all of it on
+	// installments already due, and on the current one in proportion to the
+	// days of its period that have elapsed, by the loan's day count
+	// convention if it has one.
+	AccruedInterest money.Money
+	// UnearnedInterest is the rest of the unpaid interest, and Rebate the
+	// part of it the loan's product waives.
+	UnearnedInterest money.Money
+	Rebate           money.Money
+	// Penalties are the unpaid fees charged on the schedules.
+	Penalties     money.Money
+	CreditBalance money.Money
+	// Total is what the borrower pays: everything unpaid less Rebate and the
+	// credit balance.
+	Total money.Money
+}
+
+// GetPayoffQuote returns what the borrower would pay to close the loan on the
+// day of asOf.
+func (s *LoanService) GetPayoffQuote(loanID int, asOf time.Time) (PayoffQuote, error) {
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return PayoffQuote{}, err
+	}
+	if !loan.IsPayable() {
+		return PayoffQuote{}, &LoanNotPayableError{LoanID: loanID, Status: loan.LoanStatus}
+	}
+
+	schedules, err := s.loanScheduleRepo.GetByLoanID(loanID)
+	if err != nil {
+		return PayoffQuote{}, err
+	}
+
+	quote, _, err := s.payoffQuote(loan, schedules, asOf)
+	return quote, err
+}
+
+// PayOff pays the loan off at its payoff quote as of quotedAsOf, which must not
+// have expired. Every unpaid schedule has the rebated interest taken off and
+// what is left of it reserved by one pending payment of the quote's total,
+// the rest coming out of the credit balance. ConfirmPayment settles the
+// schedules and closes the loan once the funds clear; FailPayment restores the
+// rebated interest and the credit balance. A quote the credit balance covers
+// in full is settled at once. Reversing the payment restores the rebated
+// interest too. A loan with payments still pending cannot be paid off.
+func (s *LoanService) PayOff(
+	loanID int,
+	quotedAsOf time.Time,
+	paymentMethod string,
+	idempotencyKey string,
+) (entity.Payment, error) {
+	if err := validateIdempotencyKey(idempotencyKey); err != nil {
+		return entity.Payment{}, err
+	}
+	method, err := s.paymentMethods.Get(paymentMethod)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	original, err := s.paymentRepo.GetByIdempotencyKey(idempotencyKey)
+	if err == nil {
+		// the quote may have moved on since, so only the loan and the
+		// method have to match the original request
+		return replay(original, entity.Payment{
+			LoanID:         loanID,
+			AmountPaid:     original.AmountPaid,
+			PaymentMethod:  paymentMethod,
+			IdempotencyKey: idempotencyKey,
+		})
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return entity.Payment{}, err
+	}
+
+	today := s.today()
+	if startOfDay(quotedAsOf).After(today) {
+		return entity.Payment{}, errors.New("payoff quote is for a later day")
+	}
+
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+	if !loan.IsPayable() {
+		return entity.Payment{}, &LoanNotPayableError{LoanID: loanID, Status: loan.LoanStatus}
+	}
+
+	payments, err := s.paymentRepo.GetByLoanID(loanID)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+	if pendingAmount(payments).IsPositive() {
+		return entity.Payment{}, ErrPaymentsPending
+	}
+
+	schedules, err := s.loanScheduleRepo.GetByLoanID(loanID)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	quote, rebates, err := s.payoffQuote(loan, schedules, quotedAsOf)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+	if today.After(quote.ExpiresOn) {
+		return entity.Payment{}, ErrPayoffQuoteExpired
+	}
+
+	now := s.timeNow()
+	payment := entity.Payment{
+		LoanID:                 loanID,
+		PaymentDate:            now,
+		AmountPaid:             quote.Total,
+		PaymentMethod:          paymentMethod,
+		FeeAmount:              method.Fee(quote.Total),
+		Status:                 entity.StatusPending,
+		ExpectedSettlementDate: now.Add(method.SettlementDelay()),
+		IdempotencyKey:         idempotencyKey,
+	}
+	if quote.Total.IsZero() {
+		// the credit balance covers it all
+		payment.PaymentMethod = entity.PaymentMethodCreditBalance
+		payment.FeeAmount = money.Money{}
+		payment.Status = entity.StatusCompleted
+		payment.ExpectedSettlementDate = now
+		payment.IdempotencyKey = ""
+	} else if err = method.Validate(quote.Total); err != nil {
+		return entity.Payment{}, err
+	}
+
+	var settled money.Money
+	for i := range schedules {
+		if schedules[i].IsPaid() {
+			continue
+		}
+		schedules[i].RebateInterest(rebates[i])
+		var allocation entity.PaymentAllocation
+		if payment.IsPending() {
+			allocation, _ = schedules[i].Reserve(schedules[i].Outstanding(), s.allocationOrder)
+		} else {
+			allocation, _ = schedules[i].Allocate(schedules[i].Outstanding(), s.allocationOrder)
+		}
+		allocation.InterestRebate = rebates[i]
+		payment.Allocations = append(payment.Allocations, allocation)
+		settled = settled.Add(allocation.Total())
+	}
+	if len(payment.Allocations) == 0 {
+		return entity.Payment{}, errors.New("loan has nothing left to pay off")
+	}
+	loan.CreditBalance = loan.CreditBalance.Sub(settled.Sub(quote.Total))
+
+	saved, err := s.savePayment(payment, schedules, loan)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	s.syncBorrower(loan.BorrowerID)
+
+	return saved, nil
+}
+
+// payoffQuote works out the payoff quote of the loan as of the day of asOf
+// from its schedules, and the interest rebated on each of them.
+func (s *LoanService) payoffQuote(
+	loan entity.Loan,
+	schedules []entity.LoanSchedule,
+	asOf time.Time,
+) (PayoffQuote, []money.Money, error) {
+	loanProduct, err := s.products.Get(loan.ProductCode)
+	if err != nil {
+		return PayoffQuote{}, nil, err
+	}
+
+	terms, err := s.scheduleTerms(loan)
+	if err != nil {
+		return PayoffQuote{}, nil, err
+	}
+
+	asOf = startOfDay(asOf)
+	quote := PayoffQuote{
+		LoanID:        loan.LoanID,
+		AsOf:          asOf,
+		ExpiresOn:     asOf.AddDate(0, 0, s.payoffQuoteValidity),
+		CreditBalance: loan.CreditBalance,
+	}
+	rebates := make([]money.Money, len(schedules))
+	// installments kept from before a restructure do not start the periods
+	// of those replacing them
+	periodStart := terms.LoanStartDate
+	for i, schedule := range schedules {
+		start := periodStart
+		if schedule.DueDate.After(periodStart) {
+			periodStart = schedule.DueDate
+		}
+		if schedule.IsPaid() {
+			continue
+		}
+
+		earned, err := interestEarned(loan, schedule, start, asOf)
+		if err != nil {
+			return PayoffQuote{}, nil, err
+		}
+		unpaidInterest := schedule.InterestAmount.Sub(schedule.InterestPaid)
+		accrued := money.Max(earned.Sub(schedule.InterestPaid), money.Money{})
+		unearned := unpaidInterest.Sub(accrued)
+		rebates[i] = unearned.Percent(loanProduct.PayoffRebate, money.RoundDown)
+
+		quote.Principal = quote.Principal.Add(schedule.PrincipalAmount.Sub(schedule.PrincipalPaid))
+		quote.AccruedInterest = quote.AccruedInterest.Add(accrued)
+		quote.UnearnedInterest = quote.UnearnedInterest.Add(unearned)
+		quote.Rebate = quote.Rebate.Add(rebates[i])
+		quote.Penalties = quote.Penalties.Add(schedule.UnpaidFees())
+	}
+
+	owed := money.Sum(quote.Principal, quote.AccruedInterest, quote.UnearnedInterest, quote.Penalties).Sub(quote.Rebate)
+	quote.Total = netOutstanding(owed, loan.CreditBalance)
+	return quote, rebates, nil
+}
+
+// interestEarned is how much of the schedule's interest has been earned by
+// asOf: none before its period starts, all of it from its due date, and in
+// between in proportion to the part of the period elapsed, as the loan's day
+// count convention counts it. Loans without one earn it by the day.
+func interestEarned(
+	loan entity.Loan,
+	schedule entity.LoanSchedule,
+	periodStart, asOf time.Time,
+) (money.Money, error) {
+	periodStart, dueDate := startOfDay(periodStart), startOfDay(schedule.DueDate)
+	switch {
+	case !asOf.Before(dueDate):
+		return schedule.InterestAmount, nil
+	case !asOf.After(periodStart):
+		return money.Money{}, nil
+	}
+
+	convention := loan.DayCount
+	if convention == daycount.PerTenor {
+		convention = daycount.Actual365
+	}
+	elapsed, err := convention.YearFraction(periodStart, asOf)
+	if err != nil {
+		return money.Money{}, err
+	}
+	period, err := convention.YearFraction(periodStart, dueDate)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return schedule.InterestAmount.MulRat(elapsed.Quo(elapsed, period), money.RoundHalfUp), nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,350 @@
+package application
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/delinquency"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/paymentmethod"
+	"github.com/iqbalbachmid/billing-engine/domain/product"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+func payoffFixture() (entity.Loan, []entity.LoanSchedule) {
+	loan := entity.Loan{
+		LoanID:        100,
+		BorrowerID:    1,
+		ProductCode:   product.Standard,
+		LoanAmount:    money.New(1000000),
+		InterestRate:  10,
+		LoanStatus:    entity.LoanStatusActive,
+		LoanStartDate: time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
+		Tenor:         4,
+		CreditBalance: money.New(10000),
+	}
+
+	var schedules []entity.LoanSchedule
+	for i := 1; i <= 4; i++ {
+		schedules = append(schedules, entity.LoanSchedule{
+			ScheduleID:      i,
+			LoanID:          100,
+			DueDate:         loan.LoanStartDate.AddDate(0, 0, 7*i),
+			PrincipalAmount: money.New(250000),
+			InterestAmount:  money.New(25000),
+			TotalDue:        money.New(275000),
+		})
+	}
+	schedules[0].PrincipalPaid = money.New(250000)
+	schedules[0].InterestPaid = money.New(25000)
+	schedules[0].PaymentStatus = entity.PaymentStatusPaid
+	schedules[1].Charge(money.New(5000))
+
+	return loan, schedules
+}
+
+func TestLoanService_GetPayoffQuote(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		loan, schedules      = payoffFixture()
+	)
+
+	halfRebate, err := product.NewCatalog(product.Product{
+		Code:         product.Standard,
+		Delinquency:  delinquency.TotalOverdue{Limit: 2},
+		PayoffRebate: 50,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		asOf     time.Time
+		products *product.Catalog
+		want     PayoffQuote
+		wantErr  bool
+		mock     func()
+	}{
+		{
+			name:    "should return error if loan is not payable",
+			asOf:    time.Date(2024, time.November, 7, 0, 0, 0, 0, time.UTC),
+			wantErr: true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(entity.Loan{LoanID: 100, LoanStatus: entity.LoanStatusPaid}, nil).Once()
+			},
+		},
+		{
+			name: "should accrue interest on the current installment by the day and rebate the rest",
+			asOf: time.Date(2024, time.November, 7, 15, 0, 0, 0, time.UTC),
+			want: PayoffQuote{
+				LoanID:           100,
+				AsOf:             time.Date(2024, time.November, 7, 0, 0, 0, 0, time.UTC),
+				ExpiresOn:        time.Date(2024, time.November, 14, 0, 0, 0, 0, time.UTC),
+				Principal:        money.New(750000),
+				AccruedInterest:  money.MustParse("10714.29"),
+				UnearnedInterest: money.MustParse("64285.71"),
+				Rebate:           money.MustParse("64285.71"),
+				Penalties:        money.New(5000),
+				CreditBalance:    money.New(10000),
+				Total:            money.MustParse("755714.29"),
+			},
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(schedules, nil).Once()
+			},
+		},
+		{
+			name:     "should rebate the product's share of unearned interest",
+			asOf:     time.Date(2024, time.November, 7, 0, 0, 0, 0, time.UTC),
+			products: halfRebate,
+			want: PayoffQuote{
+				LoanID:           100,
+				AsOf:             time.Date(2024, time.November, 7, 0, 0, 0, 0, time.UTC),
+				ExpiresOn:        time.Date(2024, time.November, 14, 0, 0, 0, 0, time.UTC),
+				Principal:        money.New(750000),
+				AccruedInterest:  money.MustParse("10714.29"),
+				UnearnedInterest: money.MustParse("64285.71"),
+				Rebate:           money.MustParse("32142.85"),
+				Penalties:        money.New(5000),
+				CreditBalance:    money.New(10000),
+				Total:            money.MustParse("787857.15"),
+			},
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(schedules, nil).Once()
+			},
+		},
+		{
+			name: "should accrue interest on the first installment of a restructure from its effective date",
+			asOf: time.Date(2024, time.November, 7, 0, 0, 0, 0, time.UTC),
+			want: PayoffQuote{
+				LoanID:           100,
+				AsOf:             time.Date(2024, time.November, 7, 0, 0, 0, 0, time.UTC),
+				ExpiresOn:        time.Date(2024, time.November, 14, 0, 0, 0, 0, time.UTC),
+				Principal:        money.New(750000),
+				AccruedInterest:  money.MustParse("8333.33"),
+				UnearnedInterest: money.MustParse("66666.67"),
+				Rebate:           money.MustParse("66666.67"),
+				Penalties:        money.New(5000),
+				CreditBalance:    money.New(10000),
+				Total:            money.MustParse("753333.33"),
+			},
+			mock: func() {
+				restructured := loan
+				restructured.Restructured = true
+				mockLoanRepo.EXPECT().GetByID(100).Return(restructured, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(schedules, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetRestructuresByLoanID(100).Return([]entity.LoanRestructure{{
+					RestructureID: 3,
+					LoanID:        100,
+					EffectiveDate: time.Date(2024, time.November, 5, 0, 0, 0, 0, time.UTC),
+					Tenor:         3,
+					InterestRate:  10,
+				}}, nil).Once()
+			},
+		},
+		{
+			name: "should rebate nothing once every installment is due",
+			asOf: time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+			want: PayoffQuote{
+				LoanID:          100,
+				AsOf:            time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+				ExpiresOn:       time.Date(2024, time.December, 8, 0, 0, 0, 0, time.UTC),
+				Principal:       money.New(750000),
+				AccruedInterest: money.New(75000),
+				Penalties:       money.New(5000),
+				CreditBalance:   money.New(10000),
+				Total:           money.New(820000),
+			},
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(schedules, nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:            mockLoanRepo,
+				loanScheduleRepo:    mockLoanScheduleRepo,
+				products:            product.Default(),
+				payoffQuoteValidity: defaultPayoffQuoteValidity,
+			}
+			if tt.products != nil {
+				s.products = tt.products
+			}
+			got, err := s.GetPayoffQuote(100, tt.asOf)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetPayoffQuote() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetPayoffQuote() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoanService_PayOff(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		mockPaymentRepo      = mocks.NewPaymentRepository(t)
+		loan, schedules      = payoffFixture()
+		quotedAsOf           = time.Date(2024, time.November, 7, 0, 0, 0, 0, time.UTC)
+		wantRebates          = []money.Money{money.MustParse("14285.71"), money.New(25000), money.New(25000)}
+	)
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		method    string
+		want      entity.Payment
+		wantErr   bool
+		wantErrIs error
+		mock      func()
+	}{
+		{
+			name:      "should return error if quote has expired",
+			now:       time.Date(2024, time.November, 15, 9, 0, 0, 0, time.UTC),
+			method:    paymentmethod.VirtualAccount,
+			wantErr:   true,
+			wantErrIs: ErrPayoffQuoteExpired,
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByIdempotencyKey("key-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(100).Return(nil, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(schedules, nil).Once()
+			},
+		},
+		{
+			name:      "should return error if loan has pending payments",
+			now:       time.Date(2024, time.November, 8, 9, 0, 0, 0, time.UTC),
+			method:    paymentmethod.VirtualAccount,
+			wantErr:   true,
+			wantErrIs: ErrPaymentsPending,
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByIdempotencyKey("key-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(100).Return([]entity.Payment{
+					{PaymentID: 7, LoanID: 100, AmountPaid: money.New(100000), Status: entity.StatusPending},
+				}, nil).Once()
+			},
+		},
+		{
+			name:   "should reserve every unpaid schedule at the quoted total",
+			now:    time.Date(2024, time.November, 8, 9, 0, 0, 0, time.UTC),
+			method: paymentmethod.VirtualAccount,
+			want: entity.Payment{
+				PaymentID:              8,
+				LoanID:                 100,
+				PaymentDate:            time.Date(2024, time.November, 8, 9, 0, 0, 0, time.UTC),
+				AmountPaid:             money.MustParse("755714.29"),
+				PaymentMethod:          paymentmethod.VirtualAccount,
+				FeeAmount:              money.New(4000),
+				Status:                 entity.StatusPending,
+				ExpectedSettlementDate: time.Date(2024, time.November, 8, 9, 0, 0, 0, time.UTC),
+				IdempotencyKey:         "key-1",
+			},
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByIdempotencyKey("key-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(100).Return(nil, nil).Once()
+				_, unpaid := payoffFixture()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(unpaid, nil).Once()
+				mockPaymentRepo.EXPECT().CreatePaymentAndUpdateLoanSchedules(mock.Anything, mock.Anything, mock.Anything).
+					RunAndReturn(assertPayoffReserved(t, wantRebates)).Once()
+			},
+		},
+		{
+			name:   "should expect a delayed payment method's payoff to clear after its settlement delay",
+			now:    time.Date(2024, time.November, 8, 9, 0, 0, 0, time.UTC),
+			method: paymentmethod.BankTransfer,
+			want: entity.Payment{
+				PaymentID:              8,
+				LoanID:                 100,
+				PaymentDate:            time.Date(2024, time.November, 8, 9, 0, 0, 0, time.UTC),
+				AmountPaid:             money.MustParse("755714.29"),
+				PaymentMethod:          paymentmethod.BankTransfer,
+				FeeAmount:              money.New(2500),
+				Status:                 entity.StatusPending,
+				ExpectedSettlementDate: time.Date(2024, time.November, 9, 9, 0, 0, 0, time.UTC),
+				IdempotencyKey:         "key-1",
+			},
+			mock: func() {
+				mockPaymentRepo.EXPECT().GetByIdempotencyKey("key-1").Return(entity.Payment{}, repository.ErrNotFound).Once()
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(100).Return(nil, nil).Once()
+				_, unpaid := payoffFixture()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(unpaid, nil).Once()
+				mockPaymentRepo.EXPECT().CreatePaymentAndUpdateLoanSchedules(mock.Anything, mock.Anything, mock.Anything).
+					RunAndReturn(assertPayoffReserved(t, wantRebates)).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:            mockLoanRepo,
+				loanScheduleRepo:    mockLoanScheduleRepo,
+				paymentRepo:         mockPaymentRepo,
+				paymentMethods:      paymentmethod.Default(),
+				products:            product.Default(),
+				payoffQuoteValidity: defaultPayoffQuoteValidity,
+				timeNow: func() time.Time {
+					return tt.now
+				},
+			}
+			got, err := s.PayOff(100, quotedAsOf, tt.method, "key-1")
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("PayOff() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			got.Allocations = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PayOff() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// assertPayoffReserved checks that a payoff of payoffFixture reserved every
+// unpaid schedule in full and left the loan open until the payment clears.
+func assertPayoffReserved(
+	t *testing.T,
+	wantRebates []money.Money,
+) func(entity.Payment, []entity.LoanSchedule, entity.Loan) (int, error) {
+	return func(payment entity.Payment, updated []entity.LoanSchedule, loan entity.Loan) (int, error) {
+		if len(updated) != 3 {
+			t.Errorf("PayOff() updated %v schedules, want 3", len(updated))
+		}
+		for _, schedule := range updated {
+			if schedule.IsPaid() || !schedule.Unreserved().IsZero() {
+				t.Errorf("PayOff() did not reserve schedule %+v in full", schedule)
+			}
+		}
+		if loan.LoanStatus != entity.LoanStatusActive || !loan.CreditBalance.IsZero() {
+			t.Errorf("PayOff() saved loan = %+v", loan)
+		}
+		if got := payment.AllocatedAmount(); got != money.MustParse("765714.29") {
+			t.Errorf("PayOff() allocated = %v, want 765714.29", got)
+		}
+		for i, allocation := range payment.Allocations {
+			if allocation.InterestRebate != wantRebates[i] {
+				t.Errorf("PayOff() rebate on schedule %d = %v, want %v", allocation.ScheduleID, allocation.InterestRebate, wantRebates[i])
+			}
+		}
+		return 8, nil
+	}
+}
@@ -0,0 +1,269 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/schedule"
+)
+
+// maxRestructureTenor bounds the tenor RestructureLoan looks for when it is
+// given an installment amount instead.
+const maxRestructureTenor = 1000
+
+// RestructureTerms are the terms a loan's unpaid installments are spread again
+// on.
+type RestructureTerms struct {
+	// EffectiveDate is the day the new schedule runs from. Its first
+	// installment falls due one period of the loan's frequency later.
+	EffectiveDate time.Time
+	// Tenor is the number of new installments. Instead of a tenor, an
+	// InstallmentAmount can be given, and the new schedule is then the
+	// shortest whose installments do not exceed it.
+	Tenor             int
+	InstallmentAmount money.Money
+	// InterestRate is charged on the restructured principal the way the
+	// loan's own rate is: over the new tenor, or yearly if the loan has a day
+	// count convention.
+	InterestRate float64
+	// CapitalizeArrears adds the interest and fees left unpaid on
+	// installments due before EffectiveDate to the restructured principal.
+	// Otherwise they are billed with the first new installment.
+	CapitalizeArrears bool
+}
+
+// RestructureLoan spreads the principal left unpaid on the loan again over a
+// new schedule on the given terms, amortized by the loan's method at its
+// frequency. Paid installments are kept; every other one is superseded, stays
+// queryable through GetRestructures and no longer counts towards what is
+// outstanding. Interest not yet due on the superseded installments is replaced
+// by the interest of the new schedule. Charges billed with the first new
+// installment move to it, so they can still be waived; capitalized ones move
+// with what of them was capitalized, which can no longer be waived. The loan is
+// flagged as restructured.
+func (s *LoanService) RestructureLoan(loanID int, terms RestructureTerms) (entity.LoanRestructure, error) {
+	if (terms.Tenor > 0) == terms.InstallmentAmount.IsPositive() {
+		return entity.LoanRestructure{}, errors.New("either a tenor or an installment amount is required")
+	}
+	if terms.InterestRate < 0 {
+		return entity.LoanRestructure{}, errors.New("interest rate must not be negative")
+	}
+	effectiveDate := startOfDay(terms.EffectiveDate)
+	if effectiveDate.Before(s.today()) {
+		return entity.LoanRestructure{}, errors.New("restructure cannot take effect in the past")
+	}
+
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return entity.LoanRestructure{}, err
+	}
+	if !loan.IsPayable() {
+		return entity.LoanRestructure{}, &LoanNotPayableError{LoanID: loanID, Status: loan.LoanStatus}
+	}
+	loanProduct, err := s.products.Get(loan.ProductCode)
+	if err != nil {
+		return entity.LoanRestructure{}, err
+	}
+
+	payments, err := s.paymentRepo.GetByLoanID(loanID)
+	if err != nil {
+		return entity.LoanRestructure{}, err
+	}
+	if pendingAmount(payments).IsPositive() {
+		return entity.LoanRestructure{}, ErrPaymentsPending
+	}
+
+	schedules, err := s.loanScheduleRepo.GetByLoanID(loanID)
+	if err != nil {
+		return entity.LoanRestructure{}, err
+	}
+
+	restructure := entity.LoanRestructure{
+		LoanID:           loanID,
+		EffectiveDate:    effectiveDate,
+		InterestRate:     terms.InterestRate,
+		RestructuredDate: s.today(),
+	}
+	var principal, arrearsInterest, arrearsFees money.Money
+	for _, loanSchedule := range schedules {
+		if loanSchedule.IsPaid() {
+			continue
+		}
+		restructure.Superseded = append(restructure.Superseded, loanSchedule)
+		principal = principal.Add(loanSchedule.PrincipalAmount.Sub(loanSchedule.PrincipalPaid))
+		if loanSchedule.DueDate.Before(effectiveDate) {
+			arrearsInterest = arrearsInterest.Add(loanSchedule.InterestAmount.Sub(loanSchedule.InterestPaid))
+			arrearsFees = arrearsFees.Add(loanSchedule.UnpaidFees())
+		}
+	}
+	if len(restructure.Superseded) == 0 {
+		return entity.LoanRestructure{}, errors.New("loan has no unpaid installments to restructure")
+	}
+
+	terms.EffectiveDate = effectiveDate
+	if terms.CapitalizeArrears {
+		restructure.CapitalizedArrears = arrearsInterest.Add(arrearsFees)
+		principal = principal.Add(restructure.CapitalizedArrears)
+	}
+	respread, err := s.respread(loan, principal, terms, loanProduct.BusinessDay)
+	if err != nil {
+		return entity.LoanRestructure{}, err
+	}
+	charges, err := s.supersededCharges(loanID, restructure.Superseded)
+	if err != nil {
+		return entity.LoanRestructure{}, err
+	}
+	if terms.CapitalizeArrears {
+		capitalizeCharges(charges, restructure.Superseded)
+	} else {
+		respread[0].InterestAmount = respread[0].InterestAmount.Add(arrearsInterest)
+		respread[0].FeeAmount = arrearsFees
+		respread[0].TotalDue = money.Sum(respread[0].TotalDue, arrearsInterest, arrearsFees)
+	}
+
+	now := s.timeNow()
+	periodStart := effectiveDate
+	for i := range respread {
+		respread[i].LoanID = loanID
+		respread[i].PaymentStatus = s.statusAt(respread[i], periodStart, now)
+		periodStart = respread[i].DueDate
+	}
+	restructure.Tenor = len(respread)
+	s.warnUnlistedHolidays(loanID, respread)
+
+	loan.LoanEndDate = respread[len(respread)-1].DueDate
+	loan.Restructured = true
+
+	restructure.RestructureID, err = s.loanScheduleRepo.CreateRestructure(restructure, respread, charges, loan)
+	if err != nil {
+		return entity.LoanRestructure{}, err
+	}
+
+	s.syncBorrower(loan.BorrowerID)
+
+	return restructure, nil
+}
+
+// GetRestructures returns the loan's restructures, oldest first, each with the
+// schedules it superseded.
+func (s *LoanService) GetRestructures(loanID int) ([]entity.LoanRestructure, error) {
+	if _, err := s.loanRepo.GetByID(loanID); err != nil {
+		return nil, err
+	}
+
+	return s.loanScheduleRepo.GetRestructuresByLoanID(loanID)
+}
+
+// scheduleTerms returns the loan with the terms its current schedule was
+// generated on. Those of a restructured loan are its latest restructure's: the
+// principal spread again, from the effective date, over the new tenor and at
+// the new rate.
+func (s *LoanService) scheduleTerms(loan entity.Loan) (entity.Loan, error) {
+	if !loan.Restructured {
+		return loan, nil
+	}
+
+	restructures, err := s.loanScheduleRepo.GetRestructuresByLoanID(loan.LoanID)
+	if err != nil {
+		return entity.Loan{}, err
+	}
+	if len(restructures) == 0 {
+		return loan, nil
+	}
+
+	latest := restructures[len(restructures)-1]
+	loan.LoanAmount = latest.CapitalizedArrears
+	for _, superseded := range latest.Superseded {
+		loan.LoanAmount = loan.LoanAmount.Add(superseded.PrincipalAmount.Sub(superseded.PrincipalPaid))
+	}
+	loan.LoanStartDate = latest.EffectiveDate
+	loan.Tenor = latest.Tenor
+	loan.InterestRate = latest.InterestRate
+	return loan, nil
+}
+
+// capitalizeCharges records the unpaid fees of the superseded schedules as
+// capitalized on their charges, oldest charge first.
+func capitalizeCharges(charges []entity.Charge, superseded []entity.LoanSchedule) {
+	unpaid := make(map[int]money.Money, len(superseded))
+	for _, schedule := range superseded {
+		unpaid[schedule.ScheduleID] = schedule.UnpaidFees()
+	}
+	for i := range charges {
+		capitalized := money.Min(charges[i].Waivable(), unpaid[charges[i].ScheduleID])
+		charges[i].CapitalizedAmount = charges[i].CapitalizedAmount.Add(capitalized)
+		unpaid[charges[i].ScheduleID] = unpaid[charges[i].ScheduleID].Sub(capitalized)
+	}
+}
+
+// supersededCharges returns the charges of the loan left unpaid on the
+// superseded schedules.
+func (s *LoanService) supersededCharges(loanID int, superseded []entity.LoanSchedule) ([]entity.Charge, error) {
+	charges, err := s.chargeRepo.GetByLoanID(loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	var unpaid []entity.Charge
+	for _, charge := range charges {
+		schedule := findSchedule(superseded, charge.ScheduleID)
+		if schedule != nil && schedule.UnpaidFees().IsPositive() && charge.Waivable().IsPositive() {
+			unpaid = append(unpaid, charge)
+		}
+	}
+	return unpaid, nil
+}
+
+// respread generates the schedule principal is spread over on the terms.
+// Without a tenor it looks for the shortest one whose installments stay within
+// the terms' installment amount, relying on installments shrinking as the
+// tenor grows.
+func (s *LoanService) respread(
+	loan entity.Loan,
+	principal money.Money,
+	terms RestructureTerms,
+	convention calendar.Convention,
+) ([]entity.LoanSchedule, error) {
+	loan.LoanAmount = principal
+	loan.InterestRate = terms.InterestRate
+	loan.LoanStartDate = terms.EffectiveDate
+	if terms.Tenor > 0 {
+		loan.Tenor = terms.Tenor
+		return schedule.Generate(loan, s.calendar, convention)
+	}
+
+	var err error
+	tenor := 1 + sort.Search(maxRestructureTenor, func(i int) bool {
+		if err != nil {
+			return true
+		}
+		loan.Tenor = i + 1
+		var schedules []entity.LoanSchedule
+		if schedules, err = schedule.Generate(loan, s.calendar, convention); err != nil {
+			return true
+		}
+		return !largestInstallment(schedules).GreaterThan(terms.InstallmentAmount)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tenor > maxRestructureTenor {
+		return nil, fmt.Errorf("installments exceed %v even over %d installments", terms.InstallmentAmount, maxRestructureTenor)
+	}
+
+	loan.Tenor = tenor
+	return schedule.Generate(loan, s.calendar, convention)
+}
+
+func largestInstallment(schedules []entity.LoanSchedule) money.Money {
+	var largest money.Money
+	for _, loanSchedule := range schedules {
+		largest = money.Max(largest, loanSchedule.TotalDue)
+	}
+	return largest
+}
@@ -0,0 +1,209 @@
+package application
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/product"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoanService_RestructureLoan(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		mockPaymentRepo      = mocks.NewPaymentRepository(t)
+		mockChargeRepo       = mocks.NewChargeRepository(t)
+		loan, schedules      = payoffFixture()
+		effectiveDate        = time.Date(2024, time.November, 15, 0, 0, 0, 0, time.UTC)
+		dueDates             = []time.Time{
+			time.Date(2024, time.November, 22, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.November, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.December, 6, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.December, 13, 0, 0, 0, 0, time.UTC),
+		}
+		respread []entity.LoanSchedule
+		moved    []entity.Charge
+		charges  = []entity.Charge{
+			{ChargeID: 8, LoanID: 100, ScheduleID: 1, ChargeType: entity.ChargeTypeLatePayment, Amount: money.New(5000)},
+			{ChargeID: 9, LoanID: 100, ScheduleID: 2, ChargeType: entity.ChargeTypeLatePayment, Amount: money.New(5000)},
+		}
+	)
+	schedules[1].PaymentStatus = entity.PaymentStatusOverdue
+
+	tests := []struct {
+		name          string
+		terms         RestructureTerms
+		want          entity.LoanRestructure
+		wantFirstDue  money.Money
+		wantOthersDue money.Money
+		wantMoved     []entity.Charge
+		wantErr       bool
+		wantErrIs     error
+		mock          func()
+	}{
+		{
+			name:    "should return error if neither tenor nor installment amount is given",
+			terms:   RestructureTerms{EffectiveDate: effectiveDate, InterestRate: 4},
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name:    "should return error if effective date is in the past",
+			terms:   RestructureTerms{EffectiveDate: effectiveDate.AddDate(0, 0, -1), Tenor: 4, InterestRate: 4},
+			wantErr: true,
+			mock:    func() {},
+		},
+		{
+			name:      "should return error if loan has pending payments",
+			terms:     RestructureTerms{EffectiveDate: effectiveDate, Tenor: 4, InterestRate: 4},
+			wantErr:   true,
+			wantErrIs: ErrPaymentsPending,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(100).Return([]entity.Payment{
+					{PaymentID: 7, LoanID: 100, AmountPaid: money.New(100000), Status: entity.StatusPending},
+				}, nil).Once()
+			},
+		},
+		{
+			name: "should capitalize arrears into the new tenor",
+			terms: RestructureTerms{
+				EffectiveDate:     effectiveDate,
+				Tenor:             4,
+				InterestRate:      4,
+				CapitalizeArrears: true,
+			},
+			want: entity.LoanRestructure{
+				RestructureID:      3,
+				LoanID:             100,
+				EffectiveDate:      effectiveDate,
+				Tenor:              4,
+				InterestRate:       4,
+				CapitalizedArrears: money.New(30000),
+				RestructuredDate:   effectiveDate,
+				Superseded:         schedules[1:],
+			},
+			wantFirstDue:  money.New(202800),
+			wantOthersDue: money.New(202800),
+			wantMoved: []entity.Charge{
+				{
+					ChargeID:          9,
+					LoanID:            100,
+					ScheduleID:        2,
+					ChargeType:        entity.ChargeTypeLatePayment,
+					Amount:            money.New(5000),
+					CapitalizedAmount: money.New(5000),
+				},
+			},
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(100).Return(nil, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(schedules, nil).Once()
+				mockChargeRepo.EXPECT().GetByLoanID(100).Return(charges, nil).Once()
+			},
+		},
+		{
+			name: "should find the tenor for the installment amount and bill arrears first",
+			terms: RestructureTerms{
+				EffectiveDate:     effectiveDate,
+				InstallmentAmount: money.New(200000),
+				InterestRate:      4,
+			},
+			want: entity.LoanRestructure{
+				RestructureID:    3,
+				LoanID:           100,
+				EffectiveDate:    effectiveDate,
+				Tenor:            4,
+				InterestRate:     4,
+				RestructuredDate: effectiveDate,
+				Superseded:       schedules[1:],
+			},
+			wantFirstDue:  money.New(225000),
+			wantOthersDue: money.New(195000),
+			wantMoved:     charges[1:],
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(100).Return(loan, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(100).Return(nil, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(100).Return(schedules, nil).Once()
+				mockChargeRepo.EXPECT().GetByLoanID(100).Return(charges, nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			respread, moved = nil, nil
+			if !tt.wantErr {
+				mockLoanScheduleRepo.EXPECT().CreateRestructure(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					RunAndReturn(func(
+						_ entity.LoanRestructure,
+						schedules []entity.LoanSchedule,
+						charges []entity.Charge,
+						loan entity.Loan,
+					) (int, error) {
+						if !loan.Restructured || !loan.LoanEndDate.Equal(dueDates[3]) {
+							t.Errorf("RestructureLoan() saved loan = %+v", loan)
+						}
+						respread, moved = schedules, charges
+						return 3, nil
+					}).Once()
+			}
+
+			s := &LoanService{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				paymentRepo:      mockPaymentRepo,
+				chargeRepo:       mockChargeRepo,
+				products:         product.Default(),
+				timeNow: func() time.Time {
+					return time.Date(2024, time.November, 15, 9, 0, 0, 0, time.UTC)
+				},
+			}
+			got, err := s.RestructureLoan(100, tt.terms)
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("RestructureLoan() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RestructureLoan() got = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(moved, tt.wantMoved) {
+				t.Errorf("RestructureLoan() moved charges = %+v, want %+v", moved, tt.wantMoved)
+			}
+			if len(respread) != len(dueDates) {
+				t.Fatalf("RestructureLoan() created %d schedules, want %d", len(respread), len(dueDates))
+			}
+			for i, schedule := range respread {
+				wantDue := tt.wantOthersDue
+				if i == 0 {
+					wantDue = tt.wantFirstDue
+				}
+				if !schedule.DueDate.Equal(dueDates[i]) || schedule.TotalDue != wantDue || schedule.LoanID != 100 {
+					t.Errorf("RestructureLoan() schedule %d = %+v, want %v due on %v", i, schedule, wantDue, dueDates[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoanService_GetRestructures(t *testing.T) {
+	mockLoanRepo := mocks.NewLoanRepository(t)
+	mockLoanScheduleRepo := mocks.NewLoanScheduleRepository(t)
+
+	mockLoanRepo.EXPECT().GetByID(100).Return(entity.Loan{}, repository.ErrNotFound).Once()
+
+	s := &LoanService{loanRepo: mockLoanRepo, loanScheduleRepo: mockLoanScheduleRepo}
+	if _, err := s.GetRestructures(100); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetRestructures() error = %v, want %v", err, repository.ErrNotFound)
+	}
+}
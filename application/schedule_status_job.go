@@ -0,0 +1,152 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// UpdateScheduleStatuses moves the schedules of every loan being repaid between
+// unspecified, due and overdue according to the current date and the business
+// day calendar, charges the late payment penalties the loan's product calls for
+// on overdue schedules, then applies any credit balance to the schedules that
+// became billable. Borrower account statuses are synced with their loans at the
+// end. Only schedules that actually change are written, so running it more than
+// once on the same day is a no-op. A failure on one loan does not stop the
+// others.
+func (s *LoanService) UpdateScheduleStatuses() error {
+	loans, err := s.loanRepo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	now := s.timeNow()
+	var (
+		errs      []error
+		borrowers []int
+		seen      = make(map[int]bool)
+	)
+	for _, loan := range loans {
+		if !seen[loan.BorrowerID] {
+			seen[loan.BorrowerID] = true
+			borrowers = append(borrowers, loan.BorrowerID)
+		}
+		if !loan.IsPayable() {
+			continue
+		}
+		if err = s.updateLoanScheduleStatuses(loan, now); err != nil {
+			errs = append(errs, fmt.Errorf("loan %d: %w", loan.LoanID, err))
+		}
+	}
+
+	for _, borrowerID := range borrowers {
+		s.syncBorrower(borrowerID)
+	}
+
+	return errors.Join(errs...)
+}
+
+// statusAt is LoanSchedule.StatusAt, except that an installment due on a
+// weekend or holiday does not become overdue before the following business
+// day has passed, e.g. when its due date was generated before the holiday was
+// in the calendar.
+func (s *LoanService) statusAt(schedule entity.LoanSchedule, periodStart, now time.Time) string {
+	schedule.DueDate = s.calendar.Adjust(schedule.DueDate, calendar.Following)
+	return schedule.StatusAt(periodStart, now)
+}
+
+// graceAdjusted returns copies of the schedules falling due on the business
+// day their due dates roll to, from the end of which statusAt makes them
+// overdue, so that days past due are not counted for the days before it.
+func (s *LoanService) graceAdjusted(schedules []entity.LoanSchedule) []entity.LoanSchedule {
+	adjusted := make([]entity.LoanSchedule, len(schedules))
+	for i, schedule := range schedules {
+		schedule.DueDate = s.calendar.Adjust(schedule.DueDate, calendar.Following)
+		adjusted[i] = schedule
+	}
+	return adjusted
+}
+
+func (s *LoanService) updateLoanScheduleStatuses(loan entity.Loan, now time.Time) error {
+	schedules, err := s.loanScheduleRepo.GetByLoanID(loan.LoanID)
+	if err != nil {
+		return err
+	}
+
+	terms, err := s.scheduleTerms(loan)
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[int]bool)
+	// installments kept from before a restructure do not start the periods
+	// of those replacing them
+	periodStart := terms.LoanStartDate
+	for i := range schedules {
+		status := s.statusAt(schedules[i], periodStart, now)
+		if schedules[i].DueDate.After(periodStart) {
+			periodStart = schedules[i].DueDate
+		}
+		if status != schedules[i].PaymentStatus {
+			schedules[i].PaymentStatus = status
+			changed[schedules[i].ScheduleID] = true
+		}
+	}
+
+	// charged schedules are written along with their charges
+	charged, err := s.assessPenalties(loan, schedules, now)
+	if err != nil {
+		return fmt.Errorf("penalties: %w", err)
+	}
+	for _, scheduleID := range charged {
+		delete(changed, scheduleID)
+	}
+
+	var credit entity.Payment
+	if loan.CreditBalance.IsPositive() {
+		var remaining money.Money
+		credit.Allocations, remaining = s.allocate(schedules, loan.CreditBalance)
+		credit.AmountPaid = loan.CreditBalance.Sub(remaining)
+		loan.CreditBalance = remaining
+	}
+
+	if len(credit.Allocations) > 0 {
+		credit.LoanID = loan.LoanID
+		credit.PaymentDate = now
+		credit.PaymentMethod = entity.PaymentMethodCreditBalance
+		credit.Status = entity.StatusCompleted
+		credit.ExpectedSettlementDate = now
+
+		// status changes are written in the same transaction as the credit
+		// payment, so neither is saved without the other
+		updated := allocatedSchedules(credit, schedules)
+		for _, allocation := range credit.Allocations {
+			delete(changed, allocation.ScheduleID)
+		}
+		for _, schedule := range schedules {
+			if changed[schedule.ScheduleID] {
+				updated = append(updated, schedule)
+			}
+		}
+		if _, err = s.savePaymentAndSchedules(credit, updated, schedules, loan); err != nil {
+			return fmt.Errorf("credit balance: %w", err)
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, schedule := range schedules {
+		if !changed[schedule.ScheduleID] {
+			continue
+		}
+		if err = s.loanScheduleRepo.Update(schedule); err != nil {
+			errs = append(errs, fmt.Errorf("schedule %d: %w", schedule.ScheduleID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,460 @@
+package application
+
+import (
+	"errors"
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/delinquency"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/penalty"
+	"github.com/iqbalbachmid/billing-engine/domain/product"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+	"testing"
+	"time"
+)
+
+func TestLoanService_UpdateScheduleStatuses(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		mockPaymentRepo      = mocks.NewPaymentRepository(t)
+		mockChargeRepo       = mocks.NewChargeRepository(t)
+		mockBorrowerRepo     = mocks.NewBorrowerRepository(t)
+	)
+
+	products, err := product.NewCatalog(
+		product.Product{
+			Code:        product.Standard,
+			Delinquency: delinquency.TotalOverdue{Limit: 2},
+		},
+		product.Product{
+			Code:        "flat_penalty",
+			Delinquency: delinquency.TotalOverdue{Limit: 2},
+			Penalty:     penalty.Flat{Amount: money.New(5000)},
+		},
+		product.Product{
+			Code:        "daily_penalty",
+			Delinquency: delinquency.TotalOverdue{Limit: 2},
+			Penalty:     penalty.DailyAccrual{Amount: money.New(1000), Cap: money.New(5000)},
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+
+	loans := []entity.Loan{
+		{
+			LoanID:        1,
+			ProductCode:   product.Standard,
+			LoanStartDate: time.Date(2024, time.October, 7, 0, 0, 0, 0, time.UTC),
+			LoanStatus:    entity.LoanStatusActive,
+		},
+		{
+			LoanID:        2,
+			ProductCode:   product.Standard,
+			LoanStartDate: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			LoanStatus:    entity.LoanStatusPaid,
+		},
+	}
+	schedulesWithStatus := func(statuses ...string) []entity.LoanSchedule {
+		var schedules []entity.LoanSchedule
+		for i, status := range statuses {
+			schedules = append(schedules, entity.LoanSchedule{
+				ScheduleID:      i + 1,
+				LoanID:          1,
+				DueDate:         time.Date(2024, time.October, 14+7*i, 0, 0, 0, 0, time.UTC),
+				PrincipalAmount: money.New(100000),
+				InterestAmount:  money.New(10000),
+				TotalDue:        money.New(110000),
+				PaymentStatus:   status,
+			})
+		}
+		return schedules
+	}
+
+	type fields struct {
+		borrowerRepo     repository.BorrowerRepository
+		loanRepo         repository.LoanRepository
+		loanScheduleRepo repository.LoanScheduleRepository
+		paymentRepo      repository.PaymentRepository
+		chargeRepo       repository.ChargeRepository
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr bool
+		mock    func()
+	}{
+		{
+			name: "should return error if loan repo fail",
+			fields: fields{
+				loanRepo: mockLoanRepo,
+			},
+			wantErr: true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetAll().Return(nil, errors.New("failed to get loans")).Once()
+			},
+		},
+		{
+			name: "should move schedules to due and overdue",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			wantErr: false,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetAll().Return(loans, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusDue,
+					entity.PaymentStatusUnspecified,
+					entity.PaymentStatusUnspecified,
+				), nil).Once()
+
+				want := schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusDue,
+					entity.PaymentStatusUnspecified,
+				)
+				mockLoanScheduleRepo.EXPECT().Update(want[1]).Return(nil).Once()
+				mockLoanScheduleRepo.EXPECT().Update(want[2]).Return(nil).Once()
+			},
+		},
+		{
+			name: "should not update anything when run twice on the same day",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			wantErr: false,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetAll().Return(loans, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusDue,
+					entity.PaymentStatusUnspecified,
+				), nil).Once()
+			},
+		},
+		{
+			name: "should not bill restructured installments before the restructure takes effect",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			wantErr: false,
+			mock: func() {
+				restructured := loans[0]
+				restructured.Restructured = true
+				mockLoanRepo.EXPECT().GetAll().Return([]entity.Loan{restructured}, nil).Once()
+				schedules := schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusUnspecified,
+					entity.PaymentStatusUnspecified,
+				)
+				schedules[1].DueDate = time.Date(2024, time.December, 18, 0, 0, 0, 0, time.UTC)
+				schedules[2].DueDate = time.Date(2024, time.December, 25, 0, 0, 0, 0, time.UTC)
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedules, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetRestructuresByLoanID(1).Return([]entity.LoanRestructure{{
+					LoanID:        1,
+					EffectiveDate: time.Date(2024, time.December, 11, 0, 0, 0, 0, time.UTC),
+					Tenor:         2,
+				}}, nil).Once()
+			},
+		},
+		{
+			name: "should apply credit balance to schedules that became billable",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				paymentRepo:      mockPaymentRepo,
+			},
+			wantErr: false,
+			mock: func() {
+				loanWithCredit := loans[0]
+				loanWithCredit.CreditBalance = money.New(150000)
+				mockLoanRepo.EXPECT().GetAll().Return([]entity.Loan{loanWithCredit}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusDue,
+					entity.PaymentStatusUnspecified,
+					entity.PaymentStatusUnspecified,
+				), nil).Once()
+
+				want := schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusPartiallyPaid,
+					entity.PaymentStatusUnspecified,
+				)
+				want[1].PrincipalPaid = money.New(100000)
+				want[1].InterestPaid = money.New(10000)
+				want[2].InterestPaid = money.New(10000)
+				want[2].PrincipalPaid = money.New(30000)
+
+				loanWithCredit.CreditBalance = money.Money{}
+				mockPaymentRepo.EXPECT().CreatePaymentAndUpdateLoanSchedules(entity.Payment{
+					LoanID:                 1,
+					PaymentDate:            time.Date(2024, time.October, 23, 1, 0, 0, 0, time.UTC),
+					AmountPaid:             money.New(150000),
+					PaymentMethod:          entity.PaymentMethodCreditBalance,
+					Status:                 entity.StatusCompleted,
+					ExpectedSettlementDate: time.Date(2024, time.October, 23, 1, 0, 0, 0, time.UTC),
+					Allocations: []entity.PaymentAllocation{
+						{ScheduleID: 2, PrincipalAmount: money.New(100000), InterestAmount: money.New(10000)},
+						{ScheduleID: 3, PrincipalAmount: money.New(30000), InterestAmount: money.New(10000)},
+					},
+				}, want[1:3], loanWithCredit).Return(100, nil).Once()
+			},
+		},
+		{
+			name: "should write status changes in the same transaction as the credit balance payment",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				paymentRepo:      mockPaymentRepo,
+			},
+			wantErr: false,
+			mock: func() {
+				loanWithCredit := loans[0]
+				loanWithCredit.CreditBalance = money.New(50000)
+				mockLoanRepo.EXPECT().GetAll().Return([]entity.Loan{loanWithCredit}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusDue,
+					entity.PaymentStatusUnspecified,
+					entity.PaymentStatusUnspecified,
+				), nil).Once()
+
+				want := schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusDue,
+					entity.PaymentStatusUnspecified,
+				)
+				want[1].InterestPaid = money.New(10000)
+				want[1].PrincipalPaid = money.New(40000)
+
+				loanWithCredit.CreditBalance = money.Money{}
+				mockPaymentRepo.EXPECT().CreatePaymentAndUpdateLoanSchedules(entity.Payment{
+					LoanID:                 1,
+					PaymentDate:            time.Date(2024, time.October, 23, 1, 0, 0, 0, time.UTC),
+					AmountPaid:             money.New(50000),
+					PaymentMethod:          entity.PaymentMethodCreditBalance,
+					Status:                 entity.StatusCompleted,
+					ExpectedSettlementDate: time.Date(2024, time.October, 23, 1, 0, 0, 0, time.UTC),
+					Allocations: []entity.PaymentAllocation{
+						{ScheduleID: 2, PrincipalAmount: money.New(40000), InterestAmount: money.New(10000)},
+					},
+				}, want[1:3], loanWithCredit).Return(100, nil).Once()
+			},
+		},
+		{
+			name: "should charge a penalty on schedules that became overdue",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				chargeRepo:       mockChargeRepo,
+			},
+			wantErr: false,
+			mock: func() {
+				penalised := loans[0]
+				penalised.ProductCode = "flat_penalty"
+				mockLoanRepo.EXPECT().GetAll().Return([]entity.Loan{penalised}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusDue,
+					entity.PaymentStatusUnspecified,
+				), nil).Once()
+				mockChargeRepo.EXPECT().GetByLoanID(1).Return(nil, nil).Once()
+
+				want := schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusDue,
+				)
+				want[1].FeeAmount = money.New(5000)
+				want[1].TotalDue = money.New(115000)
+				mockChargeRepo.EXPECT().CreateChargesAndUpdateLoanSchedules([]entity.Charge{
+					{
+						LoanID:       1,
+						ScheduleID:   2,
+						ChargeType:   entity.ChargeTypeLatePayment,
+						Amount:       money.New(5000),
+						AssessedDate: time.Date(2024, time.October, 23, 0, 0, 0, 0, time.UTC),
+					},
+				}, want[1:2]).Return([]int{1}, nil).Once()
+				mockLoanScheduleRepo.EXPECT().Update(want[2]).Return(nil).Once()
+			},
+		},
+		{
+			name: "should accrue daily penalties up to the cap",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				chargeRepo:       mockChargeRepo,
+			},
+			wantErr: false,
+			mock: func() {
+				penalised := loans[0]
+				penalised.ProductCode = "daily_penalty"
+				mockLoanRepo.EXPECT().GetAll().Return([]entity.Loan{penalised}, nil).Once()
+				schedules := schedulesWithStatus(
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusDue,
+				)
+				schedules[0].Charge(money.New(5000))
+				schedules[1].Charge(money.New(1000))
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedules, nil).Once()
+				mockChargeRepo.EXPECT().GetByLoanID(1).Return([]entity.Charge{
+					{ChargeID: 1, LoanID: 1, ScheduleID: 1, ChargeType: entity.ChargeTypeLatePayment, Amount: money.New(5000)},
+					{ChargeID: 2, LoanID: 1, ScheduleID: 2, ChargeType: entity.ChargeTypeLatePayment, Amount: money.New(1000)},
+				}, nil).Once()
+
+				// two days past due accrue 2000, of which 1000 was charged
+				want := schedules[1]
+				want.Charge(money.New(1000))
+				mockChargeRepo.EXPECT().CreateChargesAndUpdateLoanSchedules([]entity.Charge{
+					{
+						LoanID:       1,
+						ScheduleID:   2,
+						ChargeType:   entity.ChargeTypeLatePayment,
+						Amount:       money.New(1000),
+						AssessedDate: time.Date(2024, time.October, 23, 0, 0, 0, 0, time.UTC),
+					},
+				}, []entity.LoanSchedule{want}).Return([]int{3}, nil).Once()
+			},
+		},
+		{
+			name: "should sync the status of every borrower",
+			fields: fields{
+				borrowerRepo: mockBorrowerRepo,
+				loanRepo:     mockLoanRepo,
+			},
+			wantErr: false,
+			mock: func() {
+				paid := loans[1]
+				paid.BorrowerID = 20
+				mockLoanRepo.EXPECT().GetAll().Return([]entity.Loan{paid}, nil).Once()
+				mockBorrowerRepo.EXPECT().GetByID(20).Return(entity.Borrower{
+					BorrowerID:    20,
+					AccountStatus: entity.AccountStatusActive,
+				}, nil).Once()
+				mockLoanRepo.EXPECT().GetByBorrowerID(20).Return([]entity.Loan{paid}, nil).Once()
+				mockBorrowerRepo.EXPECT().UpdateAccountStatus(entity.BorrowerStatusChange{
+					BorrowerID:  20,
+					FromStatus:  entity.AccountStatusActive,
+					ToStatus:    entity.AccountStatusClosed,
+					ChangedDate: time.Date(2024, time.October, 23, 0, 0, 0, 0, time.UTC),
+					Reason:      "no loan is left to repay",
+				}).Return(1, nil).Once()
+			},
+		},
+		{
+			name: "should return error if schedule update fail",
+			fields: fields{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+			},
+			wantErr: true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetAll().Return(loans, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusUnspecified,
+				), nil).Once()
+
+				want := schedulesWithStatus(
+					entity.PaymentStatusPaid,
+					entity.PaymentStatusOverdue,
+					entity.PaymentStatusDue,
+				)
+				mockLoanScheduleRepo.EXPECT().Update(want[2]).Return(repository.ErrConcurrentModification).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				borrowerRepo:     tt.fields.borrowerRepo,
+				loanRepo:         tt.fields.loanRepo,
+				loanScheduleRepo: tt.fields.loanScheduleRepo,
+				paymentRepo:      tt.fields.paymentRepo,
+				chargeRepo:       tt.fields.chargeRepo,
+				allocationOrder:  entity.DefaultAllocationOrder,
+				products:         products,
+				timeNow: func() time.Time {
+					return time.Date(2024, time.October, 23, 1, 0, 0, 0, time.UTC)
+				},
+			}
+			if err := s.UpdateScheduleStatuses(); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateScheduleStatuses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoanService_statusAt(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	holidays, err := calendar.New(
+		calendar.Holiday{Date: date(time.April, 10), Name: "Idul Fitri"},
+		calendar.Holiday{Date: date(time.April, 11), Name: "Idul Fitri"},
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		calendar *calendar.Calendar
+		dueDate  time.Time
+		now      time.Time
+		want     string
+	}{
+		{
+			name:     "should keep an installment due on a holiday due on the next business day",
+			calendar: holidays,
+			dueDate:  date(time.April, 10),
+			now:      date(time.April, 12),
+			want:     entity.PaymentStatusDue,
+		},
+		{
+			name:     "should make an installment due on a holiday overdue after the next business day",
+			calendar: holidays,
+			dueDate:  date(time.April, 10),
+			now:      date(time.April, 13),
+			want:     entity.PaymentStatusOverdue,
+		},
+		{
+			name:    "should keep an installment due on a saturday due until monday without a calendar",
+			dueDate: date(time.April, 6),
+			now:     date(time.April, 8),
+			want:    entity.PaymentStatusDue,
+		},
+		{
+			name:     "should make an installment due on a business day overdue the day after",
+			calendar: holidays,
+			dueDate:  date(time.April, 9),
+			now:      date(time.April, 10),
+			want:     entity.PaymentStatusOverdue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &LoanService{calendar: tt.calendar}
+			schedule := entity.LoanSchedule{DueDate: tt.dueDate, TotalDue: money.New(110000)}
+			if got := s.statusAt(schedule, tt.dueDate.AddDate(0, 0, -7), tt.now); got != tt.want {
+				t.Errorf("statusAt() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
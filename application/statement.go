@@ -0,0 +1,84 @@
+package application
+
+import (
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// Statement is the account view of a single loan.
+type Statement struct {
+	Loan          entity.Loan
+	Schedules     []entity.LoanSchedule
+	Payments      []entity.Payment
+	Reversals     []entity.PaymentReversal
+	CreditRefunds []entity.CreditRefund
+	// Charges are the penalties assessed on the schedules, and Adjustments
+	// what was waived of them.
+	Charges     []entity.Charge
+	Adjustments []entity.ChargeAdjustment
+	// Outstanding is the unpaid amount of every schedule, charges included.
+	Outstanding money.Money
+	// CreditBalance is money received ahead of schedules becoming due.
+	CreditBalance money.Money
+	// NetOutstanding is Outstanding less CreditBalance, as GetOutstanding
+	// reports it.
+	NetOutstanding money.Money
+	// Pending is what has been paid but not cleared yet. It is not deducted
+	// from either outstanding amount.
+	Pending money.Money
+}
+
+// GetStatement returns the loan with its schedules, payments, reversals,
+// refunds and charges, and the outstanding amount with and without the credit
+// balance applied.
+func (s *LoanService) GetStatement(loanID int) (Statement, error) {
+	loan, err := s.loanRepo.GetByID(loanID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	schedules, err := s.loanScheduleRepo.GetByLoanID(loanID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	payments, err := s.paymentRepo.GetByLoanID(loanID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	reversals, err := s.paymentRepo.GetReversalsByLoanID(loanID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	refunds, err := s.paymentRepo.GetCreditRefundsByLoanID(loanID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	charges, err := s.chargeRepo.GetByLoanID(loanID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	adjustments, err := s.chargeRepo.GetAdjustmentsByLoanID(loanID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	outstanding := schedulesOutstanding(schedules)
+	return Statement{
+		Loan:           loan,
+		Schedules:      schedules,
+		Payments:       payments,
+		Reversals:      reversals,
+		CreditRefunds:  refunds,
+		Charges:        charges,
+		Adjustments:    adjustments,
+		Outstanding:    outstanding,
+		CreditBalance:  loan.CreditBalance,
+		NetOutstanding: netOutstanding(outstanding, loan.CreditBalance),
+		Pending:        pendingAmount(payments),
+	}, nil
+}
@@ -0,0 +1,148 @@
+package application
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	mocks "github.com/iqbalbachmid/billing-engine/mocks/domain/repository"
+)
+
+func TestLoanService_GetStatement(t *testing.T) {
+	var (
+		mockLoanRepo         = mocks.NewLoanRepository(t)
+		mockLoanScheduleRepo = mocks.NewLoanScheduleRepository(t)
+		mockPaymentRepo      = mocks.NewPaymentRepository(t)
+		mockChargeRepo       = mocks.NewChargeRepository(t)
+	)
+
+	loan := entity.Loan{
+		LoanID:        1,
+		LoanStatus:    entity.LoanStatusActive,
+		CreditBalance: money.New(30000),
+	}
+	schedules := []entity.LoanSchedule{
+		{
+			ScheduleID:      1,
+			LoanID:          1,
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			PrincipalPaid:   money.New(100000),
+			InterestPaid:    money.New(10000),
+			PaymentStatus:   entity.PaymentStatusPaid,
+		},
+		{
+			ScheduleID:      2,
+			LoanID:          1,
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			FeeAmount:       money.New(5000),
+			TotalDue:        money.New(115000),
+			PaymentStatus:   entity.PaymentStatusOverdue,
+		},
+	}
+	payments := []entity.Payment{
+		{
+			PaymentID:     1,
+			LoanID:        1,
+			PaymentDate:   time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC),
+			AmountPaid:    money.New(140000),
+			PaymentMethod: "bank_transfer",
+			Status:        entity.StatusCompleted,
+		},
+	}
+
+	charges := []entity.Charge{
+		{
+			ChargeID:     1,
+			LoanID:       1,
+			ScheduleID:   2,
+			ChargeType:   entity.ChargeTypeLatePayment,
+			Amount:       money.New(10000),
+			WaivedAmount: money.New(5000),
+			AssessedDate: time.Date(2024, time.October, 22, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	adjustments := []entity.ChargeAdjustment{
+		{
+			AdjustmentID:   1,
+			ChargeID:       1,
+			AdjustmentType: entity.AdjustmentTypeWaiver,
+			Amount:         money.New(5000),
+			AdjustmentDate: time.Date(2024, time.October, 23, 0, 0, 0, 0, time.UTC),
+			Reason:         "first late payment",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		want    Statement
+		wantErr bool
+		mock    func()
+	}{
+		{
+			name:    "should return error if loan not found",
+			wantErr: true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(entity.Loan{}, repository.ErrNotFound).Once()
+			},
+		},
+		{
+			name:    "should return error if payment repo fail",
+			wantErr: true,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedules, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(1).Return(nil, errors.New("failed to get payments")).Once()
+			},
+		},
+		{
+			name: "should show outstanding both before and after credit balance",
+			want: Statement{
+				Loan:           loan,
+				Schedules:      schedules,
+				Payments:       payments,
+				Charges:        charges,
+				Adjustments:    adjustments,
+				Outstanding:    money.New(115000),
+				CreditBalance:  money.New(30000),
+				NetOutstanding: money.New(85000),
+			},
+			wantErr: false,
+			mock: func() {
+				mockLoanRepo.EXPECT().GetByID(1).Return(loan, nil).Once()
+				mockLoanScheduleRepo.EXPECT().GetByLoanID(1).Return(schedules, nil).Once()
+				mockPaymentRepo.EXPECT().GetByLoanID(1).Return(payments, nil).Once()
+				mockPaymentRepo.EXPECT().GetReversalsByLoanID(1).Return(nil, nil).Once()
+				mockPaymentRepo.EXPECT().GetCreditRefundsByLoanID(1).Return(nil, nil).Once()
+				mockChargeRepo.EXPECT().GetByLoanID(1).Return(charges, nil).Once()
+				mockChargeRepo.EXPECT().GetAdjustmentsByLoanID(1).Return(adjustments, nil).Once()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			s := &LoanService{
+				loanRepo:         mockLoanRepo,
+				loanScheduleRepo: mockLoanScheduleRepo,
+				paymentRepo:      mockPaymentRepo,
+				chargeRepo:       mockChargeRepo,
+			}
+			got, err := s.GetStatement(1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetStatement() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetStatement() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
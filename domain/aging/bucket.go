@@ -0,0 +1,31 @@
+package aging
+
+// Bucket groups loans by how many days past due they are.
+type Bucket string
+
+const (
+	BucketCurrent Bucket = "current"
+	Bucket1To30   Bucket = "1-30"
+	Bucket31To60  Bucket = "31-60"
+	Bucket61To90  Bucket = "61-90"
+	BucketOver90  Bucket = "90+"
+)
+
+// Buckets lists every bucket from current to the most overdue.
+var Buckets = []Bucket{BucketCurrent, Bucket1To30, Bucket31To60, Bucket61To90, BucketOver90}
+
+// BucketFor classifies a loan that is daysPastDue days past due.
+func BucketFor(daysPastDue int) Bucket {
+	switch {
+	case daysPastDue <= 0:
+		return BucketCurrent
+	case daysPastDue <= 30:
+		return Bucket1To30
+	case daysPastDue <= 60:
+		return Bucket31To60
+	case daysPastDue <= 90:
+		return Bucket61To90
+	default:
+		return BucketOver90
+	}
+}
@@ -0,0 +1,29 @@
+package aging
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBucketFor(t *testing.T) {
+	tests := []struct {
+		daysPastDue int
+		want        Bucket
+	}{
+		{daysPastDue: 0, want: BucketCurrent},
+		{daysPastDue: 1, want: Bucket1To30},
+		{daysPastDue: 30, want: Bucket1To30},
+		{daysPastDue: 31, want: Bucket31To60},
+		{daysPastDue: 60, want: Bucket31To60},
+		{daysPastDue: 61, want: Bucket61To90},
+		{daysPastDue: 90, want: Bucket61To90},
+		{daysPastDue: 91, want: BucketOver90},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.daysPastDue), func(t *testing.T) {
+			if got := BucketFor(tt.daysPastDue); got != tt.want {
+				t.Errorf("BucketFor() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrYearNotCovered is returned for a date in a year the calendar lists no
+// holidays for, so that it cannot tell whether the date is a holiday.
+var ErrYearNotCovered = errors.New("no holidays are known for the year")
+
+// Convention decides where a date that is not a business day rolls to.
+type Convention string
+
+const (
+	// Unadjusted leaves dates where they fall.
+	Unadjusted Convention = ""
+	// Following rolls to the next business day.
+	Following Convention = "following"
+	// ModifiedFollowing rolls to the next business day, unless that is in
+	// the following month, in which case it rolls to the previous one.
+	ModifiedFollowing Convention = "modified_following"
+	// Preceding rolls to the previous business day.
+	Preceding Convention = "preceding"
+)
+
+// Valid reports whether the convention is one Adjust implements.
+func (c Convention) Valid() bool {
+	switch c {
+	case Unadjusted, Following, ModifiedFollowing, Preceding:
+		return true
+	default:
+		return false
+	}
+}
+
+// Holiday is a public holiday on which no installment falls due.
+type Holiday struct {
+	Date time.Time
+	Name string
+}
+
+// Calendar tells business days from weekends and public holidays. A nil
+// Calendar knows no holidays, so only weekends are not business days.
+type Calendar struct {
+	holidays map[civilDate]string
+	years    map[int]bool
+}
+
+type civilDate struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) civilDate {
+	year, month, day := t.Date()
+	return civilDate{year: year, month: month, day: day}
+}
+
+// New returns a calendar with the given holidays, which covers the years they
+// fall in. Two holidays on the same date are an error.
+func New(holidays ...Holiday) (*Calendar, error) {
+	c := &Calendar{
+		holidays: make(map[civilDate]string, len(holidays)),
+		years:    make(map[int]bool),
+	}
+	for _, holiday := range holidays {
+		date := dateOf(holiday.Date)
+		if name, ok := c.holidays[date]; ok {
+			return nil, fmt.Errorf("%s is listed as both %q and %q", holiday.Date.Format(time.DateOnly), name, holiday.Name)
+		}
+		c.holidays[date] = holiday.Name
+		c.years[date.year] = true
+	}
+	return c, nil
+}
+
+// CheckYear returns ErrYearNotCovered if the calendar lists no holidays for
+// the year of t, whose holidays it then takes for business days. A nil
+// Calendar knows no holidays by design, so it covers every year.
+func (c *Calendar) CheckYear(t time.Time) error {
+	if c == nil || c.years[t.Year()] {
+		return nil
+	}
+	return fmt.Errorf("%w: %d", ErrYearNotCovered, t.Year())
+}
+
+// Holiday returns the name of the holiday on the day of t, if there is one.
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	name, ok := c.holidays[dateOf(t)]
+	return name, ok
+}
+
+// IsBusinessDay reports whether the day of t is neither a weekend nor a
+// holiday.
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	if weekday := t.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return false
+	}
+	_, holiday := c.Holiday(t)
+	return !holiday
+}
+
+// Adjust rolls t to a business day by the convention. Business days are
+// returned as they are.
+func (c *Calendar) Adjust(t time.Time, convention Convention) time.Time {
+	switch convention {
+	case Following:
+		return c.roll(t, 1)
+	case ModifiedFollowing:
+		if following := c.roll(t, 1); following.Month() == t.Month() {
+			return following
+		}
+		return c.roll(t, -1)
+	case Preceding:
+		return c.roll(t, -1)
+	default:
+		return t
+	}
+}
+
+// roll moves t by step days until it reaches a business day.
+func (c *Calendar) roll(t time.Time, step int) time.Time {
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, step)
+	}
+	return t
+}
@@ -0,0 +1,73 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCalendar_Adjust(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	calendar, err := New(
+		Holiday{Date: date(time.April, 10), Name: "Idul Fitri"},
+		Holiday{Date: date(time.April, 11), Name: "Idul Fitri"},
+		Holiday{Date: date(time.May, 31), Name: "Company holiday"},
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		date       time.Time
+		convention Convention
+		want       time.Time
+	}{
+		{name: "should keep a business day", date: date(time.April, 9), convention: Following, want: date(time.April, 9)},
+		{name: "should leave dates unadjusted", date: date(time.April, 10), convention: Unadjusted, want: date(time.April, 10)},
+		{name: "should roll past holidays to the next business day", date: date(time.April, 10), convention: Following, want: date(time.April, 12)},
+		{name: "should roll over the weekend to monday", date: date(time.March, 30), convention: Following, want: date(time.April, 1)},
+		{name: "should roll back to the previous business day", date: date(time.April, 11), convention: Preceding, want: date(time.April, 9)},
+		{name: "should roll forward within the month", date: date(time.April, 10), convention: ModifiedFollowing, want: date(time.April, 12)},
+		// Jun 1, 2024 is a Saturday, so the next business day is Jun 3
+		{name: "should roll back rather than into the next month", date: date(time.May, 31), convention: ModifiedFollowing, want: date(time.May, 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calendar.Adjust(tt.date, tt.convention); !got.Equal(tt.want) {
+				t.Errorf("Adjust() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	day := time.Date(2024, time.August, 17, 0, 0, 0, 0, time.UTC)
+	if _, err := New(Holiday{Date: day, Name: "Independence Day"}, Holiday{Date: day, Name: "Other"}); err == nil {
+		t.Errorf("New() with two holidays on the same date should return error")
+	}
+
+	covering, err := New(Holiday{Date: day, Name: "Independence Day"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = covering.CheckYear(day.AddDate(0, 3, 0)); err != nil {
+		t.Errorf("CheckYear() in a year with holidays error = %v, want nil", err)
+	}
+	if err = covering.CheckYear(day.AddDate(1, 0, 0)); !errors.Is(err, ErrYearNotCovered) {
+		t.Errorf("CheckYear() in a year without holidays error = %v, want %v", err, ErrYearNotCovered)
+	}
+
+	var calendar *Calendar
+	if err = calendar.CheckYear(day); err != nil {
+		t.Errorf("CheckYear() of a nil calendar error = %v, want nil", err)
+	}
+	if !calendar.IsBusinessDay(day.AddDate(0, 0, -1)) {
+		t.Errorf("IsBusinessDay() of a nil calendar on a friday = false, want true")
+	}
+	if calendar.IsBusinessDay(day) {
+		t.Errorf("IsBusinessDay() on a saturday = true, want false")
+	}
+}
@@ -0,0 +1,93 @@
+package daycount
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Convention decides what fraction of a year the interest accrual period
+// between two dates counts as.
+type Convention string
+
+const (
+	// PerTenor counts no days: the loan's rate is charged over its whole
+	// tenor and spread evenly over the installments, however long they are.
+	PerTenor Convention = ""
+	// Actual365 counts the actual days over a 365-day year.
+	Actual365 Convention = "act_365"
+	// Actual360 counts the actual days over a 360-day year.
+	Actual360 Convention = "act_360"
+	// Thirty360 counts every month as 30 days over a 360-day year, by the
+	// bond basis rules for the 31st.
+	Thirty360 Convention = "30_360"
+	// ActualActual counts the actual days falling in each year over that
+	// year's length, 365 or 366 days (ISDA).
+	ActualActual Convention = "act_act"
+)
+
+// Valid reports whether the convention is one the engine implements.
+func (c Convention) Valid() bool {
+	switch c {
+	case PerTenor, Actual365, Actual360, Thirty360, ActualActual:
+		return true
+	default:
+		return false
+	}
+}
+
+// YearFraction returns the fraction of a year from the day of from to the
+// day of to under the convention, exactly. It is negative if to is before
+// from. PerTenor has no year fractions.
+func (c Convention) YearFraction(from, to time.Time) (*big.Rat, error) {
+	from, to = civil(from), civil(to)
+
+	switch c {
+	case Actual365:
+		return big.NewRat(days(from, to), 365), nil
+	case Actual360:
+		return big.NewRat(days(from, to), 360), nil
+	case Thirty360:
+		d1, d2 := from.Day(), to.Day()
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 && d1 == 30 {
+			d2 = 30
+		}
+		n := 360*(to.Year()-from.Year()) + 30*(int(to.Month())-int(from.Month())) + d2 - d1
+		return big.NewRat(int64(n), 360), nil
+	case ActualActual:
+		if to.Before(from) {
+			fraction, err := c.YearFraction(to, from)
+			if err != nil {
+				return nil, err
+			}
+			return fraction.Neg(fraction), nil
+		}
+		fraction := new(big.Rat)
+		for start := from; start.Before(to); {
+			nextYear := time.Date(start.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+			end := nextYear
+			if to.Before(end) {
+				end = to
+			}
+			fraction.Add(fraction, big.NewRat(days(start, end), days(nextYear.AddDate(-1, 0, 0), nextYear)))
+			start = end
+		}
+		return fraction, nil
+	default:
+		return nil, fmt.Errorf("day count convention %q has no year fractions", c)
+	}
+}
+
+// civil returns the calendar day of t at midnight UTC, so that days between
+// two of them are whole regardless of time zone transitions.
+func civil(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func days(from, to time.Time) int64 {
+	return int64(to.Sub(from) / (24 * time.Hour))
+}
@@ -0,0 +1,97 @@
+package daycount
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestConvention_YearFraction(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		convention Convention
+		from, to   time.Time
+		want       *big.Rat
+		wantErr    bool
+	}{
+		{
+			name:       "should count actual days over 365",
+			convention: Actual365,
+			from:       date(2024, time.January, 31),
+			to:         date(2024, time.February, 29),
+			want:       big.NewRat(29, 365),
+		},
+		{
+			name:       "should count actual days over 360",
+			convention: Actual360,
+			from:       date(2024, time.January, 31),
+			to:         date(2024, time.February, 29),
+			want:       big.NewRat(29, 360),
+		},
+		{
+			name:       "should count months as 30 days",
+			convention: Thirty360,
+			from:       date(2024, time.January, 31),
+			to:         date(2024, time.February, 29),
+			want:       big.NewRat(29, 360),
+		},
+		{
+			name:       "should count the 31st as the 30th when the period starts on the 30th or 31st",
+			convention: Thirty360,
+			from:       date(2024, time.March, 31),
+			to:         date(2024, time.May, 31),
+			want:       big.NewRat(60, 360),
+		},
+		{
+			name:       "should keep the 31st when the period starts earlier in the month",
+			convention: Thirty360,
+			from:       date(2024, time.May, 15),
+			to:         date(2024, time.May, 31),
+			want:       big.NewRat(16, 360),
+		},
+		{
+			name:       "should count days of each year over its own length",
+			convention: ActualActual,
+			from:       date(2023, time.December, 1),
+			to:         date(2024, time.January, 31),
+			// 31 days of 2023 and 30 of leap year 2024
+			want: new(big.Rat).Add(big.NewRat(31, 365), big.NewRat(30, 366)),
+		},
+		{
+			name:       "should be negative backwards",
+			convention: ActualActual,
+			from:       date(2024, time.February, 1),
+			to:         date(2024, time.January, 1),
+			want:       big.NewRat(-31, 366),
+		},
+		{
+			name:       "should ignore the time of day",
+			convention: Actual365,
+			from:       time.Date(2024, time.October, 28, 23, 0, 0, 0, time.FixedZone("WIB", 7*60*60)),
+			to:         date(2024, time.November, 4),
+			want:       big.NewRat(7, 365),
+		},
+		{
+			name:       "should return error for per tenor",
+			convention: PerTenor,
+			from:       date(2024, time.January, 1),
+			to:         date(2024, time.February, 1),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.convention.YearFraction(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("YearFraction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Cmp(tt.want) != 0 {
+				t.Errorf("YearFraction() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package delinquency
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// Result is the outcome of evaluating a loan against a Policy.
+type Result struct {
+	Delinquent bool
+	// Reason says why the loan was flagged. It is empty for loans that are
+	// not delinquent.
+	Reason string
+}
+
+// Policy decides whether a loan is delinquent from its schedules, ordered by
+// due date, and how often they fall due, as of the given time.
+type Policy interface {
+	Evaluate(schedules []entity.LoanSchedule, repayment entity.Repayment, asOf time.Time) Result
+}
+
+// ConsecutiveOverdue flags a loan with at least Limit overdue installments in a
+// row. Limit is scaled to the loan's frequency as TotalOverdue's is.
+type ConsecutiveOverdue struct {
+	Limit int
+}
+
+func (p ConsecutiveOverdue) Evaluate(schedules []entity.LoanSchedule, repayment entity.Repayment, _ time.Time) Result {
+	limit := weeklyInstallments(p.Limit, repayment)
+	var run int
+	for _, schedule := range schedules {
+		if !schedule.IsOverdue() {
+			run = 0
+			continue
+		}
+		run++
+		if run >= limit {
+			return flagged("%d consecutive installments are overdue", run)
+		}
+	}
+	return Result{}
+}
+
+// TotalOverdue flags a loan with at least Limit overdue installments. Limit
+// counts installments of a weekly loan: one repaid at another frequency is
+// flagged once as many installments are overdue as fall due in Limit weeks,
+// rounded up, e.g. 10 of a daily loan or one of a monthly loan for a Limit of
+// 2, so that loans of a product are flagged after about as long whatever their
+// frequency.
+type TotalOverdue struct {
+	Limit int
+}
+
+func (p TotalOverdue) Evaluate(schedules []entity.LoanSchedule, repayment entity.Repayment, _ time.Time) Result {
+	var overdue int
+	for _, schedule := range schedules {
+		if schedule.IsOverdue() {
+			overdue++
+		}
+	}
+	if overdue >= weeklyInstallments(p.Limit, repayment) {
+		return flagged("%d installments are overdue", overdue)
+	}
+	return Result{}
+}
+
+// DaysPastDueOver flags a loan whose oldest overdue installment is more than
+// Threshold days past its due date.
+type DaysPastDueOver struct {
+	Threshold int
+}
+
+func (p DaysPastDueOver) Evaluate(schedules []entity.LoanSchedule, _ entity.Repayment, asOf time.Time) Result {
+	if days := DaysPastDue(schedules, asOf); days > p.Threshold {
+		return flagged("%d days past due", days)
+	}
+	return Result{}
+}
+
+// OverdueShare flags a loan whose overdue amount is more than Percent of what
+// is outstanding on it.
+type OverdueShare struct {
+	Percent float64
+}
+
+func (p OverdueShare) Evaluate(schedules []entity.LoanSchedule, _ entity.Repayment, _ time.Time) Result {
+	var overdue, outstanding money.Money
+	for _, schedule := range schedules {
+		outstanding = outstanding.Add(schedule.Outstanding())
+		if schedule.IsOverdue() {
+			overdue = overdue.Add(schedule.Outstanding())
+		}
+	}
+	if !overdue.IsPositive() {
+		return Result{}
+	}
+	if overdue.GreaterThan(outstanding.Percent(p.Percent, money.RoundHalfUp)) {
+		return flagged("%s of %s outstanding is overdue", overdue, outstanding)
+	}
+	return Result{}
+}
+
+// Any flags a loan as soon as one of its policies does, with that policy's
+// reason.
+type Any []Policy
+
+func (p Any) Evaluate(schedules []entity.LoanSchedule, repayment entity.Repayment, asOf time.Time) Result {
+	for _, policy := range p {
+		if result := policy.Evaluate(schedules, repayment, asOf); result.Delinquent {
+			return result
+		}
+	}
+	return Result{}
+}
+
+// weeklyInstallments scales limit, a number of installments of a weekly loan,
+// to the installments of the repayment's frequency falling due in as many
+// weeks, rounded up.
+func weeklyInstallments(limit int, repayment entity.Repayment) int {
+	weekly := entity.Repayment{Frequency: entity.FrequencyWeekly}.InstallmentsPerYear()
+	return (limit*repayment.InstallmentsPerYear() + weekly - 1) / weekly
+}
+
+// DaysPastDue is how many days the oldest overdue schedule is past its due
+// date as of asOf, or zero if none is overdue.
+func DaysPastDue(schedules []entity.LoanSchedule, asOf time.Time) int {
+	for _, schedule := range schedules {
+		if schedule.IsOverdue() {
+			return schedule.DaysPastDue(asOf)
+		}
+	}
+	return 0
+}
+
+func flagged(format string, args ...any) Result {
+	return Result{
+		Delinquent: true,
+		Reason:     fmt.Sprintf(format, args...),
+	}
+}
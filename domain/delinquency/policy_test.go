@@ -0,0 +1,128 @@
+package delinquency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+func TestPolicies_Evaluate(t *testing.T) {
+	asOf := time.Date(2024, time.November, 14, 9, 30, 0, 0, time.UTC)
+	schedule := func(week int, status string) entity.LoanSchedule {
+		return entity.LoanSchedule{
+			DueDate:       time.Date(2024, time.October, 7+7*week, 0, 0, 0, 0, time.UTC),
+			TotalDue:      money.New(110000),
+			PaymentStatus: status,
+		}
+	}
+	// overdue, paid, overdue, overdue, due and three installments to come
+	schedules := []entity.LoanSchedule{
+		schedule(1, entity.PaymentStatusOverdue),
+		schedule(2, entity.PaymentStatusPaid),
+		schedule(3, entity.PaymentStatusOverdue),
+		schedule(4, entity.PaymentStatusOverdue),
+		schedule(5, entity.PaymentStatusDue),
+		schedule(6, entity.PaymentStatusUnspecified),
+		schedule(7, entity.PaymentStatusUnspecified),
+		schedule(8, entity.PaymentStatusUnspecified),
+	}
+	schedules[1].PrincipalPaid = money.New(110000)
+
+	daily := entity.Repayment{Frequency: entity.FrequencyDaily}
+	monthly := entity.Repayment{Frequency: entity.FrequencyMonthly}
+
+	tests := []struct {
+		name      string
+		policy    Policy
+		repayment entity.Repayment
+		want      Result
+	}{
+		{
+			name:   "consecutive overdue at limit",
+			policy: ConsecutiveOverdue{Limit: 2},
+			want:   Result{Delinquent: true, Reason: "2 consecutive installments are overdue"},
+		},
+		{
+			name:   "consecutive overdue under limit",
+			policy: ConsecutiveOverdue{Limit: 3},
+		},
+		{
+			name:   "total overdue at limit",
+			policy: TotalOverdue{Limit: 3},
+			want:   Result{Delinquent: true, Reason: "3 installments are overdue"},
+		},
+		{
+			name:   "total overdue under limit",
+			policy: TotalOverdue{Limit: 4},
+		},
+		{
+			name:      "total overdue limit scaled down for a monthly loan",
+			policy:    TotalOverdue{Limit: 4},
+			repayment: monthly,
+			want:      Result{Delinquent: true, Reason: "3 installments are overdue"},
+		},
+		{
+			name:      "total overdue limit scaled up for a daily loan",
+			policy:    TotalOverdue{Limit: 2},
+			repayment: daily,
+		},
+		{
+			name:      "consecutive overdue limit scaled down for a monthly loan",
+			policy:    ConsecutiveOverdue{Limit: 3},
+			repayment: monthly,
+			want:      Result{Delinquent: true, Reason: "1 consecutive installments are overdue"},
+		},
+		{
+			name:   "days past due over threshold",
+			policy: DaysPastDueOver{Threshold: 30},
+			want:   Result{Delinquent: true, Reason: "31 days past due"},
+		},
+		{
+			name:   "days past due at threshold",
+			policy: DaysPastDueOver{Threshold: 31},
+		},
+		{
+			name:   "overdue share over percent",
+			policy: OverdueShare{Percent: 40},
+			want:   Result{Delinquent: true, Reason: "330000.00 of 770000.00 outstanding is overdue"},
+		},
+		{
+			name:   "overdue share under percent",
+			policy: OverdueShare{Percent: 50},
+		},
+		{
+			name:   "any reports the first policy that flags",
+			policy: Any{TotalOverdue{Limit: 4}, DaysPastDueOver{Threshold: 30}, ConsecutiveOverdue{Limit: 2}},
+			want:   Result{Delinquent: true, Reason: "31 days past due"},
+		},
+		{
+			name:   "any with no policy flagging",
+			policy: Any{TotalOverdue{Limit: 4}, ConsecutiveOverdue{Limit: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Evaluate(schedules, tt.repayment, asOf); got != tt.want {
+				t.Errorf("Evaluate() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaysPastDue(t *testing.T) {
+	asOf := time.Date(2024, time.October, 30, 23, 0, 0, 0, time.UTC)
+	schedules := []entity.LoanSchedule{
+		{DueDate: time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC), PaymentStatus: entity.PaymentStatusPaid},
+		{DueDate: time.Date(2024, time.October, 21, 0, 0, 0, 0, time.UTC), PaymentStatus: entity.PaymentStatusOverdue},
+		{DueDate: time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC), PaymentStatus: entity.PaymentStatusOverdue},
+	}
+
+	if got := DaysPastDue(schedules, asOf); got != 9 {
+		t.Errorf("DaysPastDue() got = %v, want %v", got, 9)
+	}
+	if got := DaysPastDue(schedules[:1], asOf); got != 0 {
+		t.Errorf("DaysPastDue() without overdue schedules got = %v, want %v", got, 0)
+	}
+}
@@ -0,0 +1,29 @@
+package entity
+
+const (
+	// AmortizationFlat charges interest on the original principal and spreads
+	// principal and interest evenly over the installments.
+	AmortizationFlat = "flat"
+	// AmortizationAnnuity charges interest on the outstanding balance with
+	// equal installments, so the principal share grows as the interest share
+	// declines.
+	AmortizationAnnuity = "annuity"
+	// AmortizationEqualPrincipal charges interest on the outstanding balance
+	// with equal principal repayments, so installments decline.
+	AmortizationEqualPrincipal = "equal_principal"
+	// AmortizationInterestOnly charges interest on the principal every
+	// installment and repays the principal as a balloon with the last one.
+	AmortizationInterestOnly = "interest_only"
+	// AmortizationStepped charges interest on the outstanding balance with
+	// installments that change by a fixed percentage each period.
+	AmortizationStepped = "stepped"
+)
+
+// Amortization decides how a loan's installments are split between principal
+// and interest. A loan without a method is amortized flat.
+type Amortization struct {
+	Method string `db:"amortization_method"`
+	// Step is the percentage by which each installment of a stepped loan
+	// differs from the previous one: positive steps up, negative steps down.
+	Step float64 `db:"installment_step"`
+}
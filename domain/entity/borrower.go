@@ -2,6 +2,12 @@ package entity
 
 import "time"
 
+const (
+	AccountStatusActive     = "active"
+	AccountStatusDelinquent = "delinquent"
+	AccountStatusClosed     = "closed"
+)
+
 type Borrower struct {
 	BorrowerID    int       `db:"borrower_id"`
 	FirstName     string    `db:"first_name"`
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// BorrowerStatusChange audits a change of a borrower's account status.
+type BorrowerStatusChange struct {
+	ChangeID    int       `db:"change_id"`
+	BorrowerID  int       `db:"borrower_id"`
+	FromStatus  string    `db:"from_status"`
+	ToStatus    string    `db:"to_status"`
+	ChangedDate time.Time `db:"changed_date"`
+	Reason      string    `db:"reason"`
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+const ChargeTypeLatePayment = "late_payment"
+
+const AdjustmentTypeWaiver = "waiver"
+
+// Charge is a fee assessed on a schedule on top of its installment, such as a
+// late payment penalty. It is added to the schedule's fee amount, so payments
+// cover it like any other fee.
+type Charge struct {
+	ChargeID     int         `db:"charge_id"`
+	LoanID       int         `db:"loan_id"`
+	ScheduleID   int         `db:"schedule_id"`
+	ChargeType   string      `db:"charge_type"`
+	Amount       money.Money `db:"amount"`
+	WaivedAmount money.Money `db:"waived_amount"`
+	// CapitalizedAmount is the part of the charge a restructure added to the
+	// loan's principal. It is no longer billed as a fee, so it cannot be
+	// waived either.
+	CapitalizedAmount money.Money `db:"capitalized_amount"`
+	AssessedDate      time.Time   `db:"assessed_date"`
+}
+
+// Waivable is what is left of the charge after earlier waivers and
+// capitalization.
+func (c *Charge) Waivable() money.Money {
+	return c.Amount.Sub(c.WaivedAmount).Sub(c.CapitalizedAmount)
+}
+
+// ChargeAdjustment records a change made to a charge after it was assessed,
+// such as a waiver.
+type ChargeAdjustment struct {
+	AdjustmentID   int         `db:"adjustment_id"`
+	ChargeID       int         `db:"charge_id"`
+	AdjustmentType string      `db:"adjustment_type"`
+	Amount         money.Money `db:"amount"`
+	AdjustmentDate time.Time   `db:"adjustment_date"`
+	Reason         string      `db:"reason"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// CreditRefund records credit balance paid back to the borrower after the
+// loan was closed.
+type CreditRefund struct {
+	RefundID   int         `db:"refund_id"`
+	LoanID     int         `db:"loan_id"`
+	RefundDate time.Time   `db:"refund_date"`
+	Amount     money.Money `db:"amount"`
+}
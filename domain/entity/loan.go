@@ -1,13 +1,88 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/daycount"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/rounding"
+)
+
+const (
+	LoanStatusApplied    = "applied"
+	LoanStatusApproved   = "approved"
+	LoanStatusRejected   = "rejected"
+	LoanStatusActive     = "active"
+	LoanStatusPaid       = "paid"
+	LoanStatusDefaulted  = "defaulted"
+	LoanStatusWrittenOff = "written_off"
+	LoanStatusCancelled  = "cancelled"
+)
 
 type Loan struct {
-	LoanID        int       `db:"loan_id"`
-	BorrowerID    int       `db:"borrower_id"`
-	LoanAmount    float64   `db:"loan_amount"`
+	LoanID      int         `db:"loan_id"`
+	BorrowerID  int         `db:"borrower_id"`
+	ProductCode string      `db:"product_code"`
+	LoanAmount  money.Money `db:"loan_amount"`
+	// InterestRate is the percentage charged over the whole tenor, or yearly
+	// if the loan has a DayCount.
 	InterestRate  float64   `db:"interest_rate"`
 	LoanStartDate time.Time `db:"loan_start_date"`
 	LoanEndDate   time.Time `db:"loan_end_date"`
 	LoanStatus    string    `db:"loan_status"`
+	// Tenor is the number of installments, each falling due a period of the
+	// loan's repayment frequency after the previous one.
+	Tenor         int         `db:"tenor"`
+	ClosedDate    *time.Time  `db:"closed_date"`
+	CreditBalance money.Money `db:"credit_balance"`
+	Version       int         `db:"version"`
+	// Restructured is set once the loan's remaining installments have been
+	// spread again.
+	Restructured bool `db:"restructured"`
+	// DayCount is the convention the loan's interest is accrued by, recorded
+	// from its product when it was created so that its schedule always
+	// regenerates with the same interest.
+	DayCount daycount.Convention `db:"day_count"`
+	// Rounding is how the loan's installments are rounded, recorded from its
+	// product when it was created.
+	Rounding     rounding.Policy
+	Amortization Amortization
+	Repayment    Repayment
+}
+
+func (l *Loan) IsActive() bool {
+	return l.LoanStatus == LoanStatusActive
+}
+
+func (l *Loan) IsPaid() bool {
+	return l.LoanStatus == LoanStatusPaid
+}
+
+func (l *Loan) IsDefaulted() bool {
+	return l.LoanStatus == LoanStatusDefaulted
+}
+
+// IsPayable reports whether the loan is being repaid: it was disbursed and is
+// neither paid off nor written off.
+func (l *Loan) IsPayable() bool {
+	return l.IsActive() || l.IsDefaulted()
+}
+
+// Close marks the loan as paid off on the given date.
+func (l *Loan) Close(closedDate time.Time) error {
+	if err := l.TransitionTo(LoanStatusPaid); err != nil {
+		return err
+	}
+	l.ClosedDate = &closedDate
+	return nil
+}
+
+// Reopen makes a paid loan active again, e.g. when the payment that closed it
+// is reversed.
+func (l *Loan) Reopen() error {
+	if err := l.TransitionTo(LoanStatusActive); err != nil {
+		return err
+	}
+	l.ClosedDate = nil
+	return nil
 }
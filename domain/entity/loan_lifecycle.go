@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+)
+
+// loanTransitions lists the statuses a loan can move to from each status. A
+// loan is applied for, then approved or rejected; an approved loan is
+// disbursed, which makes it active, unless it is cancelled first. An active
+// loan is eventually paid off or defaulted, and a defaulted one is recovered
+// in full or written off. A paid loan becomes active again if the payment that
+// settled it is reversed.
+var loanTransitions = map[string][]string{
+	LoanStatusApplied:   {LoanStatusApproved, LoanStatusRejected, LoanStatusCancelled},
+	LoanStatusApproved:  {LoanStatusActive, LoanStatusCancelled},
+	LoanStatusActive:    {LoanStatusPaid, LoanStatusDefaulted},
+	LoanStatusDefaulted: {LoanStatusPaid, LoanStatusWrittenOff},
+	LoanStatusPaid:      {LoanStatusActive},
+}
+
+// InvalidTransitionError is returned when a loan is asked to move to a status
+// its current one does not lead to.
+type InvalidTransitionError struct {
+	LoanID int
+	From   string
+	To     string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("loan %d cannot go from %s to %s", e.LoanID, e.From, e.To)
+}
+
+// CanTransitionTo reports whether the loan can move to status.
+func (l *Loan) CanTransitionTo(status string) bool {
+	return slices.Contains(loanTransitions[l.LoanStatus], status)
+}
+
+// TransitionTo moves the loan to status, or returns an *InvalidTransitionError
+// if its current status does not lead there.
+func (l *Loan) TransitionTo(status string) error {
+	if !l.CanTransitionTo(status) {
+		return &InvalidTransitionError{LoanID: l.LoanID, From: l.LoanStatus, To: status}
+	}
+	l.LoanStatus = status
+	return nil
+}
@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoan_TransitionTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{name: "should approve an applied loan", from: LoanStatusApplied, to: LoanStatusApproved},
+		{name: "should reject an applied loan", from: LoanStatusApplied, to: LoanStatusRejected},
+		{name: "should cancel an approved loan", from: LoanStatusApproved, to: LoanStatusCancelled},
+		{name: "should disburse an approved loan", from: LoanStatusApproved, to: LoanStatusActive},
+		{name: "should default an active loan", from: LoanStatusActive, to: LoanStatusDefaulted},
+		{name: "should write off a defaulted loan", from: LoanStatusDefaulted, to: LoanStatusWrittenOff},
+		{name: "should reopen a paid loan", from: LoanStatusPaid, to: LoanStatusActive},
+		{name: "should not disburse an applied loan", from: LoanStatusApplied, to: LoanStatusActive, wantErr: true},
+		{name: "should not cancel an active loan", from: LoanStatusActive, to: LoanStatusCancelled, wantErr: true},
+		{name: "should not write off an active loan", from: LoanStatusActive, to: LoanStatusWrittenOff, wantErr: true},
+		{name: "should not reopen a rejected loan", from: LoanStatusRejected, to: LoanStatusApproved, wantErr: true},
+		{name: "should not move a written off loan", from: LoanStatusWrittenOff, to: LoanStatusPaid, wantErr: true},
+		{name: "should not stay in the same status", from: LoanStatusPaid, to: LoanStatusPaid, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loan := Loan{LoanID: 1, LoanStatus: tt.from}
+
+			err := loan.TransitionTo(tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TransitionTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				var transitionErr *InvalidTransitionError
+				if !errors.As(err, &transitionErr) || transitionErr.From != tt.from || transitionErr.To != tt.to {
+					t.Errorf("TransitionTo() error = %v, want an InvalidTransitionError from %v to %v", err, tt.from, tt.to)
+				}
+				if loan.LoanStatus != tt.from {
+					t.Errorf("TransitionTo() status = %v, want %v", loan.LoanStatus, tt.from)
+				}
+				return
+			}
+			if loan.LoanStatus != tt.to {
+				t.Errorf("TransitionTo() status = %v, want %v", loan.LoanStatus, tt.to)
+			}
+		})
+	}
+}
+
+func TestLoan_CloseAndReopen(t *testing.T) {
+	closedDate := time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC)
+	loan := Loan{LoanID: 1, LoanStatus: LoanStatusActive}
+
+	if err := loan.Close(closedDate); err != nil || !loan.IsPaid() || !loan.ClosedDate.Equal(closedDate) {
+		t.Fatalf("Close() error = %v, loan = %+v", err, loan)
+	}
+	if err := loan.Close(closedDate); err == nil {
+		t.Errorf("Close() of a paid loan should return error")
+	}
+	if err := loan.Reopen(); err != nil || !loan.IsActive() || loan.ClosedDate != nil {
+		t.Fatalf("Reopen() error = %v, loan = %+v", err, loan)
+	}
+	if err := loan.Reopen(); err == nil {
+		t.Errorf("Reopen() of an active loan should return error")
+	}
+}
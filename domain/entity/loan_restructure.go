@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// LoanRestructure records the remaining installments of a loan being spread
+// again from EffectiveDate over Tenor new installments at InterestRate.
+// Superseded holds the schedules it replaced, as they were when it was made.
+type LoanRestructure struct {
+	RestructureID      int         `db:"restructure_id"`
+	LoanID             int         `db:"loan_id"`
+	EffectiveDate      time.Time   `db:"effective_date"`
+	Tenor              int         `db:"tenor"`
+	InterestRate       float64     `db:"interest_rate"`
+	CapitalizedArrears money.Money `db:"capitalized_arrears"`
+	RestructuredDate   time.Time   `db:"restructured_date"`
+	Superseded         []LoanSchedule
+}
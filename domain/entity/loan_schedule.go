@@ -1,22 +1,38 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
 
 const (
-	PaymentStatusUnspecified = "unspecified"
-	PaymentStatusDue         = "due"
-	PaymentStatusPaid        = "paid"
-	PaymentStatusOverdue     = "overdue"
+	PaymentStatusUnspecified   = "unspecified"
+	PaymentStatusDue           = "due"
+	PaymentStatusPartiallyPaid = "partially_paid"
+	PaymentStatusPaid          = "paid"
+	PaymentStatusOverdue       = "overdue"
 )
 
 type LoanSchedule struct {
-	ScheduleID      int       `db:"schedule_id"`
-	LoanID          int       `db:"loan_id"`
-	DueDate         time.Time `db:"due_date"`
-	PrincipalAmount float64   `db:"principal_amount"`
-	InterestAmount  float64   `db:"interest_amount"`
-	TotalDue        float64   `db:"total_due"`
-	PaymentStatus   string    `db:"payment_status"`
+	ScheduleID      int         `db:"schedule_id"`
+	LoanID          int         `db:"loan_id"`
+	DueDate         time.Time   `db:"due_date"`
+	PrincipalAmount money.Money `db:"principal_amount"`
+	InterestAmount  money.Money `db:"interest_amount"`
+	FeeAmount       money.Money `db:"fee_amount"`
+	TotalDue        money.Money `db:"total_due"`
+	PrincipalPaid   money.Money `db:"principal_paid"`
+	InterestPaid    money.Money `db:"interest_paid"`
+	FeePaid         money.Money `db:"fee_paid"`
+	// ReservedAmount is what pending payments will pay towards the schedule
+	// once they clear.
+	ReservedAmount money.Money `db:"reserved_amount"`
+	PaymentStatus  string      `db:"payment_status"`
+	Version        int         `db:"version"`
+	// SupersededBy is the restructure that replaced the schedule, or zero
+	// while it is current.
+	SupersededBy int `db:"superseded_by"`
 }
 
 func (l *LoanSchedule) IsUnspecified() bool {
@@ -27,6 +43,10 @@ func (l *LoanSchedule) IsDue() bool {
 	return l.PaymentStatus == PaymentStatusDue
 }
 
+func (l *LoanSchedule) IsPartiallyPaid() bool {
+	return l.PaymentStatus == PaymentStatusPartiallyPaid
+}
+
 func (l *LoanSchedule) IsPaid() bool {
 	return l.PaymentStatus == PaymentStatusPaid
 }
@@ -34,3 +54,172 @@ func (l *LoanSchedule) IsPaid() bool {
 func (l *LoanSchedule) IsOverdue() bool {
 	return l.PaymentStatus == PaymentStatusOverdue
 }
+
+// IsBillable reports whether the schedule can currently take a payment: it is
+// due, overdue or already partially paid. Schedules that are not yet due are
+// covered from the loan's credit balance once they become due instead.
+func (l *LoanSchedule) IsBillable() bool {
+	return l.IsDue() || l.IsOverdue() || l.IsPartiallyPaid()
+}
+
+// AmountPaid is what has been paid towards the schedule to date.
+func (l *LoanSchedule) AmountPaid() money.Money {
+	return money.Sum(l.PrincipalPaid, l.InterestPaid, l.FeePaid)
+}
+
+// Outstanding is what is still owed on the schedule.
+func (l *LoanSchedule) Outstanding() money.Money {
+	return l.TotalDue.Sub(l.AmountPaid())
+}
+
+// Unreserved is what is still owed on the schedule once pending payments
+// clear, and so what a new payment can go towards.
+func (l *LoanSchedule) Unreserved() money.Money {
+	return money.Max(l.Outstanding().Sub(l.ReservedAmount), money.Money{})
+}
+
+// Reserve sets aside up to amount of a pending payment for the schedule and
+// returns how it is expected to be allocated once the payment clears, assuming
+// earlier reservations clear first, along with whatever is left of amount.
+// Nothing is marked as paid.
+func (l *LoanSchedule) Reserve(amount money.Money, order []AllocationComponent) (PaymentAllocation, money.Money) {
+	reserved := money.Min(amount, l.Unreserved())
+
+	projected := *l
+	projected.Allocate(l.ReservedAmount, order)
+	allocation, _ := projected.Allocate(reserved, order)
+
+	l.ReservedAmount = l.ReservedAmount.Add(reserved)
+	return allocation, amount.Sub(reserved)
+}
+
+// Release gives up the reservation made for allocation, whether because its
+// payment cleared or because it failed.
+func (l *LoanSchedule) Release(allocation PaymentAllocation) {
+	l.ReservedAmount = money.Max(l.ReservedAmount.Sub(allocation.Total()), money.Money{})
+}
+
+// Allocate applies up to amount to the schedule's unpaid components in the
+// given order and returns the allocation along with whatever is left of
+// amount. The schedule becomes paid once nothing is outstanding; an overdue
+// schedule stays overdue until then and any other becomes partially paid.
+func (l *LoanSchedule) Allocate(amount money.Money, order []AllocationComponent) (PaymentAllocation, money.Money) {
+	allocation := PaymentAllocation{ScheduleID: l.ScheduleID}
+
+	for _, component := range completeAllocationOrder(order) {
+		var due, paid, allocated *money.Money
+		switch component {
+		case AllocationComponentFees:
+			due, paid, allocated = &l.FeeAmount, &l.FeePaid, &allocation.FeeAmount
+		case AllocationComponentInterest:
+			due, paid, allocated = &l.InterestAmount, &l.InterestPaid, &allocation.InterestAmount
+		case AllocationComponentPrincipal:
+			due, paid, allocated = &l.PrincipalAmount, &l.PrincipalPaid, &allocation.PrincipalAmount
+		}
+
+		applied := money.Min(amount, due.Sub(*paid))
+		if !applied.IsPositive() {
+			continue
+		}
+		*paid = paid.Add(applied)
+		*allocated = allocated.Add(applied)
+		amount = amount.Sub(applied)
+	}
+
+	switch {
+	case !l.Outstanding().IsPositive():
+		l.PaymentStatus = PaymentStatusPaid
+	case !l.IsOverdue() && l.AmountPaid().IsPositive():
+		l.PaymentStatus = PaymentStatusPartiallyPaid
+	}
+
+	return allocation, amount
+}
+
+// Unallocate takes back an allocation previously made by Allocate, along with
+// any interest rebate it came with. A paid or partially paid schedule is reset
+// so that StatusAt derives its status again.
+func (l *LoanSchedule) Unallocate(allocation PaymentAllocation) {
+	l.FeePaid = l.FeePaid.Sub(allocation.FeeAmount)
+	l.InterestPaid = l.InterestPaid.Sub(allocation.InterestAmount)
+	l.PrincipalPaid = l.PrincipalPaid.Sub(allocation.PrincipalAmount)
+	l.RestoreInterest(allocation.InterestRebate)
+
+	if l.IsPaid() || l.IsPartiallyPaid() {
+		l.PaymentStatus = PaymentStatusUnspecified
+	}
+}
+
+// Charge adds a fee assessed on the schedule, such as a late payment penalty,
+// to what is due on it.
+func (l *LoanSchedule) Charge(amount money.Money) {
+	l.FeeAmount = l.FeeAmount.Add(amount)
+	l.TotalDue = l.TotalDue.Add(amount)
+}
+
+// Waive takes back part of the fees charged on the schedule. The schedule
+// becomes paid if nothing is outstanding once the waiver is applied.
+func (l *LoanSchedule) Waive(amount money.Money) {
+	l.FeeAmount = l.FeeAmount.Sub(amount)
+	l.TotalDue = l.TotalDue.Sub(amount)
+	if !l.Outstanding().IsPositive() {
+		l.PaymentStatus = PaymentStatusPaid
+	}
+}
+
+// RebateInterest takes unearned interest off the schedule when the loan is
+// paid off early.
+func (l *LoanSchedule) RebateInterest(amount money.Money) {
+	l.InterestAmount = l.InterestAmount.Sub(amount)
+	l.TotalDue = l.TotalDue.Sub(amount)
+}
+
+// RestoreInterest puts back interest rebated by a payoff that did not go
+// through.
+func (l *LoanSchedule) RestoreInterest(amount money.Money) {
+	l.InterestAmount = l.InterestAmount.Add(amount)
+	l.TotalDue = l.TotalDue.Add(amount)
+}
+
+// UnpaidFees is what is still owed on the fees charged on the schedule.
+func (l *LoanSchedule) UnpaidFees() money.Money {
+	return l.FeeAmount.Sub(l.FeePaid)
+}
+
+// DaysPastDue is how many days an overdue schedule is past its due date on the
+// day of now. It is zero for a schedule that is not overdue.
+func (l *LoanSchedule) DaysPastDue(now time.Time) int {
+	if !l.IsOverdue() {
+		return 0
+	}
+	days := int(startOfDay(now).Sub(startOfDay(l.DueDate)).Hours() / 24)
+	return max(days, 0)
+}
+
+// StatusAt derives the status the schedule should have on the day of now.
+// An installment is due during its billing period, which runs from the day
+// after periodStart (the previous installment's due date, or the loan start
+// date for the first one) up to and including its own due date, and overdue
+// once that period has passed. A schedule that has been paid in part is
+// partially paid until it becomes overdue. Paid schedules stay paid.
+func (l *LoanSchedule) StatusAt(periodStart, now time.Time) string {
+	if l.IsPaid() {
+		return PaymentStatusPaid
+	}
+
+	today := startOfDay(now)
+	switch {
+	case today.After(startOfDay(l.DueDate)):
+		return PaymentStatusOverdue
+	case l.AmountPaid().IsPositive():
+		return PaymentStatusPartiallyPaid
+	case today.After(startOfDay(periodStart)):
+		return PaymentStatusDue
+	default:
+		return PaymentStatusUnspecified
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,186 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+func TestLoanSchedule_Allocate(t *testing.T) {
+	newSchedule := func() LoanSchedule {
+		return LoanSchedule{
+			ScheduleID:      1,
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			FeeAmount:       money.New(5000),
+			TotalDue:        money.New(115000),
+			PaymentStatus:   PaymentStatusDue,
+		}
+	}
+
+	tests := []struct {
+		name           string
+		schedule       func() LoanSchedule
+		amount         money.Money
+		order          []AllocationComponent
+		wantAllocation PaymentAllocation
+		wantRemaining  money.Money
+		wantStatus     string
+	}{
+		{
+			name:     "should pay fees, then interest, then principal by default",
+			schedule: newSchedule,
+			amount:   money.New(20000),
+			wantAllocation: PaymentAllocation{
+				ScheduleID:      1,
+				FeeAmount:       money.New(5000),
+				InterestAmount:  money.New(10000),
+				PrincipalAmount: money.New(5000),
+			},
+			wantStatus: PaymentStatusPartiallyPaid,
+		},
+		{
+			name:     "should follow a custom order and append missing components",
+			schedule: newSchedule,
+			amount:   money.New(103000),
+			order:    []AllocationComponent{AllocationComponentPrincipal, "unknown"},
+			wantAllocation: PaymentAllocation{
+				ScheduleID:      1,
+				PrincipalAmount: money.New(100000),
+				FeeAmount:       money.New(3000),
+			},
+			wantStatus: PaymentStatusPartiallyPaid,
+		},
+		{
+			name:     "should return what is left after paying the schedule in full",
+			schedule: newSchedule,
+			amount:   money.New(120000),
+			wantAllocation: PaymentAllocation{
+				ScheduleID:      1,
+				FeeAmount:       money.New(5000),
+				InterestAmount:  money.New(10000),
+				PrincipalAmount: money.New(100000),
+			},
+			wantRemaining: money.New(5000),
+			wantStatus:    PaymentStatusPaid,
+		},
+		{
+			name: "should keep an overdue schedule overdue until fully paid",
+			schedule: func() LoanSchedule {
+				schedule := newSchedule()
+				schedule.PaymentStatus = PaymentStatusOverdue
+				schedule.FeePaid = money.New(5000)
+				return schedule
+			},
+			amount: money.New(10000),
+			wantAllocation: PaymentAllocation{
+				ScheduleID:     1,
+				InterestAmount: money.New(10000),
+			},
+			wantStatus: PaymentStatusOverdue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := tt.schedule()
+			before := schedule.AmountPaid()
+
+			allocation, remaining := schedule.Allocate(tt.amount, tt.order)
+			if !reflect.DeepEqual(allocation, tt.wantAllocation) {
+				t.Errorf("Allocate() allocation = %+v, want %+v", allocation, tt.wantAllocation)
+			}
+			if remaining != tt.wantRemaining {
+				t.Errorf("Allocate() remaining = %v, want %v", remaining, tt.wantRemaining)
+			}
+			if schedule.PaymentStatus != tt.wantStatus {
+				t.Errorf("Allocate() status = %v, want %v", schedule.PaymentStatus, tt.wantStatus)
+			}
+			if got := schedule.AmountPaid().Sub(before); got != allocation.Total() {
+				t.Errorf("Allocate() paid to date grew by %v, want %v", got, allocation.Total())
+			}
+		})
+	}
+}
+
+func TestLoanSchedule_Unallocate(t *testing.T) {
+	periodStart := time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC)
+	schedule := LoanSchedule{
+		ScheduleID:      1,
+		DueDate:         time.Date(2024, time.October, 21, 0, 0, 0, 0, time.UTC),
+		PrincipalAmount: money.New(100000),
+		InterestAmount:  money.New(10000),
+		FeeAmount:       money.New(5000),
+		TotalDue:        money.New(115000),
+		PaymentStatus:   PaymentStatusDue,
+	}
+	first, _ := schedule.Allocate(money.New(20000), nil)
+	second, _ := schedule.Allocate(money.New(95000), nil)
+
+	schedule.Unallocate(second)
+	if schedule.AmountPaid() != money.New(20000) {
+		t.Errorf("Unallocate() paid to date = %v, want %v", schedule.AmountPaid(), money.New(20000))
+	}
+	if got := schedule.StatusAt(periodStart, time.Date(2024, time.October, 18, 0, 0, 0, 0, time.UTC)); got != PaymentStatusPartiallyPaid {
+		t.Errorf("StatusAt() after Unallocate() = %v, want %v", got, PaymentStatusPartiallyPaid)
+	}
+
+	schedule.Unallocate(first)
+	if !schedule.AmountPaid().IsZero() {
+		t.Errorf("Unallocate() paid to date = %v, want 0", schedule.AmountPaid())
+	}
+	if got := schedule.StatusAt(periodStart, time.Date(2024, time.October, 25, 0, 0, 0, 0, time.UTC)); got != PaymentStatusOverdue {
+		t.Errorf("StatusAt() after Unallocate() = %v, want %v", got, PaymentStatusOverdue)
+	}
+}
+
+func TestLoanSchedule_StatusAt(t *testing.T) {
+	periodStart := time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC)
+	dueDate := time.Date(2024, time.October, 21, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule LoanSchedule
+		now      time.Time
+		want     string
+	}{
+		{
+			name:     "should be unspecified before the billing period",
+			schedule: LoanSchedule{DueDate: dueDate},
+			now:      time.Date(2024, time.October, 14, 23, 0, 0, 0, time.UTC),
+			want:     PaymentStatusUnspecified,
+		},
+		{
+			name:     "should be due within the billing period",
+			schedule: LoanSchedule{DueDate: dueDate},
+			now:      time.Date(2024, time.October, 21, 23, 0, 0, 0, time.UTC),
+			want:     PaymentStatusDue,
+		},
+		{
+			name:     "should be overdue after the due date",
+			schedule: LoanSchedule{DueDate: dueDate, PaymentStatus: PaymentStatusDue},
+			now:      time.Date(2024, time.October, 22, 0, 0, 0, 0, time.UTC),
+			want:     PaymentStatusOverdue,
+		},
+		{
+			name:     "should be partially paid while paid in part and not overdue",
+			schedule: LoanSchedule{DueDate: dueDate, InterestPaid: money.New(1)},
+			now:      time.Date(2024, time.October, 10, 0, 0, 0, 0, time.UTC),
+			want:     PaymentStatusPartiallyPaid,
+		},
+		{
+			name:     "should stay paid",
+			schedule: LoanSchedule{DueDate: dueDate, PaymentStatus: PaymentStatusPaid},
+			now:      time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC),
+			want:     PaymentStatusPaid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.StatusAt(periodStart, tt.now); got != tt.want {
+				t.Errorf("StatusAt() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,14 +1,71 @@
 package entity
 
-import "time"
+import (
+	"time"
 
-const Status = "completed"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// A payment is pending until its funds clear, when it is either completed or
+// failed. A completed payment can later be reversed.
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusReversed  = "reversed"
+)
+
+// PaymentMethodCreditBalance marks payments made out of a loan's credit
+// balance rather than with new funds. It is internal to the engine and not a
+// method borrowers can pay with.
+const PaymentMethodCreditBalance = "credit_balance"
 
 type Payment struct {
-	PaymentID     int       `db:"payment_id"`
-	LoanID        int       `db:"loan_id"`
-	PaymentDate   time.Time `db:"payment_date"`
-	AmountPaid    float64   `db:"amount_paid"`
-	PaymentMethod string    `db:"payment_method"`
-	Status        string    `db:"status"`
+	PaymentID     int         `db:"payment_id"`
+	LoanID        int         `db:"loan_id"`
+	PaymentDate   time.Time   `db:"payment_date"`
+	AmountPaid    money.Money `db:"amount_paid"`
+	PaymentMethod string      `db:"payment_method"`
+	// FeeAmount is what the payment method's provider charged for the
+	// payment. It is borne by the lender, not deducted from AmountPaid.
+	FeeAmount money.Money `db:"fee_amount"`
+	Status    string      `db:"status"`
+	// ExpectedSettlementDate is when the payment method is expected to
+	// clear the funds of a pending payment.
+	ExpectedSettlementDate time.Time `db:"expected_settlement_date"`
+	// FailureReason explains why a failed payment did not clear.
+	FailureReason string `db:"failure_reason"`
+	// IdempotencyKey identifies the request that made the payment, e.g. the
+	// bank reference of a transfer, so that a retried request is not paid
+	// twice. It is empty for payments made out of the credit balance and for
+	// those made without one.
+	IdempotencyKey string              `db:"idempotency_key"`
+	Allocations    []PaymentAllocation `db:"-"`
+}
+
+func (p *Payment) IsPending() bool {
+	return p.Status == StatusPending
+}
+
+func (p *Payment) IsCompleted() bool {
+	return p.Status == StatusCompleted
+}
+
+func (p *Payment) IsFailed() bool {
+	return p.Status == StatusFailed
+}
+
+func (p *Payment) IsReversed() bool {
+	return p.Status == StatusReversed
+}
+
+// AllocatedAmount is the part of the payment that went, or for a pending
+// payment is reserved, to schedules. The rest of AmountPaid goes to the
+// loan's credit balance.
+func (p *Payment) AllocatedAmount() money.Money {
+	var allocated money.Money
+	for _, allocation := range p.Allocations {
+		allocated = allocated.Add(allocation.Total())
+	}
+	return allocated
 }
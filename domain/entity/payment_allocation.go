@@ -0,0 +1,55 @@
+package entity
+
+import "github.com/iqbalbachmid/billing-engine/domain/money"
+
+// AllocationComponent is a part of a schedule a payment can be applied to.
+type AllocationComponent string
+
+const (
+	AllocationComponentFees      AllocationComponent = "fees"
+	AllocationComponentInterest  AllocationComponent = "interest"
+	AllocationComponentPrincipal AllocationComponent = "principal"
+)
+
+// DefaultAllocationOrder is the waterfall used unless configured otherwise:
+// fees first, then interest, then principal.
+var DefaultAllocationOrder = []AllocationComponent{
+	AllocationComponentFees,
+	AllocationComponentInterest,
+	AllocationComponentPrincipal,
+}
+
+// PaymentAllocation records how much of a payment went to each component of
+// one schedule.
+type PaymentAllocation struct {
+	AllocationID    int         `db:"allocation_id"`
+	PaymentID       int         `db:"payment_id"`
+	ScheduleID      int         `db:"schedule_id"`
+	PrincipalAmount money.Money `db:"principal_amount"`
+	InterestAmount  money.Money `db:"interest_amount"`
+	FeeAmount       money.Money `db:"fee_amount"`
+	// InterestRebate is unearned interest taken off the schedule because the
+	// payment paid the loan off early. It is not part of Total.
+	InterestRebate money.Money `db:"interest_rebate"`
+}
+
+func (a *PaymentAllocation) Total() money.Money {
+	return money.Sum(a.PrincipalAmount, a.InterestAmount, a.FeeAmount)
+}
+
+// completeAllocationOrder drops unknown and repeated components from order
+// and appends the ones it leaves out in their default position.
+func completeAllocationOrder(order []AllocationComponent) []AllocationComponent {
+	seen := make(map[AllocationComponent]bool, len(DefaultAllocationOrder))
+	complete := make([]AllocationComponent, 0, len(DefaultAllocationOrder))
+	for _, component := range append(order[:len(order):len(order)], DefaultAllocationOrder...) {
+		switch component {
+		case AllocationComponentFees, AllocationComponentInterest, AllocationComponentPrincipal:
+			if !seen[component] {
+				seen[component] = true
+				complete = append(complete, component)
+			}
+		}
+	}
+	return complete
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// PaymentHoliday records installments falling due from StartDate on being
+// deferred by Installments periods of the loan's frequency, with who approved
+// it and why. ExtraInterest is what the deferral added, if the loan's product
+// charges for it.
+type PaymentHoliday struct {
+	HolidayID     int         `db:"holiday_id"`
+	LoanID        int         `db:"loan_id"`
+	StartDate     time.Time   `db:"start_date"`
+	Installments  int         `db:"installments"`
+	ExtraInterest money.Money `db:"extra_interest"`
+	ApprovedBy    string      `db:"approved_by"`
+	Reason        string      `db:"reason"`
+	GrantedDate   time.Time   `db:"granted_date"`
+}
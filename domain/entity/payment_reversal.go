@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// PaymentReversal records that a payment was undone, e.g. because the bank
+// transfer bounced or was charged back.
+type PaymentReversal struct {
+	ReversalID   int       `db:"reversal_id"`
+	PaymentID    int       `db:"payment_id"`
+	ReversalDate time.Time `db:"reversal_date"`
+	Reason       string    `db:"reason"`
+}
@@ -0,0 +1,44 @@
+package entity
+
+const (
+	weeksPerYear  = 52
+	monthsPerYear = 12
+)
+
+const (
+	FrequencyDaily       = "daily"
+	FrequencyWeekly      = "weekly"
+	FrequencyBiWeekly    = "bi_weekly"
+	FrequencySemiMonthly = "semi_monthly"
+	FrequencyMonthly     = "monthly"
+)
+
+// Repayment decides when a loan's installments fall due. A loan without a
+// frequency is repaid weekly.
+type Repayment struct {
+	Frequency string `db:"repayment_frequency"`
+	// AnchorDay pins the due dates to a fixed day: the ISO weekday (1 for
+	// Monday to 7 for Sunday) of weekly and bi-weekly loans, the day of month
+	// (1 to 31, clamped to the end of shorter months) of monthly loans, and
+	// the first of the two days of month (1 to 15, the second being 15 days
+	// later) of semi-monthly loans. Zero anchors on the loan start date.
+	AnchorDay int `db:"anchor_day"`
+}
+
+// InstallmentsPerYear is how many installments fall due in a year at the
+// frequency. Daily loans are counted as repaid on business days only, five a
+// week, and a loan without a known frequency as repaid weekly.
+func (r Repayment) InstallmentsPerYear() int {
+	switch r.Frequency {
+	case FrequencyDaily:
+		return 5 * weeksPerYear
+	case FrequencyBiWeekly:
+		return weeksPerYear / 2
+	case FrequencySemiMonthly:
+		return 2 * monthsPerYear
+	case FrequencyMonthly:
+		return monthsPerYear
+	default:
+		return weeksPerYear
+	}
+}
@@ -0,0 +1,10 @@
+package event
+
+type Event interface {
+	Name() string
+}
+
+//go:generate mockery --name=Publisher --output=../../mocks/domain/event --with-expecter=true
+type Publisher interface {
+	Publish(event Event) error
+}
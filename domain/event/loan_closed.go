@@ -0,0 +1,14 @@
+package event
+
+import "time"
+
+// LoanClosed is emitted once a loan's last outstanding installment is paid.
+type LoanClosed struct {
+	LoanID     int
+	BorrowerID int
+	ClosedDate time.Time
+}
+
+func (LoanClosed) Name() string {
+	return "loan.closed"
+}
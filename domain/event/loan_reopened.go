@@ -0,0 +1,15 @@
+package event
+
+import "time"
+
+// LoanReopened is emitted when a paid loan becomes active again because a
+// payment that settled it was reversed.
+type LoanReopened struct {
+	LoanID       int
+	BorrowerID   int
+	ReopenedDate time.Time
+}
+
+func (LoanReopened) Name() string {
+	return "loan.reopened"
+}
@@ -0,0 +1,208 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of minor units in one major unit. It matches the
+// DECIMAL(15, 2) columns amounts are stored in.
+const Scale = 100
+
+var ErrInvalidAmount = errors.New("invalid money amount")
+
+// Money is an exact amount held as an integer number of minor units. The zero
+// value is zero. Use New or Parse rather than converting floats.
+type Money struct {
+	minor int64
+}
+
+// New returns an amount of whole major units.
+func New(major int64) Money {
+	return Money{minor: major * Scale}
+}
+
+// FromMinor returns an amount of minor units.
+func FromMinor(minor int64) Money {
+	return Money{minor: minor}
+}
+
+// Parse reads a decimal string such as "110000" or "-1234.5". More than two
+// fractional digits is an error rather than being rounded silently.
+func Parse(s string) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || strings.ContainsAny(s, "/eE") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	scaled := new(big.Rat).Mul(r, big.NewRat(Scale, 1))
+	if !scaled.IsInt() || !scaled.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	return Money{minor: scaled.Num().Int64()}, nil
+}
+
+// MustParse is like Parse but panics on error. It is meant for constants.
+func MustParse(s string) Money {
+	m, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Minor returns the amount in minor units.
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{minor: m.minor + other.minor}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{minor: m.minor - other.minor}
+}
+
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor}
+}
+
+// Mul multiplies by an integer factor, which is always exact.
+func (m Money) Mul(factor int64) Money {
+	return Money{minor: m.minor * factor}
+}
+
+// Div divides by an integer divisor, rounding to a minor unit with mode.
+func (m Money) Div(divisor int64, mode RoundingMode) Money {
+	return m.MulRat(big.NewRat(1, divisor), mode)
+}
+
+// Percent returns rate percent of the amount, rounded to a minor unit with
+// mode. The rate is taken at its shortest decimal representation, so 12.5
+// means exactly 12.5 and not the nearest binary fraction.
+func (m Money) Percent(rate float64, mode RoundingMode) Money {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	return m.MulRat(r.Quo(r, big.NewRat(100, 1)), mode)
+}
+
+// MulRat multiplies by an exact rational factor, rounding to a minor unit
+// with mode.
+func (m Money) MulRat(factor *big.Rat, mode RoundingMode) Money {
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(m.minor), factor)
+	return Money{minor: mode.round(product)}
+}
+
+// Rat returns the amount in major units as an exact rational.
+func (m Money) Rat() *big.Rat {
+	return big.NewRat(m.minor, Scale)
+}
+
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// Cmp returns -1, 0 or +1 depending on whether m is less than, equal to or
+// greater than other.
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m.minor < other.minor:
+		return -1
+	case m.minor > other.minor:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (m Money) LessThan(other Money) bool {
+	return m.minor < other.minor
+}
+
+func (m Money) GreaterThan(other Money) bool {
+	return m.minor > other.minor
+}
+
+// Min returns the smaller of a and b.
+func Min(a, b Money) Money {
+	if a.LessThan(b) {
+		return a
+	}
+	return b
+}
+
+// Max returns the larger of a and b.
+func Max(a, b Money) Money {
+	if a.GreaterThan(b) {
+		return a
+	}
+	return b
+}
+
+// Sum adds up amounts.
+func Sum(amounts ...Money) Money {
+	var total Money
+	for _, amount := range amounts {
+		total = total.Add(amount)
+	}
+	return total
+}
+
+// String formats the amount with two fractional digits, e.g. "110000.00".
+func (m Money) String() string {
+	sign := ""
+	minor := m.minor
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/Scale, minor%Scale)
+}
+
+// Value stores the amount as a decimal string so it round-trips through
+// DECIMAL columns without going through a float.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads a DECIMAL column. SQLite hands numeric columns back as integers
+// or floats, which are converted through their shortest decimal form.
+func (m *Money) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case int64:
+		*m = New(v)
+		return nil
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	*m = Money{minor: RoundHalfEven.round(r.Mul(r, big.NewRat(Scale, 1)))}
+	return nil
+}
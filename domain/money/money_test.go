@@ -0,0 +1,128 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "110000", want: New(110000)},
+		{in: "1234.5", want: FromMinor(123450)},
+		{in: "-0.01", want: FromMinor(-1)},
+		{in: "0.001", wantErr: true},
+		{in: "1/3", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("Parse() error = %v, want %v", err, ErrInvalidAmount)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{in: New(110000), want: "110000.00"},
+		{in: FromMinor(5), want: "0.05"},
+		{in: FromMinor(-12345), want: "-123.45"},
+		{in: Money{}, want: "0.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("String() got = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestMoney_Div(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  Money
+		divisor int64
+		mode    RoundingMode
+		want    Money
+	}{
+		{name: "exact", amount: New(5000000), divisor: 50, mode: RoundHalfUp, want: New(100000)},
+		{name: "half up rounds tie away from zero", amount: FromMinor(5), divisor: 2, mode: RoundHalfUp, want: FromMinor(3)},
+		{name: "half up negative", amount: FromMinor(-5), divisor: 2, mode: RoundHalfUp, want: FromMinor(-3)},
+		{name: "half even rounds tie to even", amount: FromMinor(5), divisor: 2, mode: RoundHalfEven, want: FromMinor(2)},
+		{name: "half even rounds odd tie up", amount: FromMinor(7), divisor: 2, mode: RoundHalfEven, want: FromMinor(4)},
+		{name: "down truncates", amount: New(1000000), divisor: 3, mode: RoundDown, want: FromMinor(33333333)},
+		{name: "up rounds away", amount: New(1000000), divisor: 3, mode: RoundUp, want: FromMinor(33333334)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.Div(tt.divisor, tt.mode); got != tt.want {
+				t.Errorf("Div() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_Percent(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Money
+		rate   float64
+		want   Money
+	}{
+		{name: "flat interest", amount: New(5000000), rate: 10, want: New(500000)},
+		{name: "fractional rate", amount: New(1000), rate: 12.5, want: New(125)},
+		{name: "rate that is not a binary fraction", amount: MustParse("0.30"), rate: 10, want: MustParse("0.03")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.Percent(tt.rate, RoundHalfUp); got != tt.want {
+				t.Errorf("Percent() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_Scan(t *testing.T) {
+	tests := []struct {
+		name string
+		src  any
+		want Money
+	}{
+		{name: "integer", src: int64(110000), want: New(110000)},
+		{name: "float", src: 1234.56, want: FromMinor(123456)},
+		{name: "text", src: "0.10", want: FromMinor(10)},
+		{name: "bytes", src: []byte("99.99"), want: FromMinor(9999)},
+		{name: "null", src: nil, want: Money{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(1)
+			if err := got.Scan(tt.src); err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var m Money
+	if err := m.Scan(true); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Scan() error = %v, want %v", err, ErrInvalidAmount)
+	}
+}
@@ -0,0 +1,65 @@
+package money
+
+import "math/big"
+
+// RoundingMode decides how an amount that falls between two minor units is
+// rounded.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds to the nearest minor unit, ties away from zero.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds to the nearest minor unit, ties to the even one.
+	RoundHalfEven
+	// RoundDown truncates towards zero.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+)
+
+func (mode RoundingMode) String() string {
+	switch mode {
+	case RoundHalfUp:
+		return "half_up"
+	case RoundHalfEven:
+		return "half_even"
+	case RoundDown:
+		return "down"
+	case RoundUp:
+		return "up"
+	default:
+		return "unknown"
+	}
+}
+
+// round rounds r to an integer.
+func (mode RoundingMode) round(r *big.Rat) int64 {
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	if rem.Sign() != 0 {
+		// twice the remainder against the denominator tells below, at or
+		// above the halfway point
+		half := new(big.Int).Lsh(rem, 1).Cmp(den)
+		roundAway := false
+		switch mode {
+		case RoundHalfUp:
+			roundAway = half >= 0
+		case RoundHalfEven:
+			roundAway = half > 0 || (half == 0 && quo.Bit(0) == 1)
+		case RoundUp:
+			roundAway = true
+		case RoundDown:
+			roundAway = false
+		}
+		if roundAway {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	if r.Sign() < 0 {
+		quo.Neg(quo)
+	}
+	return quo.Int64()
+}
@@ -0,0 +1,71 @@
+package paymentmethod
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+const (
+	BankTransfer   = "bank_transfer"
+	VirtualAccount = "virtual_account"
+	EWallet        = "e_wallet"
+	Card           = "card"
+	DirectDebit    = "direct_debit"
+	CashAtAgent    = "cash_at_agent"
+)
+
+// Method is a channel a borrower can pay through.
+type Method interface {
+	// Code identifies the method and is what payments store.
+	Code() string
+	// Validate rejects amounts the method cannot carry.
+	Validate(amount money.Money) error
+	// SettlementDelay is how long after a payment is made its funds clear.
+	SettlementDelay() time.Duration
+	// Fee is what the provider charges the lender for carrying amount. It
+	// does not reduce the amount applied to the loan.
+	Fee(amount money.Money) money.Money
+}
+
+// Standard is a Method with amount limits, a fixed settlement delay and a
+// flat plus percentage fee. A zero MaxAmount or MaxFee means no limit.
+type Standard struct {
+	MethodCode string
+	MinAmount  money.Money
+	MaxAmount  money.Money
+	Delay      time.Duration
+	FlatFee    money.Money
+	// FeeRate is a percentage of the amount, charged on top of FlatFee.
+	FeeRate float64
+	MaxFee  money.Money
+}
+
+var _ Method = Standard{}
+
+func (m Standard) Code() string {
+	return m.MethodCode
+}
+
+func (m Standard) Validate(amount money.Money) error {
+	if amount.LessThan(m.MinAmount) {
+		return fmt.Errorf("%s payments must be at least %s", m.MethodCode, m.MinAmount)
+	}
+	if m.MaxAmount.IsPositive() && amount.GreaterThan(m.MaxAmount) {
+		return fmt.Errorf("%s payments must be at most %s", m.MethodCode, m.MaxAmount)
+	}
+	return nil
+}
+
+func (m Standard) SettlementDelay() time.Duration {
+	return m.Delay
+}
+
+func (m Standard) Fee(amount money.Money) money.Money {
+	fee := m.FlatFee.Add(amount.Percent(m.FeeRate, money.RoundHalfUp))
+	if m.MaxFee.IsPositive() {
+		fee = money.Min(fee, m.MaxFee)
+	}
+	return fee
+}
@@ -0,0 +1,96 @@
+package paymentmethod
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+func TestStandard_Validate(t *testing.T) {
+	method := Standard{
+		MethodCode: EWallet,
+		MinAmount:  money.New(1000),
+		MaxAmount:  money.New(20000000),
+	}
+
+	tests := []struct {
+		amount  money.Money
+		wantErr bool
+	}{
+		{amount: money.New(999), wantErr: true},
+		{amount: money.New(1000)},
+		{amount: money.New(20000000)},
+		{amount: money.MustParse("20000000.01"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.amount.String(), func(t *testing.T) {
+			if err := method.Validate(tt.amount); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStandard_Fee(t *testing.T) {
+	tests := []struct {
+		name   string
+		method Standard
+		amount money.Money
+		want   money.Money
+	}{
+		{
+			name:   "flat",
+			method: Standard{FlatFee: money.New(2500)},
+			amount: money.New(110000),
+			want:   money.New(2500),
+		},
+		{
+			name:   "flat plus percentage",
+			method: Standard{FlatFee: money.New(2000), FeeRate: 2.9},
+			amount: money.New(110000),
+			want:   money.New(5190),
+		},
+		{
+			name:   "percentage rounded half up",
+			method: Standard{FeeRate: 1.5},
+			amount: money.MustParse("100.30"),
+			want:   money.MustParse("1.50"),
+		},
+		{
+			name:   "capped",
+			method: Standard{FeeRate: 0.5, MaxFee: money.New(10000)},
+			amount: money.New(5000000),
+			want:   money.New(10000),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.method.Fee(tt.amount); got != tt.want {
+				t.Errorf("Fee() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := Default()
+
+	for _, code := range []string{BankTransfer, VirtualAccount, EWallet, Card, DirectDebit, CashAtAgent} {
+		method, err := registry.Get(code)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", code, err)
+		}
+		if method.Code() != code {
+			t.Errorf("Get(%q) got method %q", code, method.Code())
+		}
+	}
+
+	if _, err := registry.Get("credit_balance"); !errors.Is(err, ErrUnknownMethod) {
+		t.Errorf("Get() error = %v, want %v", err, ErrUnknownMethod)
+	}
+
+	if _, err := NewRegistry(Standard{MethodCode: Card}, Standard{MethodCode: Card}); err == nil {
+		t.Errorf("NewRegistry() with duplicate codes should return error")
+	}
+}
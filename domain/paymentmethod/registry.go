@@ -0,0 +1,98 @@
+package paymentmethod
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// ErrUnknownMethod is returned for a payment method that is not registered.
+var ErrUnknownMethod = errors.New("unknown payment method")
+
+// Registry holds the payment methods payments can be made with.
+type Registry struct {
+	methods map[string]Method
+	codes   []string
+}
+
+// NewRegistry registers the given methods. Registering two methods with the
+// same code is an error.
+func NewRegistry(methods ...Method) (*Registry, error) {
+	r := &Registry{methods: make(map[string]Method, len(methods))}
+	for _, method := range methods {
+		code := method.Code()
+		if code == "" {
+			return nil, errors.New("payment method code must not be empty")
+		}
+		if _, ok := r.methods[code]; ok {
+			return nil, fmt.Errorf("payment method %q is registered twice", code)
+		}
+		r.methods[code] = method
+		r.codes = append(r.codes, code)
+	}
+	return r, nil
+}
+
+// Get returns the method registered under code.
+func (r *Registry) Get(code string) (Method, error) {
+	method, ok := r.methods[code]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMethod, code)
+	}
+	return method, nil
+}
+
+// Codes returns the codes of the registered methods in registration order.
+func (r *Registry) Codes() []string {
+	return append([]string(nil), r.codes...)
+}
+
+// Default returns a registry of the methods the engine supports out of the
+// box.
+func Default() *Registry {
+	r, err := NewRegistry(
+		Standard{
+			MethodCode: BankTransfer,
+			MinAmount:  money.New(10000),
+			Delay:      24 * time.Hour,
+			FlatFee:    money.New(2500),
+		},
+		Standard{
+			MethodCode: VirtualAccount,
+			MinAmount:  money.New(10000),
+			FlatFee:    money.New(4000),
+		},
+		Standard{
+			MethodCode: EWallet,
+			MinAmount:  money.New(1000),
+			MaxAmount:  money.New(20000000),
+			FeeRate:    1.5,
+		},
+		Standard{
+			MethodCode: Card,
+			MinAmount:  money.New(10000),
+			Delay:      48 * time.Hour,
+			FlatFee:    money.New(2000),
+			FeeRate:    2.9,
+		},
+		Standard{
+			MethodCode: DirectDebit,
+			MinAmount:  money.New(10000),
+			Delay:      24 * time.Hour,
+			FeeRate:    0.5,
+			MaxFee:     money.New(10000),
+		},
+		Standard{
+			MethodCode: CashAtAgent,
+			MinAmount:  money.New(10000),
+			MaxAmount:  money.New(5000000),
+			FlatFee:    money.New(5000),
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
@@ -0,0 +1,64 @@
+package penalty
+
+import (
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// Rule decides the late payment penalty on an overdue installment.
+type Rule interface {
+	// Assess returns the penalty still to be charged on an installment that
+	// is daysPastDue days past its due date, given what was already charged
+	// on it. Waived penalties count as charged, so they are not assessed
+	// again.
+	Assess(schedule entity.LoanSchedule, daysPastDue int, charged money.Money) money.Money
+}
+
+// Flat charges a fixed amount once an installment becomes overdue.
+type Flat struct {
+	Amount money.Money
+}
+
+func (r Flat) Assess(_ entity.LoanSchedule, daysPastDue int, charged money.Money) money.Money {
+	if daysPastDue <= 0 || !charged.IsZero() {
+		return money.Money{}
+	}
+	return r.Amount
+}
+
+// PercentOfInstallment charges a percentage of the installment, principal and
+// interest, once it becomes overdue.
+type PercentOfInstallment struct {
+	Percent float64
+}
+
+func (r PercentOfInstallment) Assess(schedule entity.LoanSchedule, daysPastDue int, charged money.Money) money.Money {
+	if daysPastDue <= 0 || !charged.IsZero() {
+		return money.Money{}
+	}
+	return installment(schedule).Percent(r.Percent, money.RoundHalfUp)
+}
+
+// DailyAccrual charges a flat amount plus a percentage of the installment for
+// every day it is past due, up to Cap in total. A zero Cap means no limit.
+type DailyAccrual struct {
+	Amount  money.Money
+	Percent float64
+	Cap     money.Money
+}
+
+func (r DailyAccrual) Assess(schedule entity.LoanSchedule, daysPastDue int, charged money.Money) money.Money {
+	if daysPastDue <= 0 {
+		return money.Money{}
+	}
+	daily := r.Amount.Add(installment(schedule).Percent(r.Percent, money.RoundHalfUp))
+	accrued := daily.Mul(int64(daysPastDue))
+	if r.Cap.IsPositive() {
+		accrued = money.Min(accrued, r.Cap)
+	}
+	return money.Max(accrued.Sub(charged), money.Money{})
+}
+
+func installment(schedule entity.LoanSchedule) money.Money {
+	return schedule.PrincipalAmount.Add(schedule.InterestAmount)
+}
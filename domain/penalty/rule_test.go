@@ -0,0 +1,43 @@
+package penalty
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+func TestRules_Assess(t *testing.T) {
+	schedule := entity.LoanSchedule{
+		PrincipalAmount: money.New(100000),
+		InterestAmount:  money.New(10000),
+		FeeAmount:       money.New(5000),
+		TotalDue:        money.New(115000),
+		PaymentStatus:   entity.PaymentStatusOverdue,
+	}
+
+	tests := []struct {
+		rule        Rule
+		daysPastDue int
+		charged     money.Money
+		want        money.Money
+	}{
+		{rule: Flat{Amount: money.New(25000)}, daysPastDue: 0},
+		{rule: Flat{Amount: money.New(25000)}, daysPastDue: 1, want: money.New(25000)},
+		{rule: Flat{Amount: money.New(25000)}, daysPastDue: 8, charged: money.New(25000)},
+		{rule: PercentOfInstallment{Percent: 5}, daysPastDue: 1, want: money.New(5500)},
+		{rule: PercentOfInstallment{Percent: 5}, daysPastDue: 3, charged: money.New(5500)},
+		{rule: DailyAccrual{Amount: money.New(1000)}, daysPastDue: 40, charged: money.New(39000), want: money.New(1000)},
+		{rule: DailyAccrual{Percent: 0.1, Cap: money.New(1000)}, daysPastDue: 3, want: money.New(330)},
+		{rule: DailyAccrual{Percent: 0.1, Cap: money.New(1000)}, daysPastDue: 10, charged: money.New(770), want: money.New(230)},
+		{rule: DailyAccrual{Percent: 0.1, Cap: money.New(1000)}, daysPastDue: 11, charged: money.New(1000)},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%T/%d days/%s charged", tt.rule, tt.daysPastDue, tt.charged), func(t *testing.T) {
+			if got := tt.rule.Assess(schedule, tt.daysPastDue, tt.charged); got != tt.want {
+				t.Errorf("Assess() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/iqbalbachmid/billing-engine/domain/delinquency"
+	"github.com/iqbalbachmid/billing-engine/domain/rounding"
+)
+
+// ErrUnknownProduct is returned for a product code that is not in the catalog.
+var ErrUnknownProduct = errors.New("unknown loan product")
+
+// Catalog holds the products loans can be originated under.
+type Catalog struct {
+	products map[string]Product
+	codes    []string
+}
+
+// NewCatalog registers the given products. Registering two products with the
+// same code, a product without a delinquency policy, one with a payoff rebate
+// outside 0-100%, one with an unknown business day or day count convention or
+// one whose rounding policy is invalid or whose currency has none is an
+// error. A product without a rounding policy is given its currency's.
+func NewCatalog(products ...Product) (*Catalog, error) {
+	c := &Catalog{products: make(map[string]Product, len(products))}
+	for _, product := range products {
+		if product.Code == "" {
+			return nil, errors.New("loan product code must not be empty")
+		}
+		if _, ok := c.products[product.Code]; ok {
+			return nil, fmt.Errorf("loan product %q is registered twice", product.Code)
+		}
+		if product.Delinquency == nil {
+			return nil, fmt.Errorf("loan product %q has no delinquency policy", product.Code)
+		}
+		if product.PayoffRebate < 0 || product.PayoffRebate > 100 {
+			return nil, fmt.Errorf("loan product %q has payoff rebate %v%% outside 0-100%%", product.Code, product.PayoffRebate)
+		}
+		if !product.BusinessDay.Valid() {
+			return nil, fmt.Errorf("loan product %q has unknown business day convention %q", product.Code, product.BusinessDay)
+		}
+		if !product.DayCount.Valid() {
+			return nil, fmt.Errorf("loan product %q has unknown day count convention %q", product.Code, product.DayCount)
+		}
+		if product.Rounding == nil && product.Currency != "" {
+			policy, err := rounding.ForCurrency(product.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("loan product %q: %w", product.Code, err)
+			}
+			product.Rounding = &policy
+		}
+		if product.Rounding != nil {
+			if err := product.Rounding.Validate(); err != nil {
+				return nil, fmt.Errorf("loan product %q: %w", product.Code, err)
+			}
+		}
+		c.products[product.Code] = product
+		c.codes = append(c.codes, product.Code)
+	}
+	return c, nil
+}
+
+// Get returns the product registered under code.
+func (c *Catalog) Get(code string) (Product, error) {
+	product, ok := c.products[code]
+	if !ok {
+		return Product{}, fmt.Errorf("%w: %q", ErrUnknownProduct, code)
+	}
+	return product, nil
+}
+
+// Codes returns the codes of the registered products in registration order.
+func (c *Catalog) Codes() []string {
+	return append([]string(nil), c.codes...)
+}
+
+// Default returns a catalog with the standard product, which lends rupiah,
+// flags a loan as delinquent once two weeks' installments are overdue and
+// waives all unearned interest on early payoff.
+func Default() *Catalog {
+	c, err := NewCatalog(
+		Product{
+			Code:         Standard,
+			Delinquency:  delinquency.TotalOverdue{Limit: 2},
+			PayoffRebate: 100,
+			Currency:     "IDR",
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
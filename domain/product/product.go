@@ -0,0 +1,44 @@
+package product
+
+import (
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/daycount"
+	"github.com/iqbalbachmid/billing-engine/domain/delinquency"
+	"github.com/iqbalbachmid/billing-engine/domain/penalty"
+	"github.com/iqbalbachmid/billing-engine/domain/rounding"
+)
+
+const Standard = "standard"
+
+// Product is a loan offering. Loans record the code of the product they were
+// originated under and are serviced by its rules.
+type Product struct {
+	Code string
+	// Delinquency decides when loans of the product are delinquent.
+	Delinquency delinquency.Policy
+	// Penalty assesses late payment penalties on overdue installments. Loans
+	// of a product without one are not penalised.
+	Penalty penalty.Rule
+	// BusinessDay rolls due dates that fall on a weekend or public holiday.
+	// Due dates of a product without one are left where they fall.
+	BusinessDay calendar.Convention
+	// PayoffRebate is the percentage of unearned interest waived when a loan
+	// of the product is paid off early.
+	PayoffRebate float64
+	// DeferralInterest charges interest on the principal of installments
+	// deferred by a payment holiday for the periods they are deferred. Loans
+	// of a product without it defer installments as they are.
+	DeferralInterest bool
+	// DayCount is the convention interest on loans of the product accrues
+	// by, which makes their rate annual. Loans record it when they are
+	// created. Loans of a product without one are charged their rate over
+	// their whole tenor.
+	DayCount daycount.Convention
+	// Currency is the ISO 4217 code of the currency loans of the product are
+	// lent in.
+	Currency string
+	// Rounding is how installments of loans of the product are rounded and
+	// where the residual goes. Without one, loans are rounded by the policy
+	// of the product's currency, or to a minor unit if it has none.
+	Rounding *rounding.Policy
+}
@@ -9,4 +9,9 @@ type BorrowerRepository interface {
 	Create(borrower entity.Borrower) (int, error)
 	Update(borrower entity.Borrower) error
 	Delete(id int) error
+	GetStatusChanges(borrowerID int) ([]entity.BorrowerStatusChange, error)
+	// UpdateAccountStatus atomically moves the borrower to the change's new
+	// status and records the change. It fails with ErrConcurrentModification
+	// if the borrower's status is no longer the one the change was made from.
+	UpdateAccountStatus(change entity.BorrowerStatusChange) (int, error)
 }
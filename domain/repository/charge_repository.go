@@ -0,0 +1,24 @@
+package repository
+
+import "github.com/iqbalbachmid/billing-engine/domain/entity"
+
+//go:generate mockery --name=ChargeRepository --output=../../mocks/domain/repository --with-expecter=true
+type ChargeRepository interface {
+	GetByID(chargeID int) (entity.Charge, error)
+	GetByLoanID(loanID int) ([]entity.Charge, error)
+	// CreateChargesAndUpdateLoanSchedules atomically records the charges and
+	// writes back the schedules they were added to. It fails with
+	// ErrConcurrentModification if a schedule changed since it was read.
+	CreateChargesAndUpdateLoanSchedules(charges []entity.Charge, loanSchedules []entity.LoanSchedule) ([]int, error)
+	GetAdjustmentsByLoanID(loanID int) ([]entity.ChargeAdjustment, error)
+	// CreateAdjustmentAndUpdateLoanSchedule atomically records the adjustment,
+	// writes back the adjusted charge, its schedule and the loan. It fails with
+	// ErrConcurrentModification if the charge, the schedule or the loan
+	// changed since they were read.
+	CreateAdjustmentAndUpdateLoanSchedule(
+		adjustment entity.ChargeAdjustment,
+		charge entity.Charge,
+		loanSchedule entity.LoanSchedule,
+		loan entity.Loan,
+	) (int, error)
+}
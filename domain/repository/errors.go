@@ -0,0 +1,13 @@
+package repository
+
+import "errors"
+
+var (
+	ErrNotFound = errors.New("record not found")
+	// ErrConcurrentModification is returned when a record changed between
+	// being read and being written back.
+	ErrConcurrentModification = errors.New("record was modified concurrently")
+	// ErrAlreadyExists is returned when a record with the same unique key
+	// already exists.
+	ErrAlreadyExists = errors.New("record already exists")
+)
@@ -1,12 +1,19 @@
 package repository
 
-import "github.com/iqbalbachmid/billing-engine/domain/entity"
+import (
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+)
 
 //go:generate mockery --name=LoanRepository --output=../../mocks/domain/repository --with-expecter=true
 type LoanRepository interface {
 	GetByID(id int) (entity.Loan, error)
 	GetAll() ([]entity.Loan, error)
+	GetByBorrowerID(borrowerID int) ([]entity.Loan, error)
 	Create(loan entity.Loan) (int, error)
 	Update(loan entity.Loan) error
 	Delete(id int) error
+	// GetClosedBetween returns loans closed on a day within [from, to].
+	GetClosedBetween(from, to time.Time) ([]entity.Loan, error)
 }
@@ -4,7 +4,34 @@ import "github.com/iqbalbachmid/billing-engine/domain/entity"
 
 //go:generate mockery --name=LoanScheduleRepository --output=../../mocks/domain/repository --with-expecter=true
 type LoanScheduleRepository interface {
+	// GetByLoanID returns the loan's current schedules, leaving out those a
+	// restructure superseded.
 	GetByLoanID(loanID int) ([]entity.LoanSchedule, error)
 	Create(schedule entity.LoanSchedule) (int, error)
 	Update(schedule entity.LoanSchedule) error
+	// CreateLoanSchedulesAndUpdateLoan is atomic: either all schedules are
+	// created and the loan is written back, or nothing is. It fails with
+	// ErrConcurrentModification if the loan changed since it was read.
+	CreateLoanSchedulesAndUpdateLoan(loanSchedules []entity.LoanSchedule, loan entity.Loan) error
+	// GetRestructuresByLoanID returns the loan's restructures, oldest first,
+	// each with the schedules it superseded.
+	GetRestructuresByLoanID(loanID int) ([]entity.LoanRestructure, error)
+	// CreateRestructure atomically records the restructure, marks the
+	// schedules it supersedes, creates the schedules replacing them, moves
+	// the charges onto the first of those, recording what of each was
+	// capitalized, and writes back the loan. It fails with
+	// ErrConcurrentModification if a superseded schedule or the loan changed
+	// since it was read.
+	CreateRestructure(
+		restructure entity.LoanRestructure,
+		loanSchedules []entity.LoanSchedule,
+		charges []entity.Charge,
+		loan entity.Loan,
+	) (int, error)
+	GetPaymentHolidaysByLoanID(loanID int) ([]entity.PaymentHoliday, error)
+	// CreatePaymentHoliday atomically records the holiday and writes back the
+	// schedules it deferred and the loan. It fails with
+	// ErrConcurrentModification if a schedule or the loan changed since it
+	// was read.
+	CreatePaymentHoliday(holiday entity.PaymentHoliday, loanSchedules []entity.LoanSchedule, loan entity.Loan) (int, error)
 }
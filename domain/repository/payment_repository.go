@@ -4,6 +4,29 @@ import "github.com/iqbalbachmid/billing-engine/domain/entity"
 
 //go:generate mockery --name=PaymentRepository --output=../../mocks/domain/repository --with-expecter=true
 type PaymentRepository interface {
+	GetByID(paymentID int) (entity.Payment, error)
+	GetByIdempotencyKey(idempotencyKey string) (entity.Payment, error)
 	GetByLoanID(loanID int) ([]entity.Payment, error)
-	CreatePaymentAndUpdateLoanSchedules(payment entity.Payment, loanSchedules []entity.LoanSchedule) (int, error)
+	// CreatePaymentAndUpdateLoanSchedules is atomic: either the payment, all
+	// schedules and the loan are written, or nothing is. It fails with
+	// ErrConcurrentModification if a schedule or the loan changed since it
+	// was read and with ErrAlreadyExists if the idempotency key was already
+	// used.
+	CreatePaymentAndUpdateLoanSchedules(payment entity.Payment, loanSchedules []entity.LoanSchedule, loan entity.Loan) (int, error)
+	// SettlePayment atomically moves a pending payment to completed or failed
+	// and writes back its allocations, the schedules and the loan. It fails
+	// with ErrConcurrentModification if the payment is no longer pending or a
+	// schedule or the loan changed since it was read.
+	SettlePayment(payment entity.Payment, loanSchedules []entity.LoanSchedule, loan entity.Loan) error
+	GetReversalsByLoanID(loanID int) ([]entity.PaymentReversal, error)
+	// CreateReversalsAndUpdateLoanSchedules atomically records the reversals,
+	// marks their payments as reversed and writes back the schedules and the
+	// loan. It fails with ErrConcurrentModification if a payment was already
+	// reversed or a schedule or the loan changed since it was read.
+	CreateReversalsAndUpdateLoanSchedules(reversals []entity.PaymentReversal, loanSchedules []entity.LoanSchedule, loan entity.Loan) ([]int, error)
+	GetCreditRefundsByLoanID(loanID int) ([]entity.CreditRefund, error)
+	// CreateCreditRefund records the refund and writes back the loan with its
+	// reduced credit balance atomically. It fails with
+	// ErrConcurrentModification if the loan changed since it was read.
+	CreateCreditRefund(refund entity.CreditRefund, loan entity.Loan) (int, error)
 }
@@ -0,0 +1,109 @@
+package rounding
+
+import (
+	"fmt"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// Residual decides which installments take up the difference left between a
+// total and the sum of its rounded parts.
+type Residual string
+
+const (
+	// Last adds the whole residual to the last installment.
+	Last Residual = "last"
+	// First adds the whole residual to the first installment.
+	First Residual = "first"
+	// Spread adds the residual a unit at a time to successive installments
+	// from the first, any part of a unit left going to the last.
+	Spread Residual = "spread"
+)
+
+// Policy is how the amounts of a loan's installments are rounded. The zero
+// value rounds half up to a minor unit and leaves the residual to the last
+// installment.
+type Policy struct {
+	// Unit is the increment amounts are rounded to, such as one rupiah.
+	// Zero means a minor unit.
+	Unit     money.Money        `db:"rounding_unit"`
+	Mode     money.RoundingMode `db:"rounding_mode"`
+	Residual Residual           `db:"rounding_residual"`
+}
+
+var currencies = map[string]Policy{
+	"IDR": {Unit: money.New(1), Mode: money.RoundHalfUp, Residual: Last},
+	"USD": {Unit: money.FromMinor(1), Mode: money.RoundHalfEven, Residual: Last},
+}
+
+// ForCurrency returns the policy amounts in the currency with the given ISO
+// 4217 code are rounded by unless a loan product sets its own.
+func ForCurrency(code string) (Policy, error) {
+	policy, ok := currencies[code]
+	if !ok {
+		return Policy{}, fmt.Errorf("no rounding policy for currency %q", code)
+	}
+	return policy, nil
+}
+
+// Validate reports a negative unit, an unknown mode or an unknown residual
+// rule.
+func (p Policy) Validate() error {
+	if p.Unit.IsNegative() {
+		return fmt.Errorf("rounding unit %v must not be negative", p.Unit)
+	}
+	if p.Mode.String() == "unknown" {
+		return fmt.Errorf("unknown rounding mode %d", p.Mode)
+	}
+	switch p.Residual {
+	case "", Last, First, Spread:
+		return nil
+	default:
+		return fmt.Errorf("unknown rounding residual %q", p.Residual)
+	}
+}
+
+// Round rounds the amount to a multiple of the policy's unit by its mode.
+func (p Policy) Round(amount money.Money) money.Money {
+	return p.unit().Mul(amount.Div(p.unit().Minor(), p.Mode).Minor())
+}
+
+// Distribute rounds each of the amounts and places the residual that leaves
+// against total by the policy, so that the amounts it returns sum to total
+// exactly.
+func (p Policy) Distribute(amounts []money.Money, total money.Money) []money.Money {
+	if len(amounts) == 0 {
+		return nil
+	}
+
+	rounded := make([]money.Money, len(amounts))
+	for i, amount := range amounts {
+		rounded[i] = p.Round(amount)
+	}
+	residual := total.Sub(money.Sum(rounded...))
+
+	switch p.Residual {
+	case First:
+		rounded[0] = rounded[0].Add(residual)
+	case Spread:
+		unit := p.unit()
+		if residual.IsNegative() {
+			unit = unit.Neg()
+		}
+		for i := 0; residual.Minor()/unit.Minor() > 0; i = (i + 1) % len(rounded) {
+			rounded[i] = rounded[i].Add(unit)
+			residual = residual.Sub(unit)
+		}
+		rounded[len(rounded)-1] = rounded[len(rounded)-1].Add(residual)
+	default:
+		rounded[len(rounded)-1] = rounded[len(rounded)-1].Add(residual)
+	}
+	return rounded
+}
+
+func (p Policy) unit() money.Money {
+	if p.Unit.IsZero() {
+		return money.FromMinor(1)
+	}
+	return p.Unit
+}
@@ -0,0 +1,117 @@
+package rounding
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+func TestPolicy_Distribute(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		amounts []money.Money
+		total   money.Money
+		want    []money.Money
+	}{
+		{
+			name:    "should leave amounts in minor units as they are without a policy",
+			policy:  Policy{},
+			amounts: []money.Money{money.MustParse("33.33"), money.MustParse("33.33"), money.MustParse("33.34")},
+			total:   money.New(100),
+			want:    []money.Money{money.MustParse("33.33"), money.MustParse("33.33"), money.MustParse("33.34")},
+		},
+		{
+			name:    "should add the residual to the last amount",
+			policy:  Policy{Unit: money.New(1), Residual: Last},
+			amounts: []money.Money{money.MustParse("33.33"), money.MustParse("33.33"), money.MustParse("33.34")},
+			total:   money.New(100),
+			want:    []money.Money{money.New(33), money.New(33), money.New(34)},
+		},
+		{
+			name:    "should add the residual to the first amount",
+			policy:  Policy{Unit: money.New(1), Residual: First},
+			amounts: []money.Money{money.MustParse("33.34"), money.MustParse("33.33"), money.MustParse("33.33")},
+			total:   money.New(100),
+			want:    []money.Money{money.New(34), money.New(33), money.New(33)},
+		},
+		{
+			name:    "should take a negative residual a unit at a time from the first amounts",
+			policy:  Policy{Unit: money.New(1), Residual: Spread},
+			amounts: []money.Money{money.MustParse("16.67"), money.MustParse("16.67"), money.MustParse("16.66")},
+			total:   money.MustParse("48"),
+			want:    []money.Money{money.New(16), money.New(16), money.New(16)},
+		},
+		{
+			name:    "should leave the part of a unit the spread cannot place to the last amount",
+			policy:  Policy{Unit: money.New(1), Residual: Spread},
+			amounts: []money.Money{money.New(10), money.New(10)},
+			total:   money.MustParse("22.50"),
+			want:    []money.Money{money.New(11), money.MustParse("11.50")},
+		},
+		{
+			name:    "should round to the unit by the mode",
+			policy:  Policy{Unit: money.New(100), Mode: money.RoundUp},
+			amounts: []money.Money{money.New(1001), money.New(1001)},
+			total:   money.New(2002),
+			want:    []money.Money{money.New(1100), money.New(902)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Distribute(tt.amounts, tt.total); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Distribute() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{
+			name:   "should accept the zero policy",
+			policy: Policy{},
+		},
+		{
+			name:    "should reject a negative unit",
+			policy:  Policy{Unit: money.New(-1)},
+			wantErr: true,
+		},
+		{
+			name:    "should reject an unknown mode",
+			policy:  Policy{Mode: money.RoundingMode(9)},
+			wantErr: true,
+		},
+		{
+			name:    "should reject an unknown residual",
+			policy:  Policy{Residual: "middle"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestForCurrency(t *testing.T) {
+	got, err := ForCurrency("IDR")
+	if err != nil {
+		t.Fatalf("ForCurrency() error = %v", err)
+	}
+	if want := (Policy{Unit: money.New(1), Mode: money.RoundHalfUp, Residual: Last}); got != want {
+		t.Errorf("ForCurrency() got = %+v, want %+v", got, want)
+	}
+
+	if _, err := ForCurrency("XYZ"); err == nil {
+		t.Errorf("ForCurrency() error = nil, want an error for an unknown currency")
+	}
+}
@@ -0,0 +1,185 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/daycount"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+var (
+	// ErrUnknownAmortizationMethod is returned for a loan whose amortization
+	// method the generator does not implement.
+	ErrUnknownAmortizationMethod = errors.New("unknown amortization method")
+	// ErrNegativeAmortization is returned when an installment of a stepped
+	// loan would not cover the interest accrued on the balance.
+	ErrNegativeAmortization = errors.New("installment does not cover the interest due")
+	// ErrUnknownDayCount is returned for a loan whose day count convention
+	// the generator does not implement.
+	ErrUnknownDayCount = errors.New("unknown day count convention")
+)
+
+// installment is the principal and interest due on one schedule.
+type installment struct {
+	principal money.Money
+	interest  money.Money
+}
+
+// amortize splits the loan into installments by its amortization method,
+// rates holding the interest rate charged for each installment's period.
+func amortize(loan entity.Loan, rates []*big.Rat) ([]installment, error) {
+	switch method := loan.Amortization.Method; method {
+	case "", entity.AmortizationFlat:
+		return flat(loan, rates), nil
+	case entity.AmortizationAnnuity:
+		return stepped(loan, rates, 0)
+	case entity.AmortizationEqualPrincipal:
+		return equalPrincipal(loan, rates), nil
+	case entity.AmortizationInterestOnly:
+		return interestOnly(loan, rates), nil
+	case entity.AmortizationStepped:
+		return stepped(loan, rates, loan.Amortization.Step)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAmortizationMethod, method)
+	}
+}
+
+// flat charges the rates of every period on the whole principal and spreads
+// principal and interest evenly. Amounts are rounded down to a minor unit and
+// the residual is carried by the last installment.
+func flat(loan entity.Loan, rates []*big.Rat) []installment {
+	tenor := int64(loan.Tenor)
+	totalRate := new(big.Rat)
+	for _, rate := range rates {
+		totalRate.Add(totalRate, rate)
+	}
+	totalInterest := loan.LoanAmount.MulRat(totalRate, money.RoundHalfUp)
+	principal := loan.LoanAmount.Div(tenor, money.RoundDown)
+	interest := totalInterest.Div(tenor, money.RoundDown)
+
+	installments := make([]installment, loan.Tenor)
+	for i := range installments {
+		installments[i] = installment{principal: principal, interest: interest}
+	}
+	installments[loan.Tenor-1] = installment{
+		principal: loan.LoanAmount.Sub(principal.Mul(tenor - 1)),
+		interest:  totalInterest.Sub(interest.Mul(tenor - 1)),
+	}
+	return installments
+}
+
+// equalPrincipal repays the principal evenly, the last installment carrying
+// the rounding residual, and charges interest on the balance left before each
+// installment.
+func equalPrincipal(loan entity.Loan, rates []*big.Rat) []installment {
+	tenor := int64(loan.Tenor)
+	principal := loan.LoanAmount.Div(tenor, money.RoundDown)
+
+	installments := make([]installment, loan.Tenor)
+	balance := loan.LoanAmount
+	for i := range installments {
+		if i == loan.Tenor-1 {
+			principal = balance
+		}
+		installments[i] = installment{principal: principal, interest: balance.MulRat(rates[i], money.RoundHalfUp)}
+		balance = balance.Sub(principal)
+	}
+	return installments
+}
+
+// interestOnly charges interest on the whole principal every installment and
+// repays the principal with the last one.
+func interestOnly(loan entity.Loan, rates []*big.Rat) []installment {
+	installments := make([]installment, loan.Tenor)
+	for i := range installments {
+		installments[i] = installment{interest: loan.LoanAmount.MulRat(rates[i], money.RoundHalfUp)}
+	}
+	installments[loan.Tenor-1].principal = loan.LoanAmount
+	return installments
+}
+
+// stepped charges interest on the balance left before each installment with
+// installments that grow by step percent each period, sized so the last one
+// clears the balance. A step of zero is an annuity. Installments are rounded
+// half up to a minor unit and the last one absorbs the principal residual.
+func stepped(loan entity.Loan, rates []*big.Rat, step float64) ([]installment, error) {
+	growth := new(big.Rat).Add(big.NewRat(1, 1), percent(step))
+	if growth.Sign() <= 0 {
+		return nil, fmt.Errorf("installment step %v%% must be above -100%%", step)
+	}
+
+	// the principal is the present value of the installments, the first
+	// of which is x: x * sum of growth^k / ((1+rate_0) ... (1+rate_k))
+	factor, term := new(big.Rat), big.NewRat(1, 1)
+	for i := 0; i < loan.Tenor; i++ {
+		term.Quo(term, new(big.Rat).Add(big.NewRat(1, 1), rates[i]))
+		factor.Add(factor, term)
+		term.Mul(term, growth)
+	}
+	first := new(big.Rat).Inv(factor)
+
+	installments := make([]installment, loan.Tenor)
+	balance := loan.LoanAmount
+	for i := range installments {
+		interest := balance.MulRat(rates[i], money.RoundHalfUp)
+		principal := loan.LoanAmount.MulRat(first, money.RoundHalfUp).Sub(interest)
+		if i == loan.Tenor-1 {
+			principal = balance
+		}
+		if principal.IsNegative() {
+			return nil, fmt.Errorf("%w: installment %d", ErrNegativeAmortization, i+1)
+		}
+		installments[i] = installment{principal: principal, interest: interest}
+		balance = balance.Sub(principal)
+		first.Mul(first, growth)
+	}
+	return installments, nil
+}
+
+// AccrualRate is the interest rate the loan charges on a balance from the day
+// of from to the day of to, a span of periods installments. Without a day
+// count convention it is InterestRate percent spread evenly over the tenor,
+// so each installment period is charged the same share of it whatever its
+// length. With one, InterestRate is an annual rate charged for the year
+// fraction the convention counts between the two days.
+func AccrualRate(loan entity.Loan, from, to time.Time, periods int) (*big.Rat, error) {
+	rate := percent(loan.InterestRate)
+	if loan.DayCount == daycount.PerTenor {
+		return rate.Mul(rate, big.NewRat(int64(periods), int64(loan.Tenor))), nil
+	}
+
+	fraction, err := loan.DayCount.YearFraction(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDayCount, loan.DayCount)
+	}
+	return rate.Mul(rate, fraction), nil
+}
+
+// periodRates returns the rate charged for each installment's period, which
+// runs from the previous due date, or LoanStartDate for the first one, to its
+// own.
+func periodRates(loan entity.Loan, dates []time.Time) ([]*big.Rat, error) {
+	rates := make([]*big.Rat, len(dates))
+	periodStart := loan.LoanStartDate
+	for i, dueDate := range dates {
+		rate, err := AccrualRate(loan, periodStart, dueDate, 1)
+		if err != nil {
+			return nil, err
+		}
+		rates[i] = rate
+		periodStart = dueDate
+	}
+	return rates, nil
+}
+
+// percent returns rate percent as an exact fraction, taking the rate at its
+// shortest decimal representation.
+func percent(rate float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	return r.Quo(r, big.NewRat(100, 1))
+}
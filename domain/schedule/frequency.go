@@ -0,0 +1,177 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+)
+
+const (
+	daysPerWeek      = 7
+	daysPerHalfMonth = 15
+)
+
+var (
+	// ErrUnknownFrequency is returned for a loan whose repayment frequency
+	// the generator does not implement.
+	ErrUnknownFrequency = errors.New("unknown repayment frequency")
+	// ErrInvalidAnchorDay is returned for an anchor day out of range for the
+	// loan's repayment frequency.
+	ErrInvalidAnchorDay = errors.New("invalid anchor day")
+)
+
+// dueDates returns the due date of each of the loan's installments. The first
+// installment falls due on the first anchored day at least one period after
+// LoanStartDate; later ones follow a period apart. Days of month missing from
+// a shorter month are clamped to its last day, so a loan anchored on the 31st
+// falls due on Feb 28 or 29 and on Mar 31. Due dates are then rolled to a
+// business day by the convention, except that daily loans simply fall due on
+// every business day so that installments do not pile up after a weekend.
+func dueDates(loan entity.Loan, businessDays *calendar.Calendar, convention calendar.Convention) ([]time.Time, error) {
+	nth, err := cadence(loan)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make([]time.Time, loan.Tenor)
+	if convention != calendar.Unadjusted && loan.Repayment.Frequency == entity.FrequencyDaily {
+		day := startOfDay(loan.LoanStartDate)
+		for i := range dates {
+			day = day.AddDate(0, 0, 1)
+			for !businessDays.IsBusinessDay(day) {
+				day = day.AddDate(0, 0, 1)
+			}
+			dates[i] = day
+		}
+		return dates, nil
+	}
+
+	for i := range dates {
+		dates[i] = businessDays.Adjust(nth(i), convention)
+	}
+	return dates, nil
+}
+
+// Following returns the first n due dates after the given one of the loan's
+// installments continued past its tenor. They are counted from LoanStartDate
+// by the loan's anchor like its own due dates, so a due date that was rolled
+// off a holiday or clamped to a month's end does not shift those after it.
+func Following(
+	loan entity.Loan,
+	after time.Time,
+	n int,
+	businessDays *calendar.Calendar,
+	convention calendar.Convention,
+) ([]time.Time, error) {
+	for loan.Tenor = max(loan.Tenor, 0) + n; ; loan.Tenor *= 2 {
+		dates, err := dueDates(loan, businessDays, convention)
+		if err != nil {
+			return nil, err
+		}
+		first := sort.Search(len(dates), func(i int) bool {
+			return dates[i].After(after)
+		})
+		if len(dates)-first >= n {
+			return dates[first : first+n], nil
+		}
+	}
+}
+
+// cadence returns a function giving the unadjusted due date of the i-th
+// installment, counting from zero.
+func cadence(loan entity.Loan) (func(i int) time.Time, error) {
+	start := startOfDay(loan.LoanStartDate)
+	anchor := loan.Repayment.AnchorDay
+
+	switch frequency := loan.Repayment.Frequency; frequency {
+	case entity.FrequencyDaily:
+		if anchor != 0 {
+			return nil, fmt.Errorf("%w: daily loans cannot be anchored", ErrInvalidAnchorDay)
+		}
+		return func(i int) time.Time {
+			return start.AddDate(0, 0, i+1)
+		}, nil
+	case "", entity.FrequencyWeekly:
+		return everyWeeks(start, anchor, 1)
+	case entity.FrequencyBiWeekly:
+		return everyWeeks(start, anchor, 2)
+	case entity.FrequencySemiMonthly:
+		if anchor == 0 {
+			anchor = (start.Day()-1)%daysPerHalfMonth + 1
+		}
+		if anchor < 1 || anchor > daysPerHalfMonth {
+			return nil, fmt.Errorf("%w: %d is not a day of month between 1 and %d", ErrInvalidAnchorDay, anchor, daysPerHalfMonth)
+		}
+		// half-month j falls on the anchor day of month j/2 for even j and
+		// 15 days later for odd j
+		halfMonth := func(j int) time.Time {
+			return dayOfMonth(start.Year(), start.Month()+time.Month(j/2), anchor+j%2*daysPerHalfMonth, start.Location())
+		}
+		earliest := start.AddDate(0, 0, daysPerHalfMonth)
+		first := 0
+		for halfMonth(first).Before(earliest) {
+			first++
+		}
+		return func(i int) time.Time {
+			return halfMonth(first + i)
+		}, nil
+	case entity.FrequencyMonthly:
+		if anchor == 0 {
+			anchor = start.Day()
+		}
+		if anchor < 1 || anchor > 31 {
+			return nil, fmt.Errorf("%w: %d is not a day of month", ErrInvalidAnchorDay, anchor)
+		}
+		month := func(j int) time.Time {
+			return dayOfMonth(start.Year(), start.Month()+time.Month(j), anchor, start.Location())
+		}
+		first := 1
+		if month(first).Before(dayOfMonth(start.Year(), start.Month()+1, start.Day(), start.Location())) {
+			first++
+		}
+		return func(i int) time.Time {
+			return month(first + i)
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFrequency, frequency)
+	}
+}
+
+// everyWeeks falls due every weeks weeks on the anchor ISO weekday.
+func everyWeeks(start time.Time, anchor, weeks int) (func(i int) time.Time, error) {
+	if anchor == 0 {
+		anchor = isoWeekday(start)
+	}
+	if anchor < 1 || anchor > daysPerWeek {
+		return nil, fmt.Errorf("%w: %d is not an ISO weekday", ErrInvalidAnchorDay, anchor)
+	}
+
+	earliest := start.AddDate(0, 0, daysPerWeek*weeks)
+	first := earliest.AddDate(0, 0, (anchor-isoWeekday(earliest)+daysPerWeek)%daysPerWeek)
+	return func(i int) time.Time {
+		return first.AddDate(0, 0, daysPerWeek*weeks*i)
+	}, nil
+}
+
+// dayOfMonth returns the given day of the month, or the month's last day if it
+// is shorter. Months past December roll into the following years.
+func dayOfMonth(year int, month time.Month, day int, loc *time.Location) time.Time {
+	firstOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(day, lastDay)-1)
+}
+
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return daysPerWeek
+	}
+	return int(t.Weekday())
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
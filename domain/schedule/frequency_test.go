@@ -0,0 +1,253 @@
+package schedule
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+func TestGenerate_DueDates(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	newLoan := func(start time.Time, tenor int, repayment entity.Repayment) entity.Loan {
+		return entity.Loan{
+			LoanAmount:    money.New(1200000),
+			InterestRate:  12,
+			LoanStartDate: start,
+			Tenor:         tenor,
+			Repayment:     repayment,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		loan    entity.Loan
+		want    []time.Time
+		wantErr error
+	}{
+		{
+			name: "should fall due every day",
+			loan: newLoan(date(2024, time.February, 27), 4, entity.Repayment{Frequency: entity.FrequencyDaily}),
+			want: []time.Time{
+				date(2024, time.February, 28),
+				date(2024, time.February, 29),
+				date(2024, time.March, 1),
+				date(2024, time.March, 2),
+			},
+		},
+		{
+			name: "should fall due every week on the start weekday by default",
+			loan: newLoan(date(2024, time.October, 7), 3, entity.Repayment{}),
+			want: []time.Time{
+				date(2024, time.October, 14),
+				date(2024, time.October, 21),
+				date(2024, time.October, 28),
+			},
+		},
+		{
+			name: "should fall due every other week on the anchor weekday",
+			// Oct 9, 2024 is a Wednesday; the anchor is Friday
+			loan: newLoan(date(2024, time.October, 9), 3, entity.Repayment{Frequency: entity.FrequencyBiWeekly, AnchorDay: 5}),
+			want: []time.Time{
+				date(2024, time.October, 25),
+				date(2024, time.November, 8),
+				date(2024, time.November, 22),
+			},
+		},
+		{
+			name: "should fall due twice a month on the anchor day and 15 days later",
+			loan: newLoan(date(2024, time.January, 20), 4, entity.Repayment{Frequency: entity.FrequencySemiMonthly, AnchorDay: 15}),
+			want: []time.Time{
+				date(2024, time.February, 15),
+				date(2024, time.February, 29),
+				date(2024, time.March, 15),
+				date(2024, time.March, 30),
+			},
+		},
+		{
+			name: "should clamp monthly due dates to the end of shorter months",
+			loan: newLoan(date(2023, time.December, 31), 4, entity.Repayment{Frequency: entity.FrequencyMonthly}),
+			want: []time.Time{
+				date(2024, time.January, 31),
+				date(2024, time.February, 29),
+				date(2024, time.March, 31),
+				date(2024, time.April, 30),
+			},
+		},
+		{
+			name: "should skip a month when the anchor day would cut the first period short",
+			loan: newLoan(date(2024, time.January, 20), 3, entity.Repayment{Frequency: entity.FrequencyMonthly, AnchorDay: 5}),
+			want: []time.Time{
+				date(2024, time.March, 5),
+				date(2024, time.April, 5),
+				date(2024, time.May, 5),
+			},
+		},
+		{
+			name:    "should return error if anchor day is out of range",
+			loan:    newLoan(date(2024, time.January, 20), 3, entity.Repayment{Frequency: entity.FrequencySemiMonthly, AnchorDay: 16}),
+			wantErr: ErrInvalidAnchorDay,
+		},
+		{
+			name:    "should return error if frequency is unknown",
+			loan:    newLoan(date(2024, time.January, 20), 3, entity.Repayment{Frequency: "quarterly"}),
+			wantErr: ErrUnknownFrequency,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate(tt.loan, nil, calendar.Unadjusted)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			dueDates := make([]time.Time, 0, len(got))
+			for _, s := range got {
+				dueDates = append(dueDates, s.DueDate)
+			}
+			if !reflect.DeepEqual(dueDates, tt.want) {
+				t.Errorf("Generate() due dates = %v, want %v", dueDates, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerate_BusinessDays(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	holidays, err := calendar.New(
+		calendar.Holiday{Date: date(time.April, 10), Name: "Idul Fitri"},
+		calendar.Holiday{Date: date(time.April, 11), Name: "Idul Fitri"},
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	newLoan := func(start time.Time, tenor int, frequency string) entity.Loan {
+		return entity.Loan{
+			LoanAmount:    money.New(1200000),
+			InterestRate:  12,
+			LoanStartDate: start,
+			Tenor:         tenor,
+			Repayment:     entity.Repayment{Frequency: frequency},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		loan       entity.Loan
+		convention calendar.Convention
+		want       []time.Time
+	}{
+		{
+			name:       "should roll due dates on holidays to the next business day",
+			loan:       newLoan(date(time.March, 27), 3, entity.FrequencyWeekly),
+			convention: calendar.Following,
+			want:       []time.Time{date(time.April, 3), date(time.April, 12), date(time.April, 17)},
+		},
+		{
+			name:       "should roll due dates on holidays to the previous business day",
+			loan:       newLoan(date(time.March, 27), 3, entity.FrequencyWeekly),
+			convention: calendar.Preceding,
+			want:       []time.Time{date(time.April, 3), date(time.April, 9), date(time.April, 17)},
+		},
+		{
+			name: "should roll a month-end due date back rather than into the next month",
+			// Jun 30, 2024 is a Sunday
+			loan:       newLoan(date(time.April, 30), 2, entity.FrequencyMonthly),
+			convention: calendar.ModifiedFollowing,
+			want:       []time.Time{date(time.May, 30), date(time.June, 28)},
+		},
+		{
+			name:       "should fall due on every business day of a daily loan",
+			loan:       newLoan(date(time.April, 8), 4, entity.FrequencyDaily),
+			convention: calendar.Following,
+			want:       []time.Time{date(time.April, 9), date(time.April, 12), date(time.April, 15), date(time.April, 16)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate(tt.loan, holidays, tt.convention)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			dueDates := make([]time.Time, 0, len(got))
+			for _, s := range got {
+				dueDates = append(dueDates, s.DueDate)
+			}
+			if !reflect.DeepEqual(dueDates, tt.want) {
+				t.Errorf("Generate() due dates = %v, want %v", dueDates, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowing(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	holidays, err := calendar.New(calendar.Holiday{Date: date(2024, time.January, 15), Name: "Collective leave"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		loan       entity.Loan
+		after      time.Time
+		convention calendar.Convention
+		want       []time.Time
+	}{
+		{
+			name:  "should follow a week apart",
+			loan:  entity.Loan{LoanStartDate: date(2024, time.October, 7), Tenor: 3},
+			after: date(2024, time.October, 28),
+			want:  []time.Time{date(2024, time.November, 4), date(2024, time.November, 11)},
+		},
+		{
+			name:       "should keep the weekday after a due date rolled off a holiday",
+			loan:       entity.Loan{LoanStartDate: date(2024, time.January, 1), Tenor: 2},
+			after:      date(2024, time.January, 16),
+			convention: calendar.Following,
+			want:       []time.Time{date(2024, time.January, 22), date(2024, time.January, 29)},
+		},
+		{
+			name: "should keep the anchor day of month after a due date clamped to a shorter month",
+			loan: entity.Loan{
+				LoanStartDate: date(2023, time.December, 31),
+				Tenor:         2,
+				Repayment:     entity.Repayment{Frequency: entity.FrequencyMonthly, AnchorDay: 31},
+			},
+			after: date(2024, time.February, 29),
+			want:  []time.Time{date(2024, time.March, 31), date(2024, time.April, 30)},
+		},
+		{
+			name:  "should continue past installments already deferred beyond the tenor",
+			loan:  entity.Loan{LoanStartDate: date(2024, time.October, 7), Tenor: 1},
+			after: date(2024, time.November, 4),
+			want:  []time.Time{date(2024, time.November, 11), date(2024, time.November, 18)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Following(tt.loan, tt.after, 2, holidays, tt.convention)
+			if err != nil {
+				t.Fatalf("Following() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Following() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package schedule
+
+import (
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+)
+
+// Generate builds the repayment schedule of a loan, its installments falling
+// due at the loan's repayment frequency (see dueDates) and rolled off weekends
+// and holidays of businessDays by the convention. Each installment is
+// split between principal and interest by the loan's amortization method and
+// both are rounded by the loan's rounding policy; the principal always sums
+// exactly to LoanAmount and the interest to the rounded total the method
+// charges. PaymentStatus is left for the caller to set.
+func Generate(loan entity.Loan, businessDays *calendar.Calendar, convention calendar.Convention) ([]entity.LoanSchedule, error) {
+	if loan.Tenor <= 0 {
+		return nil, nil
+	}
+
+	dates, err := dueDates(loan, businessDays, convention)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := periodRates(loan, dates)
+	if err != nil {
+		return nil, err
+	}
+	installments, err := amortize(loan, rates)
+	if err != nil {
+		return nil, err
+	}
+
+	principals := make([]money.Money, len(installments))
+	interests := make([]money.Money, len(installments))
+	for i, installment := range installments {
+		principals[i] = installment.principal
+		interests[i] = installment.interest
+	}
+	principals = loan.Rounding.Distribute(principals, loan.LoanAmount)
+	interests = loan.Rounding.Distribute(interests, loan.Rounding.Round(money.Sum(interests...)))
+
+	schedules := make([]entity.LoanSchedule, 0, loan.Tenor)
+	for i := range installments {
+		schedules = append(schedules, entity.LoanSchedule{
+			LoanID:          loan.LoanID,
+			DueDate:         dates[i],
+			PrincipalAmount: principals[i],
+			InterestAmount:  interests[i],
+			TotalDue:        principals[i].Add(interests[i]),
+		})
+	}
+
+	return schedules, nil
+}
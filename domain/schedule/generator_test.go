@@ -0,0 +1,398 @@
+package schedule
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/daycount"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/rounding"
+)
+
+func TestGenerate(t *testing.T) {
+	start := time.Date(2024, time.October, 7, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		loan          entity.Loan
+		wantLen       int
+		wantFirst     entity.LoanSchedule
+		wantLastTotal money.Money
+	}{
+		{
+			name: "should split 5,000,000 at 10% over 50 weeks into 110,000 installments",
+			loan: entity.Loan{
+				LoanID:        1,
+				LoanAmount:    money.New(5000000),
+				InterestRate:  10,
+				LoanStartDate: start,
+				Tenor:         50,
+			},
+			wantLen: 50,
+			wantFirst: entity.LoanSchedule{
+				LoanID:          1,
+				DueDate:         time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC),
+				PrincipalAmount: money.New(100000),
+				InterestAmount:  money.New(10000),
+				TotalDue:        money.New(110000),
+			},
+			wantLastTotal: money.New(110000),
+		},
+		{
+			name: "should carry rounding residual on the last installment",
+			loan: entity.Loan{
+				LoanID:        2,
+				LoanAmount:    money.New(1000000),
+				InterestRate:  10,
+				LoanStartDate: start,
+				Tenor:         3,
+			},
+			wantLen: 3,
+			wantFirst: entity.LoanSchedule{
+				LoanID:          2,
+				DueDate:         time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC),
+				PrincipalAmount: money.MustParse("333333.33"),
+				InterestAmount:  money.MustParse("33333.33"),
+				TotalDue:        money.MustParse("366666.66"),
+			},
+			wantLastTotal: money.MustParse("366666.68"),
+		},
+		{
+			name:    "should return nothing for a loan without tenor",
+			loan:    entity.Loan{LoanAmount: money.New(1000000), LoanStartDate: start},
+			wantLen: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate(tt.loan, nil, calendar.Unadjusted)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if len(got) != tt.wantLen {
+				t.Fatalf("Generate() len = %v, want %v", len(got), tt.wantLen)
+			}
+			if tt.wantLen == 0 {
+				return
+			}
+			if got[0] != tt.wantFirst {
+				t.Errorf("Generate() first = %+v, want %+v", got[0], tt.wantFirst)
+			}
+			if last := got[len(got)-1]; last.TotalDue != tt.wantLastTotal {
+				t.Errorf("Generate() last total due = %v, want %v", last.TotalDue, tt.wantLastTotal)
+			}
+			if wantEnd := start.AddDate(0, 0, 7*tt.loan.Tenor); !got[len(got)-1].DueDate.Equal(wantEnd) {
+				t.Errorf("Generate() last due date = %v, want %v", got[len(got)-1].DueDate, wantEnd)
+			}
+
+			var principal money.Money
+			for _, s := range got {
+				principal = principal.Add(s.PrincipalAmount)
+			}
+			if principal != tt.loan.LoanAmount {
+				t.Errorf("Generate() principal sums to %v, want %v", principal, tt.loan.LoanAmount)
+			}
+		})
+	}
+}
+
+func TestGenerate_Amortization(t *testing.T) {
+	start := time.Date(2024, time.October, 7, 0, 0, 0, 0, time.UTC)
+	newLoan := func(amortization entity.Amortization) entity.Loan {
+		return entity.Loan{
+			LoanID:        1,
+			LoanAmount:    money.New(1000000),
+			InterestRate:  12,
+			LoanStartDate: start,
+			Tenor:         4,
+			Amortization:  amortization,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		loan         entity.Loan
+		wantTotals   []money.Money
+		wantInterest money.Money
+		wantErr      error
+	}{
+		{
+			name:         "should charge flat interest on the original principal",
+			loan:         newLoan(entity.Amortization{Method: entity.AmortizationFlat}),
+			wantTotals:   []money.Money{money.New(280000), money.New(280000), money.New(280000), money.New(280000)},
+			wantInterest: money.New(120000),
+		},
+		{
+			name: "should charge equal installments on an annuity",
+			loan: newLoan(entity.Amortization{Method: entity.AmortizationAnnuity}),
+			wantTotals: []money.Money{
+				money.MustParse("269027.05"),
+				money.MustParse("269027.05"),
+				money.MustParse("269027.05"),
+				money.MustParse("269027.03"),
+			},
+			wantInterest: money.MustParse("76108.18"),
+		},
+		{
+			name: "should decline installments with equal principal",
+			loan: newLoan(entity.Amortization{Method: entity.AmortizationEqualPrincipal}),
+			wantTotals: []money.Money{
+				money.New(280000),
+				money.New(272500),
+				money.New(265000),
+				money.New(257500),
+			},
+			wantInterest: money.New(75000),
+		},
+		{
+			name: "should repay the principal as a balloon when interest only",
+			loan: newLoan(entity.Amortization{Method: entity.AmortizationInterestOnly}),
+			wantTotals: []money.Money{
+				money.New(30000),
+				money.New(30000),
+				money.New(30000),
+				money.New(1030000),
+			},
+			wantInterest: money.New(120000),
+		},
+		{
+			name: "should step installments up by the given percentage",
+			loan: newLoan(entity.Amortization{Method: entity.AmortizationStepped, Step: 10}),
+			wantTotals: []money.Money{
+				money.MustParse("232686.55"),
+				money.MustParse("255955.21"),
+				money.MustParse("281550.73"),
+				money.MustParse("309705.80"),
+			},
+			wantInterest: money.MustParse("79898.29"),
+		},
+		{
+			name:    "should return error if a step up leaves interest uncovered",
+			loan:    newLoan(entity.Amortization{Method: entity.AmortizationStepped, Step: 400}),
+			wantErr: ErrNegativeAmortization,
+		},
+		{
+			name:    "should return error if amortization method is unknown",
+			loan:    newLoan(entity.Amortization{Method: "balloon"}),
+			wantErr: ErrUnknownAmortizationMethod,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate(tt.loan, nil, calendar.Unadjusted)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			var principal, interest money.Money
+			totals := make([]money.Money, 0, len(got))
+			for _, s := range got {
+				if s.TotalDue != s.PrincipalAmount.Add(s.InterestAmount) {
+					t.Errorf("Generate() schedule = %+v does not add up", s)
+				}
+				principal = principal.Add(s.PrincipalAmount)
+				interest = interest.Add(s.InterestAmount)
+				totals = append(totals, s.TotalDue)
+			}
+			if !reflect.DeepEqual(totals, tt.wantTotals) {
+				t.Errorf("Generate() totals = %v, want %v", totals, tt.wantTotals)
+			}
+			if principal != tt.loan.LoanAmount {
+				t.Errorf("Generate() principal sums to %v, want %v", principal, tt.loan.LoanAmount)
+			}
+			if interest != tt.wantInterest {
+				t.Errorf("Generate() interest sums to %v, want %v", interest, tt.wantInterest)
+			}
+		})
+	}
+}
+
+func TestGenerate_DayCount(t *testing.T) {
+	// monthly on the 31st from Jan 31, 2024: periods of 29, 31 and 30 days
+	newLoan := func(dayCount daycount.Convention, method string) entity.Loan {
+		return entity.Loan{
+			LoanAmount:    money.New(1200000),
+			InterestRate:  12,
+			LoanStartDate: time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC),
+			Tenor:         3,
+			DayCount:      dayCount,
+			Amortization:  entity.Amortization{Method: method},
+			Repayment:     entity.Repayment{Frequency: entity.FrequencyMonthly},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		loan         entity.Loan
+		wantInterest []money.Money
+		wantErr      error
+	}{
+		{
+			name:         "should charge the rate over the tenor evenly without a convention",
+			loan:         newLoan(daycount.PerTenor, entity.AmortizationInterestOnly),
+			wantInterest: []money.Money{money.New(48000), money.New(48000), money.New(48000)},
+		},
+		{
+			name: "should accrue an annual rate by actual days over 365",
+			loan: newLoan(daycount.Actual365, entity.AmortizationInterestOnly),
+			wantInterest: []money.Money{
+				money.MustParse("11441.10"),
+				money.MustParse("12230.14"),
+				money.MustParse("11835.62"),
+			},
+		},
+		{
+			name:         "should accrue an annual rate by actual days over 360",
+			loan:         newLoan(daycount.Actual360, entity.AmortizationInterestOnly),
+			wantInterest: []money.Money{money.New(11600), money.New(12400), money.New(12000)},
+		},
+		{
+			name:         "should accrue an annual rate by 30-day months",
+			loan:         newLoan(daycount.Thirty360, entity.AmortizationInterestOnly),
+			wantInterest: []money.Money{money.New(11600), money.New(12800), money.New(12000)},
+		},
+		{
+			name: "should accrue an annual rate by actual days over the days of a leap year",
+			loan: newLoan(daycount.ActualActual, entity.AmortizationInterestOnly),
+			wantInterest: []money.Money{
+				money.MustParse("11409.84"),
+				money.MustParse("12196.72"),
+				money.MustParse("11803.28"),
+			},
+		},
+		{
+			name:         "should spread flat interest for the whole term evenly",
+			loan:         newLoan(daycount.Actual360, entity.AmortizationFlat),
+			wantInterest: []money.Money{money.New(12000), money.New(12000), money.New(12000)},
+		},
+		{
+			name:    "should return error if day count convention is unknown",
+			loan:    newLoan("act_364", entity.AmortizationFlat),
+			wantErr: ErrUnknownDayCount,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate(tt.loan, nil, calendar.Unadjusted)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			interest := make([]money.Money, 0, len(got))
+			for _, s := range got {
+				interest = append(interest, s.InterestAmount)
+			}
+			if !reflect.DeepEqual(interest, tt.wantInterest) {
+				t.Errorf("Generate() interest = %v, want %v", interest, tt.wantInterest)
+			}
+		})
+	}
+}
+
+func TestGenerate_Rounding(t *testing.T) {
+	// a sixth of 1,000,000 principal and 100,000 interest round up to
+	// 166,667 and 16,667 rupiah, two over the totals
+	newLoan := func(policy rounding.Policy) entity.Loan {
+		return entity.Loan{
+			LoanAmount:    money.New(1000000),
+			InterestRate:  10,
+			LoanStartDate: time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
+			Tenor:         6,
+			Rounding:      policy,
+			Amortization:  entity.Amortization{Method: entity.AmortizationFlat},
+			Repayment:     entity.Repayment{Frequency: entity.FrequencyWeekly},
+		}
+	}
+	rupiahs := func(amounts ...int64) []money.Money {
+		m := make([]money.Money, 0, len(amounts))
+		for _, amount := range amounts {
+			m = append(m, money.New(amount))
+		}
+		return m
+	}
+
+	tests := []struct {
+		name          string
+		loan          entity.Loan
+		wantPrincipal []money.Money
+		wantInterest  []money.Money
+	}{
+		{
+			name: "should round to a minor unit without a policy",
+			loan: newLoan(rounding.Policy{}),
+			wantPrincipal: []money.Money{
+				money.MustParse("166666.66"),
+				money.MustParse("166666.66"),
+				money.MustParse("166666.66"),
+				money.MustParse("166666.66"),
+				money.MustParse("166666.66"),
+				money.MustParse("166666.70"),
+			},
+			wantInterest: []money.Money{
+				money.MustParse("16666.66"),
+				money.MustParse("16666.66"),
+				money.MustParse("16666.66"),
+				money.MustParse("16666.66"),
+				money.MustParse("16666.66"),
+				money.MustParse("16666.70"),
+			},
+		},
+		{
+			name:          "should leave the residual to the last installment",
+			loan:          newLoan(rounding.Policy{Unit: money.New(1), Residual: rounding.Last}),
+			wantPrincipal: rupiahs(166667, 166667, 166667, 166667, 166667, 166665),
+			wantInterest:  rupiahs(16667, 16667, 16667, 16667, 16667, 16665),
+		},
+		{
+			name:          "should leave the residual to the first installment",
+			loan:          newLoan(rounding.Policy{Unit: money.New(1), Residual: rounding.First}),
+			wantPrincipal: rupiahs(166665, 166667, 166667, 166667, 166667, 166667),
+			wantInterest:  rupiahs(16665, 16667, 16667, 16667, 16667, 16667),
+		},
+		{
+			name:          "should spread the residual a rupiah at a time",
+			loan:          newLoan(rounding.Policy{Unit: money.New(1), Residual: rounding.Spread}),
+			wantPrincipal: rupiahs(166666, 166666, 166667, 166667, 166667, 166667),
+			wantInterest:  rupiahs(16666, 16666, 16667, 16667, 16667, 16667),
+		},
+		{
+			name:          "should round interest down to the unit",
+			loan:          newLoan(rounding.Policy{Unit: money.New(100), Mode: money.RoundDown, Residual: rounding.Last}),
+			wantPrincipal: rupiahs(166600, 166600, 166600, 166600, 166600, 167000),
+			wantInterest:  rupiahs(16600, 16600, 16600, 16600, 16600, 17000),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Generate(tt.loan, nil, calendar.Unadjusted)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			var principal, interest []money.Money
+			for _, s := range got {
+				principal = append(principal, s.PrincipalAmount)
+				interest = append(interest, s.InterestAmount)
+				if s.TotalDue != s.PrincipalAmount.Add(s.InterestAmount) {
+					t.Errorf("Generate() total due = %v, want %v", s.TotalDue, s.PrincipalAmount.Add(s.InterestAmount))
+				}
+			}
+			if !reflect.DeepEqual(principal, tt.wantPrincipal) {
+				t.Errorf("Generate() principal = %v, want %v", principal, tt.wantPrincipal)
+			}
+			if !reflect.DeepEqual(interest, tt.wantInterest) {
+				t.Errorf("Generate() interest = %v, want %v", interest, tt.wantInterest)
+			}
+		})
+	}
+}
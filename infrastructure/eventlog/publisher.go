@@ -0,0 +1,26 @@
+package eventlog
+
+import (
+	"log"
+
+	"github.com/iqbalbachmid/billing-engine/domain/event"
+)
+
+// Publisher writes domain events to a logger. It stands in for a message
+// broker until one is wired up.
+type Publisher struct {
+	logger *log.Logger
+}
+
+var _ event.Publisher = (*Publisher)(nil)
+
+func NewPublisher(logger *log.Logger) *Publisher {
+	return &Publisher{
+		logger: logger,
+	}
+}
+
+func (p *Publisher) Publish(e event.Event) error {
+	p.logger.Printf("event %s: %+v", e.Name(), e)
+	return nil
+}
@@ -0,0 +1,55 @@
+package holidays
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+)
+
+type holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// Load reads a calendar from a JSON file listing the public holidays of each
+// year:
+//
+//	{"2024": [{"date": "2024-01-01", "name": "Tahun Baru Masehi"}]}
+//
+// A holiday listed under a year it does not fall in is an error. The calendar
+// covers only the years with holidays listed; see calendar.CheckYear.
+func Load(path string) (*calendar.Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var years map[string][]holiday
+	if err = json.Unmarshal(data, &years); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var holidays []calendar.Holiday
+	for year, listed := range years {
+		y, err := strconv.Atoi(year)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a year", path, year)
+		}
+		for _, h := range listed {
+			date, err := time.Parse(time.DateOnly, h.Date)
+			if err != nil {
+				return nil, fmt.Errorf("%s: holiday %q: %w", path, h.Name, err)
+			}
+			if date.Year() != y {
+				return nil, fmt.Errorf("%s: holiday %q on %s is listed under %d", path, h.Name, h.Date, y)
+			}
+			holidays = append(holidays, calendar.Holiday{Date: date, Name: h.Name})
+		}
+	}
+
+	return calendar.New(holidays...)
+}
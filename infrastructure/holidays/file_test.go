@@ -0,0 +1,60 @@
+package holidays
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "should load holidays per year",
+			content: `{"2024": [{"date": "2024-04-10", "name": "Idul Fitri"}], "2025": [{"date": "2025-03-31", "name": "Idul Fitri"}]}`,
+		},
+		{
+			name:    "should return error if a holiday is listed under another year",
+			content: `{"2024": [{"date": "2025-03-31", "name": "Idul Fitri"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "should return error if a date is malformed",
+			content: `{"2024": [{"date": "10/04/2024", "name": "Idul Fitri"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "should return error if the file is not JSON",
+			content: `2024-04-10 Idul Fitri`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "holidays.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			got, err := Load(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, day := range []time.Time{
+				time.Date(2024, time.April, 10, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC),
+			} {
+				if got.IsBusinessDay(day) {
+					t.Errorf("Load() IsBusinessDay(%v) = true, want false", day)
+				}
+			}
+		})
+	}
+}
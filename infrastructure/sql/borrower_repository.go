@@ -0,0 +1,205 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+)
+
+const borrowerColumns = `borrower_id, first_name, last_name, email, phone, address, date_of_birth, account_status`
+
+type BorrowerRepository struct {
+	db *sql.DB
+}
+
+var _ repository.BorrowerRepository = (*BorrowerRepository)(nil)
+
+func NewBorrowerRepository(client *DbClient) *BorrowerRepository {
+	return &BorrowerRepository{
+		db: client.DB,
+	}
+}
+
+func (r *BorrowerRepository) GetByID(id int) (entity.Borrower, error) {
+	row := r.db.QueryRow(`SELECT `+borrowerColumns+` FROM borrowers WHERE borrower_id = ?`, id)
+
+	borrower, err := scanBorrower(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Borrower{}, repository.ErrNotFound
+	}
+	return borrower, err
+}
+
+func (r *BorrowerRepository) GetAll() ([]entity.Borrower, error) {
+	rows, err := r.db.Query(`SELECT ` + borrowerColumns + ` FROM borrowers ORDER BY borrower_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var borrowers []entity.Borrower
+	for rows.Next() {
+		borrower, err := scanBorrower(rows)
+		if err != nil {
+			return nil, err
+		}
+		borrowers = append(borrowers, borrower)
+	}
+
+	return borrowers, rows.Err()
+}
+
+func (r *BorrowerRepository) Create(borrower entity.Borrower) (int, error) {
+	result, err := r.db.Exec(`
+		INSERT INTO borrowers (first_name, last_name, email, phone, address, date_of_birth, account_status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		borrower.FirstName,
+		borrower.LastName,
+		borrower.Email,
+		borrower.Phone,
+		borrower.Address,
+		borrower.DateOfBirth,
+		borrower.AccountStatus,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *BorrowerRepository) Update(borrower entity.Borrower) error {
+	result, err := r.db.Exec(`
+		UPDATE borrowers
+		SET first_name = ?, last_name = ?, email = ?, phone = ?, address = ?, date_of_birth = ?, account_status = ?
+		WHERE borrower_id = ?`,
+		borrower.FirstName,
+		borrower.LastName,
+		borrower.Email,
+		borrower.Phone,
+		borrower.Address,
+		borrower.DateOfBirth,
+		borrower.AccountStatus,
+		borrower.BorrowerID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
+}
+
+func (r *BorrowerRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM borrowers WHERE borrower_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
+}
+
+func (r *BorrowerRepository) GetStatusChanges(borrowerID int) ([]entity.BorrowerStatusChange, error) {
+	rows, err := r.db.Query(`
+		SELECT change_id, borrower_id, from_status, to_status, changed_date, reason
+		FROM borrower_status_changes
+		WHERE borrower_id = ?
+		ORDER BY changed_date, change_id`,
+		borrowerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []entity.BorrowerStatusChange
+	for rows.Next() {
+		var change entity.BorrowerStatusChange
+		err = rows.Scan(
+			&change.ChangeID,
+			&change.BorrowerID,
+			&change.FromStatus,
+			&change.ToStatus,
+			&change.ChangedDate,
+			&change.Reason,
+		)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// UpdateAccountStatus moves the borrower from the change's old status to its
+// new one and records the change in a single transaction.
+func (r *BorrowerRepository) UpdateAccountStatus(change entity.BorrowerStatusChange) (int, error) {
+	var changeID int64
+	err := withTx(r.db, func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			`UPDATE borrowers SET account_status = ? WHERE borrower_id = ? AND account_status = ?`,
+			change.ToStatus,
+			change.BorrowerID,
+			change.FromStatus,
+		)
+		if err != nil {
+			return err
+		}
+		err = expectAffected(result)
+		if errors.Is(err, repository.ErrNotFound) {
+			var exists bool
+			if err = tx.QueryRow(
+				`SELECT EXISTS (SELECT 1 FROM borrowers WHERE borrower_id = ?)`,
+				change.BorrowerID,
+			).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				return repository.ErrNotFound
+			}
+			return repository.ErrConcurrentModification
+		}
+		if err != nil {
+			return err
+		}
+
+		result, err = tx.Exec(`
+			INSERT INTO borrower_status_changes (borrower_id, from_status, to_status, changed_date, reason)
+			VALUES (?, ?, ?, ?, ?)`,
+			change.BorrowerID,
+			change.FromStatus,
+			change.ToStatus,
+			change.ChangedDate,
+			change.Reason,
+		)
+		if err != nil {
+			return err
+		}
+
+		changeID, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(changeID), nil
+}
+
+func scanBorrower(row scanner) (entity.Borrower, error) {
+	var borrower entity.Borrower
+	err := row.Scan(
+		&borrower.BorrowerID,
+		&borrower.FirstName,
+		&borrower.LastName,
+		&borrower.Email,
+		&borrower.Phone,
+		&borrower.Address,
+		&borrower.DateOfBirth,
+		&borrower.AccountStatus,
+	)
+	return borrower, err
+}
@@ -0,0 +1,110 @@
+package sql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+)
+
+func TestBorrowerRepository(t *testing.T) {
+	repo := NewBorrowerRepository(newTestDbClient(t))
+
+	borrower := entity.Borrower{
+		FirstName:     "Budi",
+		LastName:      "Santoso",
+		Email:         "budi@example.com",
+		Phone:         "081234567890",
+		Address:       "Jl. Sudirman 1, Jakarta",
+		DateOfBirth:   time.Date(1990, time.May, 17, 0, 0, 0, 0, time.UTC),
+		AccountStatus: "active",
+	}
+
+	id, err := repo.Create(borrower)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	borrower.BorrowerID = id
+
+	got, err := repo.GetByID(id)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, borrower) {
+		t.Errorf("GetByID() got = %+v, want %+v", got, borrower)
+	}
+
+	borrower.AccountStatus = "delinquent"
+	if err = repo.Update(borrower); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	all, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if !reflect.DeepEqual(all, []entity.Borrower{borrower}) {
+		t.Errorf("GetAll() got = %+v, want %+v", all, []entity.Borrower{borrower})
+	}
+
+	if err = repo.Delete(id); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err = repo.GetByID(id); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetByID() after delete error = %v, want %v", err, repository.ErrNotFound)
+	}
+	if err = repo.Update(borrower); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Update() after delete error = %v, want %v", err, repository.ErrNotFound)
+	}
+}
+
+func TestBorrowerRepository_UpdateAccountStatus(t *testing.T) {
+	repo := NewBorrowerRepository(newTestDbClient(t))
+
+	borrowerID, err := repo.Create(entity.Borrower{FirstName: "Budi", AccountStatus: entity.AccountStatusActive})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	change := entity.BorrowerStatusChange{
+		BorrowerID:  borrowerID,
+		FromStatus:  entity.AccountStatusActive,
+		ToStatus:    entity.AccountStatusDelinquent,
+		ChangedDate: time.Date(2024, time.October, 23, 0, 0, 0, 0, time.UTC),
+		Reason:      "loan 1: 2 installments are overdue",
+	}
+	if change.ChangeID, err = repo.UpdateAccountStatus(change); err != nil {
+		t.Fatalf("UpdateAccountStatus() error = %v", err)
+	}
+
+	borrower, err := repo.GetByID(borrowerID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if borrower.AccountStatus != entity.AccountStatusDelinquent {
+		t.Errorf("GetByID() account status = %v, want %v", borrower.AccountStatus, entity.AccountStatusDelinquent)
+	}
+
+	changes, err := repo.GetStatusChanges(borrowerID)
+	if err != nil {
+		t.Fatalf("GetStatusChanges() error = %v", err)
+	}
+	if !reflect.DeepEqual(changes, []entity.BorrowerStatusChange{change}) {
+		t.Errorf("GetStatusChanges() got = %+v, want %+v", changes, []entity.BorrowerStatusChange{change})
+	}
+
+	// the borrower is no longer active, so the change is stale
+	if _, err = repo.UpdateAccountStatus(change); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Errorf("UpdateAccountStatus() twice error = %v, wantErr %v", err, repository.ErrConcurrentModification)
+	}
+	change.BorrowerID = 999
+	if _, err = repo.UpdateAccountStatus(change); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("UpdateAccountStatus() for unknown borrower error = %v, wantErr %v", err, repository.ErrNotFound)
+	}
+	if changes, _ = repo.GetStatusChanges(borrowerID); len(changes) != 1 {
+		t.Errorf("GetStatusChanges() after failed updates got %v changes, want 1", len(changes))
+	}
+}
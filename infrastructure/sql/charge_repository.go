@@ -0,0 +1,212 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+)
+
+const chargeColumns = `charge_id, loan_id, schedule_id, charge_type, amount, waived_amount, capitalized_amount,
+	assessed_date`
+
+type ChargeRepository struct {
+	db *sql.DB
+}
+
+var _ repository.ChargeRepository = (*ChargeRepository)(nil)
+
+func NewChargeRepository(client *DbClient) *ChargeRepository {
+	return &ChargeRepository{
+		db: client.DB,
+	}
+}
+
+func (r *ChargeRepository) GetByID(chargeID int) (entity.Charge, error) {
+	row := r.db.QueryRow(`SELECT `+chargeColumns+` FROM charges WHERE charge_id = ?`, chargeID)
+
+	charge, err := scanCharge(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Charge{}, repository.ErrNotFound
+	}
+	return charge, err
+}
+
+func (r *ChargeRepository) GetByLoanID(loanID int) ([]entity.Charge, error) {
+	rows, err := r.db.Query(`
+		SELECT `+chargeColumns+`
+		FROM charges
+		WHERE loan_id = ?
+		ORDER BY assessed_date, charge_id`,
+		loanID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var charges []entity.Charge
+	for rows.Next() {
+		charge, err := scanCharge(rows)
+		if err != nil {
+			return nil, err
+		}
+		charges = append(charges, charge)
+	}
+
+	return charges, rows.Err()
+}
+
+// CreateChargesAndUpdateLoanSchedules records the charges together with the
+// schedules they were added to in a single transaction.
+func (r *ChargeRepository) CreateChargesAndUpdateLoanSchedules(
+	charges []entity.Charge,
+	loanSchedules []entity.LoanSchedule,
+) ([]int, error) {
+	var chargeIDs []int
+	err := withTx(r.db, func(tx *sql.Tx) error {
+		for _, charge := range charges {
+			result, err := tx.Exec(`
+				INSERT INTO charges (loan_id, schedule_id, charge_type, amount, waived_amount, assessed_date)
+				VALUES (?, ?, ?, ?, ?, ?)`,
+				charge.LoanID,
+				charge.ScheduleID,
+				charge.ChargeType,
+				charge.Amount,
+				charge.WaivedAmount,
+				charge.AssessedDate,
+			)
+			if err != nil {
+				return err
+			}
+
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			chargeIDs = append(chargeIDs, int(id))
+		}
+
+		for _, schedule := range loanSchedules {
+			if err := updateLoanSchedule(tx, schedule); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return chargeIDs, nil
+}
+
+func (r *ChargeRepository) GetAdjustmentsByLoanID(loanID int) ([]entity.ChargeAdjustment, error) {
+	rows, err := r.db.Query(`
+		SELECT a.adjustment_id, a.charge_id, a.adjustment_type, a.amount, a.adjustment_date, a.reason
+		FROM charge_adjustments a
+		JOIN charges c ON c.charge_id = a.charge_id
+		WHERE c.loan_id = ?
+		ORDER BY a.adjustment_date, a.adjustment_id`,
+		loanID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var adjustments []entity.ChargeAdjustment
+	for rows.Next() {
+		var adjustment entity.ChargeAdjustment
+		err = rows.Scan(
+			&adjustment.AdjustmentID,
+			&adjustment.ChargeID,
+			&adjustment.AdjustmentType,
+			&adjustment.Amount,
+			&adjustment.AdjustmentDate,
+			&adjustment.Reason,
+		)
+		if err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+
+	return adjustments, rows.Err()
+}
+
+// CreateAdjustmentAndUpdateLoanSchedule records the adjustment and writes back
+// the charge, its schedule and the loan in a single transaction. The charge is
+// only updated if nothing else adjusted it since it was read, i.e. its stored
+// waived amount is still the one the adjustment was made against.
+func (r *ChargeRepository) CreateAdjustmentAndUpdateLoanSchedule(
+	adjustment entity.ChargeAdjustment,
+	charge entity.Charge,
+	loanSchedule entity.LoanSchedule,
+	loan entity.Loan,
+) (int, error) {
+	var adjustmentID int64
+	err := withTx(r.db, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`
+			UPDATE charges SET waived_amount = ? WHERE charge_id = ? AND waived_amount = ?`,
+			charge.WaivedAmount,
+			charge.ChargeID,
+			charge.WaivedAmount.Sub(adjustment.Amount),
+		)
+		if err != nil {
+			return err
+		}
+		err = expectAffected(result)
+		if errors.Is(err, repository.ErrNotFound) {
+			return repository.ErrConcurrentModification
+		}
+		if err != nil {
+			return err
+		}
+
+		result, err = tx.Exec(`
+			INSERT INTO charge_adjustments (charge_id, adjustment_type, amount, adjustment_date, reason)
+			VALUES (?, ?, ?, ?, ?)`,
+			adjustment.ChargeID,
+			adjustment.AdjustmentType,
+			adjustment.Amount,
+			adjustment.AdjustmentDate,
+			adjustment.Reason,
+		)
+		if err != nil {
+			return err
+		}
+
+		if adjustmentID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+
+		if err = updateLoanSchedule(tx, loanSchedule); err != nil {
+			return err
+		}
+
+		return updateLoan(tx, loan)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(adjustmentID), nil
+}
+
+func scanCharge(row scanner) (entity.Charge, error) {
+	var charge entity.Charge
+	err := row.Scan(
+		&charge.ChargeID,
+		&charge.LoanID,
+		&charge.ScheduleID,
+		&charge.ChargeType,
+		&charge.Amount,
+		&charge.WaivedAmount,
+		&charge.CapitalizedAmount,
+		&charge.AssessedDate,
+	)
+	return charge, err
+}
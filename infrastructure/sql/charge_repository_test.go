@@ -0,0 +1,97 @@
+package sql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+)
+
+func TestChargeRepository(t *testing.T) {
+	dbClient := newTestDbClient(t)
+	scheduleRepo := NewLoanScheduleRepository(dbClient)
+	repo := NewChargeRepository(dbClient)
+	loan, schedules := createTestLoanWithSchedules(t, dbClient)
+
+	charged := schedules[0]
+	charged.PaymentStatus = entity.PaymentStatusOverdue
+	charged.Charge(money.New(5000))
+	charge := entity.Charge{
+		LoanID:       loan.LoanID,
+		ScheduleID:   charged.ScheduleID,
+		ChargeType:   entity.ChargeTypeLatePayment,
+		Amount:       money.New(5000),
+		AssessedDate: time.Date(2024, time.October, 15, 0, 0, 0, 0, time.UTC),
+	}
+	chargeIDs, err := repo.CreateChargesAndUpdateLoanSchedules([]entity.Charge{charge}, []entity.LoanSchedule{charged})
+	if err != nil {
+		t.Fatalf("CreateChargesAndUpdateLoanSchedules() error = %v", err)
+	}
+	charge.ChargeID = chargeIDs[0]
+
+	got, err := repo.GetByID(charge.ChargeID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got != charge {
+		t.Errorf("GetByID() got = %+v, want %+v", got, charge)
+	}
+
+	gotSchedules, err := scheduleRepo.GetByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	charged.Version++
+	if !reflect.DeepEqual(gotSchedules[0], charged) {
+		t.Errorf("schedule after charge got = %+v, want %+v", gotSchedules[0], charged)
+	}
+
+	// the schedule was written since it was read, so nothing is recorded
+	if _, err = repo.CreateChargesAndUpdateLoanSchedules([]entity.Charge{charge}, []entity.LoanSchedule{schedules[0]}); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Errorf("CreateChargesAndUpdateLoanSchedules() with stale schedule error = %v, wantErr %v", err, repository.ErrConcurrentModification)
+	}
+
+	waiver := entity.ChargeAdjustment{
+		ChargeID:       charge.ChargeID,
+		AdjustmentType: entity.AdjustmentTypeWaiver,
+		Amount:         money.New(2000),
+		AdjustmentDate: time.Date(2024, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Reason:         "first late payment",
+	}
+	waived := charge
+	waived.WaivedAmount = money.New(2000)
+	charged.Waive(money.New(2000))
+	if waiver.AdjustmentID, err = repo.CreateAdjustmentAndUpdateLoanSchedule(waiver, waived, charged, loan); err != nil {
+		t.Fatalf("CreateAdjustmentAndUpdateLoanSchedule() error = %v", err)
+	}
+
+	charges, err := repo.GetByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	if !reflect.DeepEqual(charges, []entity.Charge{waived}) {
+		t.Errorf("GetByLoanID() got = %+v, want %+v", charges, []entity.Charge{waived})
+	}
+
+	adjustments, err := repo.GetAdjustmentsByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetAdjustmentsByLoanID() error = %v", err)
+	}
+	if !reflect.DeepEqual(adjustments, []entity.ChargeAdjustment{waiver}) {
+		t.Errorf("GetAdjustmentsByLoanID() got = %+v, want %+v", adjustments, []entity.ChargeAdjustment{waiver})
+	}
+
+	// a waiver made against the charge as it was before the first one
+	charged.Version++
+	if _, err = repo.CreateAdjustmentAndUpdateLoanSchedule(waiver, waived, charged, loan); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Errorf("CreateAdjustmentAndUpdateLoanSchedule() with stale charge error = %v, wantErr %v", err, repository.ErrConcurrentModification)
+	}
+
+	if _, err = repo.GetByID(999); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetByID() error = %v, wantErr %v", err, repository.ErrNotFound)
+	}
+}
@@ -12,10 +12,13 @@ type DbClient struct {
 }
 
 func NewSQLite3Client() *DbClient {
-	db, err := sql.Open("sqlite3", ":memory:")
+	db, err := sql.Open("sqlite3", ":memory:?_foreign_keys=on")
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
+	// every connection to :memory: opens its own empty database, so the pool
+	// must be pinned to a single connection for the tables to be shared.
+	db.SetMaxOpenConns(1)
 	return &DbClient{
 		DB: db,
 	}
@@ -33,14 +36,36 @@ func (c *DbClient) CreateTables() {
 	  date_of_birth DATE,
 	  account_status TEXT CHECK(account_status IN ('active', 'delinquent', 'closed'))
 	);
+	CREATE TABLE borrower_status_changes (
+	  change_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	  borrower_id INTEGER,
+	  from_status TEXT,
+	  to_status TEXT CHECK(to_status IN ('active', 'delinquent', 'closed')),
+	  changed_date DATE,
+	  reason TEXT
+	);
 	CREATE TABLE loans (
 	  loan_id INTEGER PRIMARY KEY AUTOINCREMENT,
 	  borrower_id INTEGER,
+	  product_code TEXT NOT NULL,
 	  loan_amount DECIMAL(15, 2),
 	  interest_rate DECIMAL(5, 2),
 	  loan_start_date DATE,
 	  loan_end_date DATE,
-	  loan_status TEXT CHECK(loan_status IN ('active', 'paid'))
+	  loan_status TEXT CHECK(loan_status IN ('applied', 'approved', 'rejected', 'active', 'paid', 'defaulted', 'written_off', 'cancelled')),
+	  tenor INTEGER,
+	  closed_date DATE,
+	  credit_balance DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  amortization_method TEXT NOT NULL DEFAULT 'flat',
+	  installment_step DECIMAL(5, 2) NOT NULL DEFAULT 0,
+	  repayment_frequency TEXT NOT NULL DEFAULT 'weekly',
+	  anchor_day INTEGER NOT NULL DEFAULT 0,
+	  restructured BOOLEAN NOT NULL DEFAULT 0,
+	  day_count TEXT NOT NULL DEFAULT '',
+	  rounding_unit DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  rounding_mode INTEGER NOT NULL DEFAULT 0,
+	  rounding_residual TEXT NOT NULL DEFAULT '',
+	  version INTEGER NOT NULL DEFAULT 0
 	);
 	CREATE TABLE loan_schedule (
 	  schedule_id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -48,22 +73,105 @@ func (c *DbClient) CreateTables() {
 	  due_date DATE,
 	  principal_amount DECIMAL(15, 2),
 	  interest_amount DECIMAL(15, 2),
+	  fee_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
 	  total_due DECIMAL(15, 2),
-	  payment_status TEXT CHECK(payment_status IN ('unspecified', 'due', 'paid', 'overdue'))
+	  principal_paid DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  interest_paid DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  fee_paid DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  reserved_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  payment_status TEXT CHECK(payment_status IN ('unspecified', 'due', 'partially_paid', 'paid', 'overdue')),
+	  version INTEGER NOT NULL DEFAULT 0,
+	  superseded_by INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE loan_restructures (
+	  restructure_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	  loan_id INTEGER,
+	  effective_date DATE,
+	  tenor INTEGER,
+	  interest_rate DECIMAL(5, 2),
+	  capitalized_arrears DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  restructured_date DATE
 	);
+	CREATE TABLE payment_holidays (
+	  holiday_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	  loan_id INTEGER,
+	  start_date DATE,
+	  installments INTEGER,
+	  extra_interest DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  approved_by TEXT,
+	  reason TEXT,
+	  granted_date DATE
+	);
+	CREATE TABLE payment_methods (
+	  code TEXT PRIMARY KEY
+	);
+	INSERT INTO payment_methods (code) VALUES ('credit_balance');
 	CREATE TABLE payments (
 	  payment_id INTEGER PRIMARY KEY AUTOINCREMENT,
 	  loan_id INTEGER,
 	  payment_date DATE,
 	  amount_paid DECIMAL(15, 2),
-	  payment_method TEXT CHECK(payment_method IN ('bank_transfer')),
-	  status TEXT CHECK(status IN ('completed'))
+	  payment_method TEXT REFERENCES payment_methods (code),
+	  fee_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  status TEXT CHECK(status IN ('pending', 'completed', 'failed', 'reversed')),
+	  expected_settlement_date DATE,
+	  failure_reason TEXT NOT NULL DEFAULT '',
+	  idempotency_key TEXT UNIQUE
+	);
+	CREATE TABLE payment_allocations (
+	  allocation_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	  payment_id INTEGER,
+	  schedule_id INTEGER,
+	  principal_amount DECIMAL(15, 2),
+	  interest_amount DECIMAL(15, 2),
+	  fee_amount DECIMAL(15, 2),
+	  interest_rebate DECIMAL(15, 2) NOT NULL DEFAULT 0
+	);
+	CREATE TABLE payment_reversals (
+	  reversal_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	  payment_id INTEGER UNIQUE,
+	  reversal_date DATE,
+	  reason TEXT
+	);
+	CREATE TABLE credit_refunds (
+	  refund_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	  loan_id INTEGER,
+	  refund_date DATE,
+	  amount DECIMAL(15, 2)
+	);
+	CREATE TABLE charges (
+	  charge_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	  loan_id INTEGER,
+	  schedule_id INTEGER,
+	  charge_type TEXT CHECK(charge_type IN ('late_payment')),
+	  amount DECIMAL(15, 2),
+	  waived_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  capitalized_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+	  assessed_date DATE
+	);
+	CREATE TABLE charge_adjustments (
+	  adjustment_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	  charge_id INTEGER,
+	  adjustment_type TEXT CHECK(adjustment_type IN ('waiver')),
+	  amount DECIMAL(15, 2),
+	  adjustment_date DATE,
+	  reason TEXT
 	);`
 	if _, err := c.DB.Exec(createTableSQL); err != nil {
 		log.Fatalf("Failed to create table: %v", err)
 	}
 }
 
+// SyncPaymentMethods registers the payment method codes payments may
+// reference. Codes that are already registered are left alone.
+func (c *DbClient) SyncPaymentMethods(codes []string) {
+	for _, code := range codes {
+		if _, err := c.DB.Exec(`INSERT OR IGNORE INTO payment_methods (code) VALUES (?)`, code); err != nil {
+			log.Fatalf("Failed to register payment method %s: %v", code, err)
+		}
+	}
+}
+
 func (c *DbClient) Close() {
 	if err := c.DB.Close(); err != nil {
 		log.Fatalf("Failed to close db: %v", err)
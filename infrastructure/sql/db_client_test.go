@@ -0,0 +1,18 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/iqbalbachmid/billing-engine/domain/paymentmethod"
+)
+
+func newTestDbClient(t *testing.T) *DbClient {
+	t.Helper()
+
+	dbClient := NewSQLite3Client()
+	dbClient.CreateTables()
+	dbClient.SyncPaymentMethods(paymentmethod.Default().Codes())
+	t.Cleanup(dbClient.Close)
+
+	return dbClient
+}
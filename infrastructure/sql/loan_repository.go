@@ -0,0 +1,229 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+)
+
+const loanColumns = `loan_id, borrower_id, product_code, loan_amount, interest_rate, loan_start_date, loan_end_date, loan_status, tenor, closed_date, credit_balance, version, restructured, day_count, rounding_unit, rounding_mode, rounding_residual, amortization_method, installment_step, repayment_frequency, anchor_day`
+
+type LoanRepository struct {
+	db *sql.DB
+}
+
+var _ repository.LoanRepository = (*LoanRepository)(nil)
+
+func NewLoanRepository(client *DbClient) *LoanRepository {
+	return &LoanRepository{
+		db: client.DB,
+	}
+}
+
+func (r *LoanRepository) GetByID(id int) (entity.Loan, error) {
+	row := r.db.QueryRow(`SELECT `+loanColumns+` FROM loans WHERE loan_id = ?`, id)
+
+	loan, err := scanLoan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Loan{}, repository.ErrNotFound
+	}
+	return loan, err
+}
+
+func (r *LoanRepository) GetAll() ([]entity.Loan, error) {
+	rows, err := r.db.Query(`SELECT ` + loanColumns + ` FROM loans ORDER BY loan_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loans []entity.Loan
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
+		}
+		loans = append(loans, loan)
+	}
+
+	return loans, rows.Err()
+}
+
+func (r *LoanRepository) GetByBorrowerID(borrowerID int) ([]entity.Loan, error) {
+	rows, err := r.db.Query(`SELECT `+loanColumns+` FROM loans WHERE borrower_id = ? ORDER BY loan_id`, borrowerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loans []entity.Loan
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
+		}
+		loans = append(loans, loan)
+	}
+
+	return loans, rows.Err()
+}
+
+func (r *LoanRepository) Create(loan entity.Loan) (int, error) {
+	result, err := r.db.Exec(`
+		INSERT INTO loans (borrower_id, product_code, loan_amount, interest_rate, loan_start_date, loan_end_date,
+		                   loan_status, tenor, closed_date, credit_balance, restructured, day_count,
+		                   rounding_unit, rounding_mode, rounding_residual,
+		                   amortization_method, installment_step, repayment_frequency, anchor_day)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		loan.BorrowerID,
+		loan.ProductCode,
+		loan.LoanAmount,
+		loan.InterestRate,
+		loan.LoanStartDate,
+		loan.LoanEndDate,
+		loan.LoanStatus,
+		loan.Tenor,
+		loan.ClosedDate,
+		loan.CreditBalance,
+		loan.Restructured,
+		loan.DayCount,
+		loan.Rounding.Unit,
+		loan.Rounding.Mode,
+		loan.Rounding.Residual,
+		loan.Amortization.Method,
+		loan.Amortization.Step,
+		loan.Repayment.Frequency,
+		loan.Repayment.AnchorDay,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// Update writes the loan back only if its version still matches the one that
+// was read, returning repository.ErrConcurrentModification otherwise.
+func (r *LoanRepository) Update(loan entity.Loan) error {
+	return updateLoan(r.db, loan)
+}
+
+func (r *LoanRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM loans WHERE loan_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
+}
+
+func (r *LoanRepository) GetClosedBetween(from, to time.Time) ([]entity.Loan, error) {
+	rows, err := r.db.Query(`
+		SELECT `+loanColumns+`
+		FROM loans
+		WHERE date(closed_date) BETWEEN date(?) AND date(?)
+		ORDER BY closed_date, loan_id`,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loans []entity.Loan
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
+		}
+		loans = append(loans, loan)
+	}
+
+	return loans, rows.Err()
+}
+
+func updateLoan(db execer, loan entity.Loan) error {
+	result, err := db.Exec(`
+		UPDATE loans
+		SET borrower_id = ?, product_code = ?, loan_amount = ?, interest_rate = ?, loan_start_date = ?, loan_end_date = ?,
+		    loan_status = ?, tenor = ?, closed_date = ?, credit_balance = ?, restructured = ?, day_count = ?,
+		    rounding_unit = ?, rounding_mode = ?, rounding_residual = ?,
+		    amortization_method = ?, installment_step = ?, repayment_frequency = ?, anchor_day = ?,
+		    version = version + 1
+		WHERE loan_id = ? AND version = ?`,
+		loan.BorrowerID,
+		loan.ProductCode,
+		loan.LoanAmount,
+		loan.InterestRate,
+		loan.LoanStartDate,
+		loan.LoanEndDate,
+		loan.LoanStatus,
+		loan.Tenor,
+		loan.ClosedDate,
+		loan.CreditBalance,
+		loan.Restructured,
+		loan.DayCount,
+		loan.Rounding.Unit,
+		loan.Rounding.Mode,
+		loan.Rounding.Residual,
+		loan.Amortization.Method,
+		loan.Amortization.Step,
+		loan.Repayment.Frequency,
+		loan.Repayment.AnchorDay,
+		loan.LoanID,
+		loan.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err = db.QueryRow(`SELECT EXISTS (SELECT 1 FROM loans WHERE loan_id = ?)`, loan.LoanID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return repository.ErrNotFound
+	}
+	return repository.ErrConcurrentModification
+}
+
+func scanLoan(row scanner) (entity.Loan, error) {
+	var loan entity.Loan
+	err := row.Scan(
+		&loan.LoanID,
+		&loan.BorrowerID,
+		&loan.ProductCode,
+		&loan.LoanAmount,
+		&loan.InterestRate,
+		&loan.LoanStartDate,
+		&loan.LoanEndDate,
+		&loan.LoanStatus,
+		&loan.Tenor,
+		&loan.ClosedDate,
+		&loan.CreditBalance,
+		&loan.Version,
+		&loan.Restructured,
+		&loan.DayCount,
+		&loan.Rounding.Unit,
+		&loan.Rounding.Mode,
+		&loan.Rounding.Residual,
+		&loan.Amortization.Method,
+		&loan.Amortization.Step,
+		&loan.Repayment.Frequency,
+		&loan.Repayment.AnchorDay,
+	)
+	return loan, err
+}
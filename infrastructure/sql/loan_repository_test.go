@@ -0,0 +1,157 @@
+package sql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/daycount"
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	"github.com/iqbalbachmid/billing-engine/domain/rounding"
+)
+
+func TestLoanRepository(t *testing.T) {
+	repo := NewLoanRepository(newTestDbClient(t))
+
+	loan := entity.Loan{
+		BorrowerID:    1,
+		ProductCode:   "standard",
+		LoanAmount:    money.New(5000000),
+		InterestRate:  10,
+		LoanStartDate: time.Date(2024, time.October, 7, 0, 0, 0, 0, time.UTC),
+		LoanEndDate:   time.Date(2025, time.September, 22, 0, 0, 0, 0, time.UTC),
+		LoanStatus:    "active",
+		Tenor:         50,
+		DayCount:      daycount.Thirty360,
+		Rounding:      rounding.Policy{Unit: money.New(100), Mode: money.RoundHalfEven, Residual: rounding.Spread},
+		Amortization:  entity.Amortization{Method: entity.AmortizationStepped, Step: -2.5},
+		Repayment:     entity.Repayment{Frequency: entity.FrequencyMonthly, AnchorDay: 31},
+	}
+
+	id, err := repo.Create(loan)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	loan.LoanID = id
+
+	got, err := repo.GetByID(id)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, loan) {
+		t.Errorf("GetByID() got = %+v, want %+v", got, loan)
+	}
+
+	loan.LoanStatus = "paid"
+	loan.CreditBalance = money.MustParse("12500.75")
+	loan.Restructured = true
+	loan.Amortization = entity.Amortization{Method: entity.AmortizationAnnuity}
+	if err = repo.Update(loan); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	loan.Version++
+
+	stale := loan
+	stale.Version--
+	if err = repo.Update(stale); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Errorf("Update() with a stale version error = %v, want %v", err, repository.ErrConcurrentModification)
+	}
+
+	all, err := repo.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if !reflect.DeepEqual(all, []entity.Loan{loan}) {
+		t.Errorf("GetAll() got = %+v, want %+v", all, []entity.Loan{loan})
+	}
+
+	if err = repo.Delete(id); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err = repo.GetByID(id); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetByID() after delete error = %v, want %v", err, repository.ErrNotFound)
+	}
+	if err = repo.Delete(id); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Delete() after delete error = %v, want %v", err, repository.ErrNotFound)
+	}
+}
+
+func TestLoanRepository_CheckConstraint(t *testing.T) {
+	repo := NewLoanRepository(newTestDbClient(t))
+
+	if _, err := repo.Create(entity.Loan{LoanStatus: "unknown"}); err == nil {
+		t.Errorf("Create() with invalid loan_status should return error")
+	}
+}
+
+func TestLoanRepository_GetClosedBetween(t *testing.T) {
+	repo := NewLoanRepository(newTestDbClient(t))
+
+	var loans []entity.Loan
+	for _, closedDay := range []int{0, 5, 20, 28} {
+		loan := entity.Loan{
+			BorrowerID:    1,
+			LoanAmount:    money.New(1000000),
+			LoanStartDate: time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC),
+			LoanStatus:    entity.LoanStatusActive,
+			Tenor:         4,
+		}
+		if closedDay > 0 {
+			loan.Close(time.Date(2024, time.October, closedDay, 0, 0, 0, 0, time.UTC))
+		}
+
+		id, err := repo.Create(loan)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		loan.LoanID = id
+		loans = append(loans, loan)
+	}
+
+	got, err := repo.GetClosedBetween(
+		time.Date(2024, time.October, 5, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.October, 20, 0, 0, 0, 0, time.UTC),
+	)
+	if err != nil {
+		t.Fatalf("GetClosedBetween() error = %v", err)
+	}
+	want := []entity.Loan{loans[1], loans[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetClosedBetween() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoanRepository_GetByBorrowerID(t *testing.T) {
+	repo := NewLoanRepository(newTestDbClient(t))
+
+	var want []entity.Loan
+	for _, borrowerID := range []int{1, 2, 1} {
+		loan := entity.Loan{
+			BorrowerID:    borrowerID,
+			ProductCode:   "standard",
+			LoanAmount:    money.New(1000000),
+			LoanStartDate: time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC),
+			LoanEndDate:   time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+			LoanStatus:    entity.LoanStatusActive,
+		}
+		id, err := repo.Create(loan)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		loan.LoanID = id
+		if borrowerID == 1 {
+			want = append(want, loan)
+		}
+	}
+
+	got, err := repo.GetByBorrowerID(1)
+	if err != nil {
+		t.Fatalf("GetByBorrowerID() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetByBorrowerID() got = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,392 @@
+package sql
+
+import (
+	"database/sql"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+)
+
+const loanScheduleColumns = `schedule_id, loan_id, due_date, principal_amount, interest_amount, fee_amount, total_due,
+	principal_paid, interest_paid, fee_paid, reserved_amount, payment_status, version, superseded_by`
+
+type LoanScheduleRepository struct {
+	db *sql.DB
+}
+
+var _ repository.LoanScheduleRepository = (*LoanScheduleRepository)(nil)
+
+func NewLoanScheduleRepository(client *DbClient) *LoanScheduleRepository {
+	return &LoanScheduleRepository{
+		db: client.DB,
+	}
+}
+
+func (r *LoanScheduleRepository) GetByLoanID(loanID int) ([]entity.LoanSchedule, error) {
+	rows, err := r.db.Query(`
+		SELECT `+loanScheduleColumns+`
+		FROM loan_schedule
+		WHERE loan_id = ? AND superseded_by = 0
+		ORDER BY due_date, schedule_id`,
+		loanID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []entity.LoanSchedule
+	for rows.Next() {
+		schedule, err := scanLoanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+func (r *LoanScheduleRepository) Create(schedule entity.LoanSchedule) (int, error) {
+	return createLoanSchedule(r.db, schedule)
+}
+
+// Update writes the schedule back only if its version still matches the one
+// that was read, returning repository.ErrConcurrentModification otherwise.
+func (r *LoanScheduleRepository) Update(schedule entity.LoanSchedule) error {
+	return updateLoanSchedule(r.db, schedule)
+}
+
+func (r *LoanScheduleRepository) GetRestructuresByLoanID(loanID int) ([]entity.LoanRestructure, error) {
+	rows, err := r.db.Query(`
+		SELECT restructure_id, loan_id, effective_date, tenor, interest_rate, capitalized_arrears, restructured_date
+		FROM loan_restructures
+		WHERE loan_id = ?
+		ORDER BY restructure_id`,
+		loanID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var restructures []entity.LoanRestructure
+	for rows.Next() {
+		var restructure entity.LoanRestructure
+		if err = rows.Scan(
+			&restructure.RestructureID,
+			&restructure.LoanID,
+			&restructure.EffectiveDate,
+			&restructure.Tenor,
+			&restructure.InterestRate,
+			&restructure.CapitalizedArrears,
+			&restructure.RestructuredDate,
+		); err != nil {
+			return nil, err
+		}
+		restructures = append(restructures, restructure)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	superseded, err := r.getSupersededByLoanID(loanID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range restructures {
+		restructures[i].Superseded = superseded[restructures[i].RestructureID]
+	}
+
+	return restructures, nil
+}
+
+func (r *LoanScheduleRepository) getSupersededByLoanID(loanID int) (map[int][]entity.LoanSchedule, error) {
+	rows, err := r.db.Query(`
+		SELECT `+loanScheduleColumns+`
+		FROM loan_schedule
+		WHERE loan_id = ? AND superseded_by <> 0
+		ORDER BY due_date, schedule_id`,
+		loanID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	superseded := make(map[int][]entity.LoanSchedule)
+	for rows.Next() {
+		schedule, err := scanLoanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		superseded[schedule.SupersededBy] = append(superseded[schedule.SupersededBy], schedule)
+	}
+
+	return superseded, rows.Err()
+}
+
+// CreateLoanSchedulesAndUpdateLoan creates the schedules and writes back the
+// loan in a single transaction. Nothing is persisted if the loan is missing or
+// was modified since it was read.
+func (r *LoanScheduleRepository) CreateLoanSchedulesAndUpdateLoan(loanSchedules []entity.LoanSchedule, loan entity.Loan) error {
+	return withTx(r.db, func(tx *sql.Tx) error {
+		for _, schedule := range loanSchedules {
+			if _, err := createLoanSchedule(tx, schedule); err != nil {
+				return err
+			}
+		}
+
+		return updateLoan(tx, loan)
+	})
+}
+
+// CreateRestructure inserts the restructure, marks the schedules it
+// supersedes, creates their replacements, moves the charges onto the first of
+// them with what of each was capitalized and writes back the loan in a single
+// transaction. Nothing is persisted
+// if a superseded schedule is missing or was modified since it was read.
+func (r *LoanScheduleRepository) CreateRestructure(
+	restructure entity.LoanRestructure,
+	loanSchedules []entity.LoanSchedule,
+	charges []entity.Charge,
+	loan entity.Loan,
+) (int, error) {
+	var restructureID int64
+	err := withTx(r.db, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`
+			INSERT INTO loan_restructures (loan_id, effective_date, tenor, interest_rate, capitalized_arrears,
+			                               restructured_date)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			restructure.LoanID,
+			restructure.EffectiveDate,
+			restructure.Tenor,
+			restructure.InterestRate,
+			restructure.CapitalizedArrears,
+			restructure.RestructuredDate,
+		)
+		if err != nil {
+			return err
+		}
+
+		if restructureID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+
+		for _, schedule := range restructure.Superseded {
+			schedule.SupersededBy = int(restructureID)
+			if err = updateLoanSchedule(tx, schedule); err != nil {
+				return err
+			}
+		}
+
+		var firstID int
+		for i, schedule := range loanSchedules {
+			scheduleID, err := createLoanSchedule(tx, schedule)
+			if err != nil {
+				return err
+			}
+			if i == 0 {
+				firstID = scheduleID
+			}
+		}
+
+		for _, charge := range charges {
+			result, err := tx.Exec(`
+				UPDATE charges SET schedule_id = ?, capitalized_amount = ? WHERE charge_id = ?`,
+				firstID,
+				charge.CapitalizedAmount,
+				charge.ChargeID,
+			)
+			if err != nil {
+				return err
+			}
+			if err = expectAffected(result); err != nil {
+				return err
+			}
+		}
+
+		return updateLoan(tx, loan)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(restructureID), nil
+}
+
+func (r *LoanScheduleRepository) GetPaymentHolidaysByLoanID(loanID int) ([]entity.PaymentHoliday, error) {
+	rows, err := r.db.Query(`
+		SELECT holiday_id, loan_id, start_date, installments, extra_interest, approved_by, reason, granted_date
+		FROM payment_holidays
+		WHERE loan_id = ?
+		ORDER BY holiday_id`,
+		loanID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holidays []entity.PaymentHoliday
+	for rows.Next() {
+		var holiday entity.PaymentHoliday
+		if err = rows.Scan(
+			&holiday.HolidayID,
+			&holiday.LoanID,
+			&holiday.StartDate,
+			&holiday.Installments,
+			&holiday.ExtraInterest,
+			&holiday.ApprovedBy,
+			&holiday.Reason,
+			&holiday.GrantedDate,
+		); err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, holiday)
+	}
+
+	return holidays, rows.Err()
+}
+
+// CreatePaymentHoliday inserts the holiday and writes back the deferred
+// schedules and the loan in a single transaction. Nothing is persisted if a
+// schedule is missing or was modified since it was read.
+func (r *LoanScheduleRepository) CreatePaymentHoliday(
+	holiday entity.PaymentHoliday,
+	loanSchedules []entity.LoanSchedule,
+	loan entity.Loan,
+) (int, error) {
+	var holidayID int64
+	err := withTx(r.db, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`
+			INSERT INTO payment_holidays (loan_id, start_date, installments, extra_interest, approved_by, reason,
+			                              granted_date)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			holiday.LoanID,
+			holiday.StartDate,
+			holiday.Installments,
+			holiday.ExtraInterest,
+			holiday.ApprovedBy,
+			holiday.Reason,
+			holiday.GrantedDate,
+		)
+		if err != nil {
+			return err
+		}
+
+		if holidayID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+
+		for _, schedule := range loanSchedules {
+			if err = updateLoanSchedule(tx, schedule); err != nil {
+				return err
+			}
+		}
+
+		return updateLoan(tx, loan)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(holidayID), nil
+}
+
+func createLoanSchedule(db execer, schedule entity.LoanSchedule) (int, error) {
+	result, err := db.Exec(`
+		INSERT INTO loan_schedule (loan_id, due_date, principal_amount, interest_amount, fee_amount, total_due,
+		                           principal_paid, interest_paid, fee_paid, reserved_amount, payment_status, version,
+		                           superseded_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		schedule.LoanID,
+		schedule.DueDate,
+		schedule.PrincipalAmount,
+		schedule.InterestAmount,
+		schedule.FeeAmount,
+		schedule.TotalDue,
+		schedule.PrincipalPaid,
+		schedule.InterestPaid,
+		schedule.FeePaid,
+		schedule.ReservedAmount,
+		schedule.PaymentStatus,
+		schedule.Version,
+		schedule.SupersededBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func updateLoanSchedule(db execer, schedule entity.LoanSchedule) error {
+	result, err := db.Exec(`
+		UPDATE loan_schedule
+		SET loan_id = ?, due_date = ?, principal_amount = ?, interest_amount = ?, fee_amount = ?, total_due = ?,
+		    principal_paid = ?, interest_paid = ?, fee_paid = ?, reserved_amount = ?, payment_status = ?,
+		    superseded_by = ?, version = version + 1
+		WHERE schedule_id = ? AND version = ?`,
+		schedule.LoanID,
+		schedule.DueDate,
+		schedule.PrincipalAmount,
+		schedule.InterestAmount,
+		schedule.FeeAmount,
+		schedule.TotalDue,
+		schedule.PrincipalPaid,
+		schedule.InterestPaid,
+		schedule.FeePaid,
+		schedule.ReservedAmount,
+		schedule.PaymentStatus,
+		schedule.SupersededBy,
+		schedule.ScheduleID,
+		schedule.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err = db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM loan_schedule WHERE schedule_id = ?)`,
+		schedule.ScheduleID,
+	).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return repository.ErrNotFound
+	}
+	return repository.ErrConcurrentModification
+}
+
+func scanLoanSchedule(row scanner) (entity.LoanSchedule, error) {
+	var schedule entity.LoanSchedule
+	err := row.Scan(
+		&schedule.ScheduleID,
+		&schedule.LoanID,
+		&schedule.DueDate,
+		&schedule.PrincipalAmount,
+		&schedule.InterestAmount,
+		&schedule.FeeAmount,
+		&schedule.TotalDue,
+		&schedule.PrincipalPaid,
+		&schedule.InterestPaid,
+		&schedule.FeePaid,
+		&schedule.ReservedAmount,
+		&schedule.PaymentStatus,
+		&schedule.Version,
+		&schedule.SupersededBy,
+	)
+	return schedule, err
+}
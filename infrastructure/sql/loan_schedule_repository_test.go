@@ -0,0 +1,332 @@
+package sql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+)
+
+func TestLoanScheduleRepository(t *testing.T) {
+	repo := NewLoanScheduleRepository(newTestDbClient(t))
+
+	schedules := []entity.LoanSchedule{
+		{
+			LoanID:          1,
+			DueDate:         time.Date(2024, time.October, 21, 0, 0, 0, 0, time.UTC),
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			PaymentStatus:   entity.PaymentStatusUnspecified,
+		},
+		{
+			LoanID:          1,
+			DueDate:         time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC),
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			PaymentStatus:   entity.PaymentStatusDue,
+		},
+		{
+			LoanID:          2,
+			DueDate:         time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC),
+			PrincipalAmount: money.MustParse("333333.34"),
+			InterestAmount:  money.MustParse("33333.34"),
+			TotalDue:        money.MustParse("366666.68"),
+			PaymentStatus:   entity.PaymentStatusDue,
+		},
+	}
+	for i := range schedules {
+		id, err := repo.Create(schedules[i])
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		schedules[i].ScheduleID = id
+	}
+
+	got, err := repo.GetByLoanID(1)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	want := []entity.LoanSchedule{schedules[1], schedules[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetByLoanID() should be ordered by due date, got = %+v, want %+v", got, want)
+	}
+
+	schedules[1].PaymentStatus = entity.PaymentStatusOverdue
+	if err = repo.Update(schedules[1]); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got, err = repo.GetByLoanID(1)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	if got[0].PaymentStatus != entity.PaymentStatusOverdue {
+		t.Errorf("Update() payment status got = %v, want %v", got[0].PaymentStatus, entity.PaymentStatusOverdue)
+	}
+	if got[0].Version != schedules[1].Version+1 {
+		t.Errorf("Update() version got = %v, want %v", got[0].Version, schedules[1].Version+1)
+	}
+
+	if err = repo.Update(schedules[1]); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Errorf("Update() with stale version error = %v, want %v", err, repository.ErrConcurrentModification)
+	}
+	if err = repo.Update(entity.LoanSchedule{ScheduleID: 999}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Update() for unknown schedule error = %v, want %v", err, repository.ErrNotFound)
+	}
+
+	got, err = repo.GetByLoanID(2)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []entity.LoanSchedule{schedules[2]}) {
+		t.Errorf("GetByLoanID() should keep exact amounts, got = %+v, want %+v", got, []entity.LoanSchedule{schedules[2]})
+	}
+
+	got, err = repo.GetByLoanID(3)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("GetByLoanID() for unknown loan got = %+v, want empty", got)
+	}
+}
+
+func TestLoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan(t *testing.T) {
+	dbClient := newTestDbClient(t)
+	loanRepo := NewLoanRepository(dbClient)
+	repo := NewLoanScheduleRepository(dbClient)
+
+	loan := entity.Loan{
+		BorrowerID:    1,
+		LoanAmount:    money.New(200000),
+		InterestRate:  10,
+		LoanStartDate: time.Date(2024, time.October, 7, 0, 0, 0, 0, time.UTC),
+		LoanStatus:    entity.LoanStatusApproved,
+		Tenor:         2,
+	}
+	loanID, err := loanRepo.Create(loan)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	loan.LoanID = loanID
+
+	var schedules []entity.LoanSchedule
+	for week := 1; week <= 2; week++ {
+		schedules = append(schedules, entity.LoanSchedule{
+			LoanID:          loanID,
+			DueDate:         time.Date(2024, time.October, 7+7*week, 0, 0, 0, 0, time.UTC),
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			PaymentStatus:   entity.PaymentStatusUnspecified,
+		})
+	}
+
+	stale := loan
+	stale.LoanStatus = entity.LoanStatusActive
+	stale.Version = 1
+	if err = repo.CreateLoanSchedulesAndUpdateLoan(schedules, stale); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Fatalf("CreateLoanSchedulesAndUpdateLoan() with a stale loan error = %v, wantErr %v", err, repository.ErrConcurrentModification)
+	}
+	if got, err := repo.GetByLoanID(loanID); err != nil || len(got) != 0 {
+		t.Errorf("schedules should have been rolled back, got = %+v, err = %v", got, err)
+	}
+
+	loan.LoanStatus = entity.LoanStatusActive
+	loan.LoanEndDate = schedules[1].DueDate
+	if err = repo.CreateLoanSchedulesAndUpdateLoan(schedules, loan); err != nil {
+		t.Fatalf("CreateLoanSchedulesAndUpdateLoan() error = %v", err)
+	}
+	loan.Version++
+
+	got, err := repo.GetByLoanID(loanID)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	for i := range schedules {
+		schedules[i].ScheduleID = i + 1
+	}
+	if !reflect.DeepEqual(got, schedules) {
+		t.Errorf("GetByLoanID() got = %+v, want %+v", got, schedules)
+	}
+
+	gotLoan, err := loanRepo.GetByID(loanID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotLoan, loan) {
+		t.Errorf("loan after creating schedules got = %+v, want %+v", gotLoan, loan)
+	}
+}
+
+func TestLoanScheduleRepository_CreateRestructure(t *testing.T) {
+	dbClient := newTestDbClient(t)
+	repo := NewLoanScheduleRepository(dbClient)
+	loan, schedules := createTestLoanWithSchedules(t, dbClient)
+
+	schedules[0].PrincipalPaid = money.New(100000)
+	schedules[0].InterestPaid = money.New(10000)
+	schedules[0].PaymentStatus = entity.PaymentStatusPaid
+	if err := repo.Update(schedules[0]); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	schedules[0].Version++
+
+	schedules[1].Charge(money.New(5000))
+	charges := []entity.Charge{{
+		LoanID:       loan.LoanID,
+		ScheduleID:   schedules[1].ScheduleID,
+		ChargeType:   entity.ChargeTypeLatePayment,
+		Amount:       money.New(5000),
+		AssessedDate: time.Date(2024, time.October, 15, 0, 0, 0, 0, time.UTC),
+	}}
+	chargeRepo := NewChargeRepository(dbClient)
+	chargeIDs, err := chargeRepo.CreateChargesAndUpdateLoanSchedules(charges, schedules[1:2])
+	if err != nil {
+		t.Fatalf("CreateChargesAndUpdateLoanSchedules() error = %v", err)
+	}
+	charges[0].ChargeID = chargeIDs[0]
+	schedules[1].Version++
+
+	restructure := entity.LoanRestructure{
+		LoanID:           loan.LoanID,
+		EffectiveDate:    time.Date(2024, time.October, 21, 0, 0, 0, 0, time.UTC),
+		Tenor:            4,
+		InterestRate:     5,
+		RestructuredDate: time.Date(2024, time.October, 21, 0, 0, 0, 0, time.UTC),
+		Superseded:       schedules[1:],
+	}
+	var respread []entity.LoanSchedule
+	for week := 1; week <= 4; week++ {
+		respread = append(respread, entity.LoanSchedule{
+			LoanID:          loan.LoanID,
+			DueDate:         time.Date(2024, time.October, 21+7*week, 0, 0, 0, 0, time.UTC),
+			PrincipalAmount: money.New(50000),
+			InterestAmount:  money.New(2500),
+			TotalDue:        money.New(52500),
+			PaymentStatus:   entity.PaymentStatusUnspecified,
+		})
+	}
+	loan.LoanEndDate = respread[3].DueDate
+	loan.Restructured = true
+
+	// the penalty is capitalized along with the rest of the arrears
+	restructure.CapitalizedArrears = money.New(5000)
+	charges[0].CapitalizedAmount = money.New(5000)
+
+	restructureID, err := repo.CreateRestructure(restructure, respread, charges, loan)
+	if err != nil {
+		t.Fatalf("CreateRestructure() error = %v", err)
+	}
+
+	got, err := repo.GetByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	if len(got) != 5 || got[0].ScheduleID != schedules[0].ScheduleID || got[1].TotalDue != money.New(52500) {
+		t.Fatalf("GetByLoanID() should return the paid schedule and the new ones, got = %+v", got)
+	}
+
+	gotCharges, err := chargeRepo.GetByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	if len(gotCharges) != 1 || gotCharges[0].ScheduleID != got[1].ScheduleID {
+		t.Errorf("CreateRestructure() should move the charge to schedule %d, got = %+v", got[1].ScheduleID, gotCharges)
+	}
+	if len(gotCharges) == 1 && gotCharges[0].Waivable().IsPositive() {
+		t.Errorf("CreateRestructure() should record the charge as capitalized, got = %+v", gotCharges[0])
+	}
+
+	restructures, err := repo.GetRestructuresByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetRestructuresByLoanID() error = %v", err)
+	}
+	if len(restructures) != 1 || restructures[0].RestructureID != restructureID {
+		t.Fatalf("GetRestructuresByLoanID() got = %+v, want restructure %d", restructures, restructureID)
+	}
+	if restructures[0].InterestRate != 5 || restructures[0].Tenor != 4 {
+		t.Errorf("GetRestructuresByLoanID() got = %+v, want %+v", restructures[0], restructure)
+	}
+	for i, superseded := range restructures[0].Superseded {
+		want := schedules[1+i]
+		want.SupersededBy = restructureID
+		want.Version++
+		if superseded != want {
+			t.Errorf("GetRestructuresByLoanID() superseded = %+v, want %+v", superseded, want)
+		}
+	}
+
+	gotLoan, err := NewLoanRepository(dbClient).GetByID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !gotLoan.Restructured {
+		t.Errorf("CreateRestructure() should flag the loan as restructured, got = %+v", gotLoan)
+	}
+
+	// the superseded schedules were written with the versions read, so doing
+	// it again conflicts and leaves nothing behind
+	if _, err = repo.CreateRestructure(restructure, respread, nil, loan); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Errorf("CreateRestructure() with stale schedules error = %v, want %v", err, repository.ErrConcurrentModification)
+	}
+	if restructures, _ = repo.GetRestructuresByLoanID(loan.LoanID); len(restructures) != 1 {
+		t.Errorf("CreateRestructure() should roll back, got %d restructures", len(restructures))
+	}
+}
+
+func TestLoanScheduleRepository_CreatePaymentHoliday(t *testing.T) {
+	dbClient := newTestDbClient(t)
+	repo := NewLoanScheduleRepository(dbClient)
+	loan, schedules := createTestLoanWithSchedules(t, dbClient)
+
+	holiday := entity.PaymentHoliday{
+		LoanID:       loan.LoanID,
+		StartDate:    time.Date(2024, time.October, 21, 0, 0, 0, 0, time.UTC),
+		Installments: 1,
+		ApprovedBy:   "branch manager",
+		Reason:       "harvest gap",
+		GrantedDate:  time.Date(2024, time.October, 15, 0, 0, 0, 0, time.UTC),
+	}
+	deferred := schedules[1:]
+	for i := range deferred {
+		deferred[i].DueDate = deferred[i].DueDate.AddDate(0, 0, 7)
+		deferred[i].PaymentStatus = entity.PaymentStatusUnspecified
+	}
+	loan.LoanEndDate = deferred[1].DueDate
+
+	holidayID, err := repo.CreatePaymentHoliday(holiday, deferred, loan)
+	if err != nil {
+		t.Fatalf("CreatePaymentHoliday() error = %v", err)
+	}
+	holiday.HolidayID = holidayID
+
+	got, err := repo.GetPaymentHolidaysByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetPaymentHolidaysByLoanID() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []entity.PaymentHoliday{holiday}) {
+		t.Errorf("GetPaymentHolidaysByLoanID() got = %+v, want %+v", got, []entity.PaymentHoliday{holiday})
+	}
+
+	gotSchedules, err := repo.GetByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	if !gotSchedules[2].DueDate.Equal(time.Date(2024, time.November, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("CreatePaymentHoliday() last due date got = %v, want 2024-11-04", gotSchedules[2].DueDate)
+	}
+
+	if _, err = repo.CreatePaymentHoliday(holiday, deferred, loan); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Errorf("CreatePaymentHoliday() with stale schedules error = %v, want %v", err, repository.ErrConcurrentModification)
+	}
+	if got, _ = repo.GetPaymentHolidaysByLoanID(loan.LoanID); len(got) != 1 {
+		t.Errorf("CreatePaymentHoliday() should roll back, got %d holidays", len(got))
+	}
+}
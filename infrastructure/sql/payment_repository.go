@@ -0,0 +1,432 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+)
+
+const paymentColumns = `payment_id, loan_id, payment_date, amount_paid, payment_method, fee_amount, status,
+	expected_settlement_date, failure_reason, idempotency_key`
+
+const allocationColumns = `allocation_id, payment_id, schedule_id, principal_amount, interest_amount, fee_amount, interest_rebate`
+
+type PaymentRepository struct {
+	db *sql.DB
+}
+
+var _ repository.PaymentRepository = (*PaymentRepository)(nil)
+
+func NewPaymentRepository(client *DbClient) *PaymentRepository {
+	return &PaymentRepository{
+		db: client.DB,
+	}
+}
+
+func (r *PaymentRepository) GetByID(paymentID int) (entity.Payment, error) {
+	return r.getPayment(`WHERE payment_id = ?`, paymentID)
+}
+
+func (r *PaymentRepository) GetByIdempotencyKey(idempotencyKey string) (entity.Payment, error) {
+	return r.getPayment(`WHERE idempotency_key = ?`, idempotencyKey)
+}
+
+// getPayment loads the single payment matched by where together with its
+// allocations.
+func (r *PaymentRepository) getPayment(where string, args ...any) (entity.Payment, error) {
+	payment, err := scanPayment(r.db.QueryRow(`
+		SELECT `+paymentColumns+`
+		FROM payments
+		`+where,
+		args...,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Payment{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+allocationColumns+`
+		FROM payment_allocations
+		WHERE payment_id = ?
+		ORDER BY allocation_id`,
+		payment.PaymentID,
+	)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		allocation, err := scanAllocation(rows)
+		if err != nil {
+			return entity.Payment{}, err
+		}
+		payment.Allocations = append(payment.Allocations, allocation)
+	}
+
+	return payment, rows.Err()
+}
+
+func (r *PaymentRepository) GetByLoanID(loanID int) ([]entity.Payment, error) {
+	rows, err := r.db.Query(`
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE loan_id = ?
+		ORDER BY payment_date, payment_id`,
+		loanID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []entity.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	allocations, err := r.getAllocationsByLoanID(loanID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range payments {
+		payments[i].Allocations = allocations[payments[i].PaymentID]
+	}
+
+	return payments, nil
+}
+
+func (r *PaymentRepository) getAllocationsByLoanID(loanID int) (map[int][]entity.PaymentAllocation, error) {
+	rows, err := r.db.Query(`
+		SELECT a.allocation_id, a.payment_id, a.schedule_id, a.principal_amount, a.interest_amount, a.fee_amount, a.interest_rebate
+		FROM payment_allocations a
+		JOIN payments p ON p.payment_id = a.payment_id
+		WHERE p.loan_id = ?
+		ORDER BY a.allocation_id`,
+		loanID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allocations := make(map[int][]entity.PaymentAllocation)
+	for rows.Next() {
+		allocation, err := scanAllocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		allocations[allocation.PaymentID] = append(allocations[allocation.PaymentID], allocation)
+	}
+
+	return allocations, rows.Err()
+}
+
+// CreatePaymentAndUpdateLoanSchedules inserts the payment with its allocations
+// and writes back every schedule and the loan in a single transaction. Nothing
+// is persisted if any schedule is missing or was modified since it was read, or
+// if the payment's idempotency key was already used.
+func (r *PaymentRepository) CreatePaymentAndUpdateLoanSchedules(
+	payment entity.Payment,
+	loanSchedules []entity.LoanSchedule,
+	loan entity.Loan,
+) (int, error) {
+	var paymentID int64
+	err := withTx(r.db, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`
+			INSERT INTO payments (loan_id, payment_date, amount_paid, payment_method, fee_amount, status,
+			                      expected_settlement_date, failure_reason, idempotency_key)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			payment.LoanID,
+			payment.PaymentDate,
+			payment.AmountPaid,
+			payment.PaymentMethod,
+			payment.FeeAmount,
+			payment.Status,
+			payment.ExpectedSettlementDate,
+			payment.FailureReason,
+			nullString(payment.IdempotencyKey),
+		)
+		if isUniqueViolation(err) {
+			return repository.ErrAlreadyExists
+		}
+		if err != nil {
+			return err
+		}
+
+		if paymentID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+
+		for _, allocation := range payment.Allocations {
+			if _, err = tx.Exec(`
+				INSERT INTO payment_allocations (payment_id, schedule_id, principal_amount, interest_amount, fee_amount,
+				                                 interest_rebate)
+				VALUES (?, ?, ?, ?, ?, ?)`,
+				paymentID,
+				allocation.ScheduleID,
+				allocation.PrincipalAmount,
+				allocation.InterestAmount,
+				allocation.FeeAmount,
+				allocation.InterestRebate,
+			); err != nil {
+				return err
+			}
+		}
+
+		for _, schedule := range loanSchedules {
+			if err = updateLoanSchedule(tx, schedule); err != nil {
+				return err
+			}
+		}
+
+		return updateLoan(tx, loan)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(paymentID), nil
+}
+
+// SettlePayment moves a pending payment to its final status, writing back its
+// allocations as they were finally applied together with every schedule and
+// the loan in a single transaction. A payment that is no longer pending is
+// reported as ErrConcurrentModification and nothing is persisted.
+func (r *PaymentRepository) SettlePayment(
+	payment entity.Payment,
+	loanSchedules []entity.LoanSchedule,
+	loan entity.Loan,
+) error {
+	return withTx(r.db, func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			`UPDATE payments SET status = ?, failure_reason = ? WHERE payment_id = ? AND status = ?`,
+			payment.Status,
+			payment.FailureReason,
+			payment.PaymentID,
+			entity.StatusPending,
+		)
+		if err != nil {
+			return err
+		}
+		err = expectAffected(result)
+		if errors.Is(err, repository.ErrNotFound) {
+			return repository.ErrConcurrentModification
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, allocation := range payment.Allocations {
+			result, err = tx.Exec(`
+				UPDATE payment_allocations
+				SET principal_amount = ?, interest_amount = ?, fee_amount = ?
+				WHERE allocation_id = ? AND payment_id = ?`,
+				allocation.PrincipalAmount,
+				allocation.InterestAmount,
+				allocation.FeeAmount,
+				allocation.AllocationID,
+				payment.PaymentID,
+			)
+			if err != nil {
+				return err
+			}
+			if err = expectAffected(result); err != nil {
+				return err
+			}
+		}
+
+		for _, schedule := range loanSchedules {
+			if err = updateLoanSchedule(tx, schedule); err != nil {
+				return err
+			}
+		}
+
+		return updateLoan(tx, loan)
+	})
+}
+
+func (r *PaymentRepository) GetReversalsByLoanID(loanID int) ([]entity.PaymentReversal, error) {
+	rows, err := r.db.Query(`
+		SELECT r.reversal_id, r.payment_id, r.reversal_date, r.reason
+		FROM payment_reversals r
+		JOIN payments p ON p.payment_id = r.payment_id
+		WHERE p.loan_id = ?
+		ORDER BY r.reversal_date, r.reversal_id`,
+		loanID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reversals []entity.PaymentReversal
+	for rows.Next() {
+		var reversal entity.PaymentReversal
+		if err = rows.Scan(&reversal.ReversalID, &reversal.PaymentID, &reversal.ReversalDate, &reversal.Reason); err != nil {
+			return nil, err
+		}
+		reversals = append(reversals, reversal)
+	}
+
+	return reversals, rows.Err()
+}
+
+// CreateReversalsAndUpdateLoanSchedules inserts the reversals, flips their
+// payments from completed to reversed and writes back every schedule and the
+// loan in a single transaction. A payment that is no longer completed is
+// reported as ErrConcurrentModification and nothing is persisted.
+func (r *PaymentRepository) CreateReversalsAndUpdateLoanSchedules(
+	reversals []entity.PaymentReversal,
+	loanSchedules []entity.LoanSchedule,
+	loan entity.Loan,
+) ([]int, error) {
+	reversalIDs := make([]int, 0, len(reversals))
+	err := withTx(r.db, func(tx *sql.Tx) error {
+		for _, reversal := range reversals {
+			result, err := tx.Exec(
+				`UPDATE payments SET status = ? WHERE payment_id = ? AND status = ?`,
+				entity.StatusReversed,
+				reversal.PaymentID,
+				entity.StatusCompleted,
+			)
+			if err != nil {
+				return err
+			}
+			err = expectAffected(result)
+			if errors.Is(err, repository.ErrNotFound) {
+				return repository.ErrConcurrentModification
+			}
+			if err != nil {
+				return err
+			}
+
+			if result, err = tx.Exec(
+				`INSERT INTO payment_reversals (payment_id, reversal_date, reason) VALUES (?, ?, ?)`,
+				reversal.PaymentID,
+				reversal.ReversalDate,
+				reversal.Reason,
+			); err != nil {
+				return err
+			}
+
+			reversalID, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			reversalIDs = append(reversalIDs, int(reversalID))
+		}
+
+		for _, schedule := range loanSchedules {
+			if err := updateLoanSchedule(tx, schedule); err != nil {
+				return err
+			}
+		}
+
+		return updateLoan(tx, loan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reversalIDs, nil
+}
+
+func (r *PaymentRepository) GetCreditRefundsByLoanID(loanID int) ([]entity.CreditRefund, error) {
+	rows, err := r.db.Query(`
+		SELECT refund_id, loan_id, refund_date, amount
+		FROM credit_refunds
+		WHERE loan_id = ?
+		ORDER BY refund_date, refund_id`,
+		loanID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []entity.CreditRefund
+	for rows.Next() {
+		var refund entity.CreditRefund
+		if err = rows.Scan(&refund.RefundID, &refund.LoanID, &refund.RefundDate, &refund.Amount); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, rows.Err()
+}
+
+func (r *PaymentRepository) CreateCreditRefund(refund entity.CreditRefund, loan entity.Loan) (int, error) {
+	var refundID int64
+	err := withTx(r.db, func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			`INSERT INTO credit_refunds (loan_id, refund_date, amount) VALUES (?, ?, ?)`,
+			refund.LoanID,
+			refund.RefundDate,
+			refund.Amount,
+		)
+		if err != nil {
+			return err
+		}
+
+		if refundID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+
+		return updateLoan(tx, loan)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(refundID), nil
+}
+
+func scanPayment(row scanner) (entity.Payment, error) {
+	var (
+		payment        entity.Payment
+		idempotencyKey sql.NullString
+	)
+	err := row.Scan(
+		&payment.PaymentID,
+		&payment.LoanID,
+		&payment.PaymentDate,
+		&payment.AmountPaid,
+		&payment.PaymentMethod,
+		&payment.FeeAmount,
+		&payment.Status,
+		&payment.ExpectedSettlementDate,
+		&payment.FailureReason,
+		&idempotencyKey,
+	)
+	payment.IdempotencyKey = idempotencyKey.String
+	return payment, err
+}
+
+func scanAllocation(row scanner) (entity.PaymentAllocation, error) {
+	var allocation entity.PaymentAllocation
+	err := row.Scan(
+		&allocation.AllocationID,
+		&allocation.PaymentID,
+		&allocation.ScheduleID,
+		&allocation.PrincipalAmount,
+		&allocation.InterestAmount,
+		&allocation.FeeAmount,
+		&allocation.InterestRebate,
+	)
+	return allocation, err
+}
@@ -0,0 +1,500 @@
+package sql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/domain/entity"
+	"github.com/iqbalbachmid/billing-engine/domain/money"
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+)
+
+func createTestLoanWithSchedules(t *testing.T, dbClient *DbClient) (entity.Loan, []entity.LoanSchedule) {
+	t.Helper()
+
+	loan := entity.Loan{
+		BorrowerID:    1,
+		LoanAmount:    money.New(300000),
+		InterestRate:  10,
+		LoanStartDate: time.Date(2024, time.October, 7, 0, 0, 0, 0, time.UTC),
+		LoanEndDate:   time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
+		LoanStatus:    entity.LoanStatusActive,
+		Tenor:         3,
+	}
+	loanID, err := NewLoanRepository(dbClient).Create(loan)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	loan.LoanID = loanID
+
+	scheduleRepo := NewLoanScheduleRepository(dbClient)
+	var schedules []entity.LoanSchedule
+	for week := 1; week <= 3; week++ {
+		schedule := entity.LoanSchedule{
+			LoanID:          loanID,
+			DueDate:         time.Date(2024, time.October, 7+7*week, 0, 0, 0, 0, time.UTC),
+			PrincipalAmount: money.New(100000),
+			InterestAmount:  money.New(10000),
+			TotalDue:        money.New(110000),
+			PaymentStatus:   entity.PaymentStatusDue,
+		}
+		id, err := scheduleRepo.Create(schedule)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		schedule.ScheduleID = id
+		schedules = append(schedules, schedule)
+	}
+
+	return loan, schedules
+}
+
+func TestPaymentRepository_CreatePaymentAndUpdateLoanSchedules(t *testing.T) {
+	dbClient := newTestDbClient(t)
+	loanRepo := NewLoanRepository(dbClient)
+	scheduleRepo := NewLoanScheduleRepository(dbClient)
+	repo := NewPaymentRepository(dbClient)
+	loan, schedules := createTestLoanWithSchedules(t, dbClient)
+
+	payment := entity.Payment{
+		LoanID:        1,
+		PaymentDate:   time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
+		AmountPaid:    money.New(220000),
+		PaymentMethod: "bank_transfer",
+		Status:        entity.StatusCompleted,
+	}
+	paid := []entity.LoanSchedule{schedules[0], schedules[1]}
+	for i := range paid {
+		allocation, _ := paid[i].Allocate(paid[i].TotalDue, entity.DefaultAllocationOrder)
+		payment.Allocations = append(payment.Allocations, allocation)
+	}
+
+	paymentID, err := repo.CreatePaymentAndUpdateLoanSchedules(payment, paid, loan)
+	if err != nil {
+		t.Fatalf("CreatePaymentAndUpdateLoanSchedules() error = %v", err)
+	}
+	loan.Version++
+	payment.PaymentID = paymentID
+	for i := range payment.Allocations {
+		payment.Allocations[i].AllocationID = i + 1
+		payment.Allocations[i].PaymentID = paymentID
+	}
+
+	payments, err := repo.GetByLoanID(1)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	if !reflect.DeepEqual(payments, []entity.Payment{payment}) {
+		t.Errorf("GetByLoanID() got = %+v, want %+v", payments, []entity.Payment{payment})
+	}
+
+	got, err := scheduleRepo.GetByLoanID(1)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	for i := range paid {
+		paid[i].Version++
+	}
+	want := []entity.LoanSchedule{paid[0], paid[1], schedules[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("schedules after payment got = %+v, want %+v", got, want)
+	}
+
+	last := schedules[2]
+	last.PaymentStatus = entity.PaymentStatusPaid
+	loan.Close(time.Date(2024, time.October, 29, 0, 0, 0, 0, time.UTC))
+	if _, err = repo.CreatePaymentAndUpdateLoanSchedules(entity.Payment{
+		LoanID:        loan.LoanID,
+		PaymentDate:   time.Date(2024, time.October, 29, 0, 0, 0, 0, time.UTC),
+		AmountPaid:    money.New(110000),
+		PaymentMethod: "bank_transfer",
+		Status:        entity.StatusCompleted,
+	}, []entity.LoanSchedule{last}, loan); err != nil {
+		t.Fatalf("CreatePaymentAndUpdateLoanSchedules() error = %v", err)
+	}
+	loan.Version++
+
+	gotLoan, err := loanRepo.GetByID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotLoan, loan) {
+		t.Errorf("loan after final payment got = %+v, want %+v", gotLoan, loan)
+	}
+}
+
+func TestPaymentRepository_CreatePaymentAndUpdateLoanSchedules_Rollback(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr error
+		prepare func(t *testing.T, scheduleRepo *LoanScheduleRepository, schedules []entity.LoanSchedule) []entity.LoanSchedule
+		loan    func(t *testing.T, loanRepo *LoanRepository, loan entity.Loan) entity.Loan
+	}{
+		{
+			name:    "should rollback if a schedule was modified since it was read",
+			wantErr: repository.ErrConcurrentModification,
+			prepare: func(t *testing.T, scheduleRepo *LoanScheduleRepository, schedules []entity.LoanSchedule) []entity.LoanSchedule {
+				concurrent := schedules[1]
+				concurrent.PaymentStatus = entity.PaymentStatusOverdue
+				if err := scheduleRepo.Update(concurrent); err != nil {
+					t.Fatalf("Update() error = %v", err)
+				}
+				return schedules[:2]
+			},
+		},
+		{
+			name:    "should rollback if a schedule does not exist",
+			wantErr: repository.ErrNotFound,
+			prepare: func(t *testing.T, scheduleRepo *LoanScheduleRepository, schedules []entity.LoanSchedule) []entity.LoanSchedule {
+				return append(schedules[:2:2], entity.LoanSchedule{ScheduleID: 999, LoanID: 1})
+			},
+		},
+		{
+			name:    "should rollback if the loan does not exist",
+			wantErr: repository.ErrNotFound,
+			prepare: func(t *testing.T, scheduleRepo *LoanScheduleRepository, schedules []entity.LoanSchedule) []entity.LoanSchedule {
+				return schedules
+			},
+			loan: func(t *testing.T, loanRepo *LoanRepository, loan entity.Loan) entity.Loan {
+				loan.LoanID = 999
+				return loan
+			},
+		},
+		{
+			name:    "should rollback if the loan was modified since it was read",
+			wantErr: repository.ErrConcurrentModification,
+			prepare: func(t *testing.T, scheduleRepo *LoanScheduleRepository, schedules []entity.LoanSchedule) []entity.LoanSchedule {
+				return schedules
+			},
+			loan: func(t *testing.T, loanRepo *LoanRepository, loan entity.Loan) entity.Loan {
+				concurrent := loan
+				concurrent.LoanStatus = entity.LoanStatusActive
+				concurrent.ClosedDate = nil
+				concurrent.CreditBalance = money.New(5000)
+				if err := loanRepo.Update(concurrent); err != nil {
+					t.Fatalf("Update() error = %v", err)
+				}
+				return loan
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbClient := newTestDbClient(t)
+			scheduleRepo := NewLoanScheduleRepository(dbClient)
+			repo := NewPaymentRepository(dbClient)
+			loan, schedules := createTestLoanWithSchedules(t, dbClient)
+			loan.Close(time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC))
+			if tt.loan != nil {
+				loan = tt.loan(t, NewLoanRepository(dbClient), loan)
+			}
+
+			toBeUpdated := tt.prepare(t, scheduleRepo, schedules)
+			for i := range toBeUpdated {
+				toBeUpdated[i].PaymentStatus = entity.PaymentStatusPaid
+			}
+
+			before, err := scheduleRepo.GetByLoanID(1)
+			if err != nil {
+				t.Fatalf("GetByLoanID() error = %v", err)
+			}
+
+			_, err = repo.CreatePaymentAndUpdateLoanSchedules(entity.Payment{
+				LoanID:        1,
+				PaymentDate:   time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC),
+				AmountPaid:    money.New(220000),
+				PaymentMethod: "bank_transfer",
+				Status:        entity.StatusCompleted,
+			}, toBeUpdated, loan)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreatePaymentAndUpdateLoanSchedules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			payments, err := repo.GetByLoanID(1)
+			if err != nil {
+				t.Fatalf("GetByLoanID() error = %v", err)
+			}
+			if len(payments) != 0 {
+				t.Errorf("payment should have been rolled back, got = %+v", payments)
+			}
+
+			after, err := scheduleRepo.GetByLoanID(1)
+			if err != nil {
+				t.Fatalf("GetByLoanID() error = %v", err)
+			}
+			if !reflect.DeepEqual(after, before) {
+				t.Errorf("schedules should have been rolled back, got = %+v, want %+v", after, before)
+			}
+
+			loans, err := NewLoanRepository(dbClient).GetClosedBetween(time.Time{}, time.Now())
+			if err != nil {
+				t.Fatalf("GetClosedBetween() error = %v", err)
+			}
+			if len(loans) != 0 {
+				t.Errorf("loan closure should have been rolled back, got = %+v", loans)
+			}
+		})
+	}
+}
+
+func TestPaymentRepository_CreateCreditRefund(t *testing.T) {
+	dbClient := newTestDbClient(t)
+	loanRepo := NewLoanRepository(dbClient)
+	repo := NewPaymentRepository(dbClient)
+	loan, _ := createTestLoanWithSchedules(t, dbClient)
+	loan.Close(time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC))
+
+	refund := entity.CreditRefund{
+		LoanID:     loan.LoanID,
+		RefundDate: time.Date(2024, time.October, 29, 0, 0, 0, 0, time.UTC),
+		Amount:     money.MustParse("15000.50"),
+	}
+	refundID, err := repo.CreateCreditRefund(refund, loan)
+	if err != nil {
+		t.Fatalf("CreateCreditRefund() error = %v", err)
+	}
+	loan.Version++
+	refund.RefundID = refundID
+
+	refunds, err := repo.GetCreditRefundsByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetCreditRefundsByLoanID() error = %v", err)
+	}
+	if !reflect.DeepEqual(refunds, []entity.CreditRefund{refund}) {
+		t.Errorf("GetCreditRefundsByLoanID() got = %+v, want %+v", refunds, []entity.CreditRefund{refund})
+	}
+
+	gotLoan, err := loanRepo.GetByID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotLoan, loan) {
+		t.Errorf("loan after refund got = %+v, want %+v", gotLoan, loan)
+	}
+
+	stale := loan
+	stale.Version--
+	if _, err = repo.CreateCreditRefund(refund, stale); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Fatalf("CreateCreditRefund() with a stale loan error = %v, wantErr %v", err, repository.ErrConcurrentModification)
+	}
+
+	loan.LoanID = 999
+	if _, err = repo.CreateCreditRefund(refund, loan); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("CreateCreditRefund() error = %v, wantErr %v", err, repository.ErrNotFound)
+	}
+	if refunds, err = repo.GetCreditRefundsByLoanID(1); err != nil || len(refunds) != 1 {
+		t.Errorf("refund for missing loan should have been rolled back, got = %+v, err = %v", refunds, err)
+	}
+}
+
+func TestPaymentRepository_CreateReversalsAndUpdateLoanSchedules(t *testing.T) {
+	dbClient := newTestDbClient(t)
+	scheduleRepo := NewLoanScheduleRepository(dbClient)
+	repo := NewPaymentRepository(dbClient)
+	loan, schedules := createTestLoanWithSchedules(t, dbClient)
+
+	paid := schedules[0]
+	allocation, _ := paid.Allocate(paid.TotalDue, entity.DefaultAllocationOrder)
+	paymentID, err := repo.CreatePaymentAndUpdateLoanSchedules(entity.Payment{
+		LoanID:        loan.LoanID,
+		PaymentDate:   time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC),
+		AmountPaid:    money.New(110000),
+		PaymentMethod: "bank_transfer",
+		Status:        entity.StatusCompleted,
+		Allocations:   []entity.PaymentAllocation{allocation},
+	}, []entity.LoanSchedule{paid}, loan)
+	if err != nil {
+		t.Fatalf("CreatePaymentAndUpdateLoanSchedules() error = %v", err)
+	}
+	loan.Version++
+
+	payment, err := repo.GetByID(paymentID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if len(payment.Allocations) != 1 || payment.Allocations[0].Total() != paid.TotalDue {
+		t.Errorf("GetByID() allocations = %+v, want one of %v", payment.Allocations, paid.TotalDue)
+	}
+
+	paid.Version++
+	paid.Unallocate(payment.Allocations[0])
+	paid.PaymentStatus = entity.PaymentStatusOverdue
+	reversal := entity.PaymentReversal{
+		PaymentID:    paymentID,
+		ReversalDate: time.Date(2024, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Reason:       "bounced",
+	}
+	reversalIDs, err := repo.CreateReversalsAndUpdateLoanSchedules([]entity.PaymentReversal{reversal}, []entity.LoanSchedule{paid}, loan)
+	if err != nil {
+		t.Fatalf("CreateReversalsAndUpdateLoanSchedules() error = %v", err)
+	}
+	loan.Version++
+	reversal.ReversalID = reversalIDs[0]
+
+	reversals, err := repo.GetReversalsByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetReversalsByLoanID() error = %v", err)
+	}
+	if !reflect.DeepEqual(reversals, []entity.PaymentReversal{reversal}) {
+		t.Errorf("GetReversalsByLoanID() got = %+v, want %+v", reversals, []entity.PaymentReversal{reversal})
+	}
+
+	if payment, err = repo.GetByID(paymentID); err != nil || !payment.IsReversed() {
+		t.Errorf("GetByID() after reversal got = %+v, err = %v", payment, err)
+	}
+
+	got, err := scheduleRepo.GetByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	paid.Version++
+	if !reflect.DeepEqual(got[0], paid) {
+		t.Errorf("schedule after reversal got = %+v, want %+v", got[0], paid)
+	}
+
+	paid.Version++
+	if _, err = repo.CreateReversalsAndUpdateLoanSchedules([]entity.PaymentReversal{reversal}, []entity.LoanSchedule{paid}, loan); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Errorf("CreateReversalsAndUpdateLoanSchedules() twice error = %v, wantErr %v", err, repository.ErrConcurrentModification)
+	}
+
+	if _, err = repo.GetByID(999); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetByID() error = %v, wantErr %v", err, repository.ErrNotFound)
+	}
+}
+
+func TestPaymentRepository_IdempotencyKey(t *testing.T) {
+	dbClient := newTestDbClient(t)
+	repo := NewPaymentRepository(dbClient)
+	loan, _ := createTestLoanWithSchedules(t, dbClient)
+
+	payment := entity.Payment{
+		LoanID:         loan.LoanID,
+		PaymentDate:    time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC),
+		AmountPaid:     money.New(110000),
+		PaymentMethod:  "bank_transfer",
+		Status:         entity.StatusCompleted,
+		IdempotencyKey: "REF-1",
+	}
+	paymentID, err := repo.CreatePaymentAndUpdateLoanSchedules(payment, nil, loan)
+	if err != nil {
+		t.Fatalf("CreatePaymentAndUpdateLoanSchedules() error = %v", err)
+	}
+	loan.Version++
+	payment.PaymentID = paymentID
+
+	got, err := repo.GetByIdempotencyKey("REF-1")
+	if err != nil {
+		t.Fatalf("GetByIdempotencyKey() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, payment) {
+		t.Errorf("GetByIdempotencyKey() got = %+v, want %+v", got, payment)
+	}
+
+	if _, err = repo.CreatePaymentAndUpdateLoanSchedules(payment, nil, loan); !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Errorf("CreatePaymentAndUpdateLoanSchedules() with used key error = %v, wantErr %v", err, repository.ErrAlreadyExists)
+	}
+
+	credit := entity.Payment{
+		LoanID:        loan.LoanID,
+		PaymentDate:   time.Date(2024, time.October, 21, 0, 0, 0, 0, time.UTC),
+		AmountPaid:    money.New(10000),
+		PaymentMethod: entity.PaymentMethodCreditBalance,
+		Status:        entity.StatusCompleted,
+	}
+	for range 2 {
+		if _, err = repo.CreatePaymentAndUpdateLoanSchedules(credit, nil, loan); err != nil {
+			t.Errorf("CreatePaymentAndUpdateLoanSchedules() without key error = %v", err)
+		}
+		loan.Version++
+	}
+
+	if _, err = repo.GetByIdempotencyKey("REF-2"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetByIdempotencyKey() error = %v, wantErr %v", err, repository.ErrNotFound)
+	}
+}
+
+func TestPaymentRepository_UnknownPaymentMethod(t *testing.T) {
+	dbClient := newTestDbClient(t)
+	repo := NewPaymentRepository(dbClient)
+	loan, _ := createTestLoanWithSchedules(t, dbClient)
+
+	if _, err := repo.CreatePaymentAndUpdateLoanSchedules(entity.Payment{
+		LoanID:        loan.LoanID,
+		AmountPaid:    money.New(110000),
+		PaymentMethod: "cheque",
+		Status:        entity.StatusCompleted,
+	}, nil, loan); err == nil {
+		t.Errorf("CreatePaymentAndUpdateLoanSchedules() with unregistered payment method should return error")
+	}
+}
+
+func TestPaymentRepository_SettlePayment(t *testing.T) {
+	dbClient := newTestDbClient(t)
+	scheduleRepo := NewLoanScheduleRepository(dbClient)
+	repo := NewPaymentRepository(dbClient)
+	loan, schedules := createTestLoanWithSchedules(t, dbClient)
+
+	reserved := schedules[0]
+	allocation, _ := reserved.Reserve(reserved.TotalDue, entity.DefaultAllocationOrder)
+	paymentID, err := repo.CreatePaymentAndUpdateLoanSchedules(entity.Payment{
+		LoanID:                 loan.LoanID,
+		PaymentDate:            time.Date(2024, time.October, 14, 0, 0, 0, 0, time.UTC),
+		AmountPaid:             money.New(110000),
+		PaymentMethod:          "bank_transfer",
+		Status:                 entity.StatusPending,
+		ExpectedSettlementDate: time.Date(2024, time.October, 15, 0, 0, 0, 0, time.UTC),
+		Allocations:            []entity.PaymentAllocation{allocation},
+	}, []entity.LoanSchedule{reserved}, loan)
+	if err != nil {
+		t.Fatalf("CreatePaymentAndUpdateLoanSchedules() error = %v", err)
+	}
+	loan.Version++
+
+	got, err := scheduleRepo.GetByLoanID(loan.LoanID)
+	if err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	reserved.Version++
+	if !reflect.DeepEqual(got[0], reserved) {
+		t.Errorf("schedule after reservation got = %+v, want %+v", got[0], reserved)
+	}
+
+	payment, err := repo.GetByID(paymentID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !payment.IsPending() || len(payment.Allocations) != 1 {
+		t.Fatalf("GetByID() got = %+v, want a pending payment with one allocation", payment)
+	}
+
+	paid := reserved
+	paid.Release(payment.Allocations[0])
+	settled, _ := paid.Allocate(payment.AmountPaid, entity.DefaultAllocationOrder)
+	settled.AllocationID = payment.Allocations[0].AllocationID
+	settled.PaymentID = paymentID
+	payment.Allocations = []entity.PaymentAllocation{settled}
+	payment.Status = entity.StatusCompleted
+	if err = repo.SettlePayment(payment, []entity.LoanSchedule{paid}, loan); err != nil {
+		t.Fatalf("SettlePayment() error = %v", err)
+	}
+	loan.Version++
+
+	if got, err := repo.GetByID(paymentID); err != nil || !reflect.DeepEqual(got, payment) {
+		t.Errorf("GetByID() after settlement got = %+v, err = %v, want %+v", got, err, payment)
+	}
+	if got, err = scheduleRepo.GetByLoanID(loan.LoanID); err != nil {
+		t.Fatalf("GetByLoanID() error = %v", err)
+	}
+	paid.Version++
+	if !reflect.DeepEqual(got[0], paid) {
+		t.Errorf("schedule after settlement got = %+v, want %+v", got[0], paid)
+	}
+
+	payment.Status = entity.StatusFailed
+	payment.FailureReason = "bounced"
+	if err = repo.SettlePayment(payment, nil, loan); !errors.Is(err, repository.ErrConcurrentModification) {
+		t.Errorf("SettlePayment() twice error = %v, wantErr %v", err, repository.ErrConcurrentModification)
+	}
+}
@@ -0,0 +1,44 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/iqbalbachmid/billing-engine/domain/repository"
+	"github.com/mattn/go-sqlite3"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func expectAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is SQLite rejecting a duplicate value
+// in a UNIQUE column.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// nullString stores an empty string as NULL, so that optional values in
+// UNIQUE columns do not collide.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
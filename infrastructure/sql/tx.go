@@ -0,0 +1,28 @@
+package sql
+
+import "database/sql"
+
+// withTx runs fn inside a transaction, committing when fn succeeds and
+// rolling back when it returns an error or panics.
+func withTx(db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -1,15 +1,77 @@
 package main
 
 import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/iqbalbachmid/billing-engine/application"
+	"github.com/iqbalbachmid/billing-engine/domain/calendar"
+	"github.com/iqbalbachmid/billing-engine/domain/paymentmethod"
+	"github.com/iqbalbachmid/billing-engine/infrastructure/eventlog"
+	"github.com/iqbalbachmid/billing-engine/infrastructure/holidays"
 	"github.com/iqbalbachmid/billing-engine/infrastructure/sql"
 )
 
 func main() {
+	statusUpdateInterval := flag.Duration("status-update-interval", 24*time.Hour, "how often schedule statuses are updated")
+	holidaysFile := flag.String("holidays", "holidays.json", "JSON file of public holidays per year; empty for weekends only")
+	flag.Parse()
+
+	var holidayCalendar *calendar.Calendar
+	if *holidaysFile != "" {
+		var err error
+		if holidayCalendar, err = holidays.Load(*holidaysFile); err != nil {
+			log.Fatalf("Failed to load holidays: %v", err)
+		}
+		if err = holidayCalendar.CheckYear(time.Now()); err != nil {
+			log.Printf("%s lists no holidays for this year: %v", *holidaysFile, err)
+		}
+	}
+
 	dbClient := sql.NewSQLite3Client()
 	dbClient.CreateTables()
 	defer dbClient.Close()
 
-	//service := application.NewLoanService(nil, nil, nil, func() time.Time {
-	//	return time.Now()
-	//})
+	paymentMethods := paymentmethod.Default()
+	dbClient.SyncPaymentMethods(paymentMethods.Codes())
+
+	service := application.NewLoanService(
+		sql.NewBorrowerRepository(dbClient),
+		sql.NewLoanRepository(dbClient),
+		sql.NewLoanScheduleRepository(dbClient),
+		sql.NewPaymentRepository(dbClient),
+		sql.NewChargeRepository(dbClient),
+		eventlog.NewPublisher(log.Default()),
+		func() time.Time {
+			return time.Now()
+		},
+		application.WithPaymentMethods(paymentMethods),
+		application.WithCalendar(holidayCalendar),
+	)
+
+	updateScheduleStatuses := func() {
+		if err := service.UpdateScheduleStatuses(); err != nil {
+			log.Printf("Failed to update schedule statuses: %v", err)
+		}
+	}
+	updateScheduleStatuses()
+
+	ticker := time.NewTicker(*statusUpdateInterval)
+	defer ticker.Stop()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	for {
+		select {
+		case <-ticker.C:
+			updateScheduleStatuses()
+		case <-signals:
+			return
+		}
+	}
 }
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.46.3. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
@@ -122,7 +122,7 @@ func (_c *BorrowerRepository_Delete_Call) RunAndReturn(run func(int) error) *Bor
 	return _c
 }
 
-// GetAll provides a mock function with given fields:
+// GetAll provides a mock function with no fields
 func (_m *BorrowerRepository) GetAll() ([]entity.Borrower, error) {
 	ret := _m.Called()
 
@@ -235,6 +235,64 @@ func (_c *BorrowerRepository_GetByID_Call) RunAndReturn(run func(int) (entity.Bo
 	return _c
 }
 
+// GetStatusChanges provides a mock function with given fields: borrowerID
+func (_m *BorrowerRepository) GetStatusChanges(borrowerID int) ([]entity.BorrowerStatusChange, error) {
+	ret := _m.Called(borrowerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStatusChanges")
+	}
+
+	var r0 []entity.BorrowerStatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]entity.BorrowerStatusChange, error)); ok {
+		return rf(borrowerID)
+	}
+	if rf, ok := ret.Get(0).(func(int) []entity.BorrowerStatusChange); ok {
+		r0 = rf(borrowerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.BorrowerStatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(borrowerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BorrowerRepository_GetStatusChanges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStatusChanges'
+type BorrowerRepository_GetStatusChanges_Call struct {
+	*mock.Call
+}
+
+// GetStatusChanges is a helper method to define mock.On call
+//   - borrowerID int
+func (_e *BorrowerRepository_Expecter) GetStatusChanges(borrowerID interface{}) *BorrowerRepository_GetStatusChanges_Call {
+	return &BorrowerRepository_GetStatusChanges_Call{Call: _e.mock.On("GetStatusChanges", borrowerID)}
+}
+
+func (_c *BorrowerRepository_GetStatusChanges_Call) Run(run func(borrowerID int)) *BorrowerRepository_GetStatusChanges_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *BorrowerRepository_GetStatusChanges_Call) Return(_a0 []entity.BorrowerStatusChange, _a1 error) *BorrowerRepository_GetStatusChanges_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *BorrowerRepository_GetStatusChanges_Call) RunAndReturn(run func(int) ([]entity.BorrowerStatusChange, error)) *BorrowerRepository_GetStatusChanges_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Update provides a mock function with given fields: borrower
 func (_m *BorrowerRepository) Update(borrower entity.Borrower) error {
 	ret := _m.Called(borrower)
@@ -281,6 +339,62 @@ func (_c *BorrowerRepository_Update_Call) RunAndReturn(run func(entity.Borrower)
 	return _c
 }
 
+// UpdateAccountStatus provides a mock function with given fields: change
+func (_m *BorrowerRepository) UpdateAccountStatus(change entity.BorrowerStatusChange) (int, error) {
+	ret := _m.Called(change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAccountStatus")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(entity.BorrowerStatusChange) (int, error)); ok {
+		return rf(change)
+	}
+	if rf, ok := ret.Get(0).(func(entity.BorrowerStatusChange) int); ok {
+		r0 = rf(change)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(entity.BorrowerStatusChange) error); ok {
+		r1 = rf(change)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BorrowerRepository_UpdateAccountStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateAccountStatus'
+type BorrowerRepository_UpdateAccountStatus_Call struct {
+	*mock.Call
+}
+
+// UpdateAccountStatus is a helper method to define mock.On call
+//   - change entity.BorrowerStatusChange
+func (_e *BorrowerRepository_Expecter) UpdateAccountStatus(change interface{}) *BorrowerRepository_UpdateAccountStatus_Call {
+	return &BorrowerRepository_UpdateAccountStatus_Call{Call: _e.mock.On("UpdateAccountStatus", change)}
+}
+
+func (_c *BorrowerRepository_UpdateAccountStatus_Call) Run(run func(change entity.BorrowerStatusChange)) *BorrowerRepository_UpdateAccountStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(entity.BorrowerStatusChange))
+	})
+	return _c
+}
+
+func (_c *BorrowerRepository_UpdateAccountStatus_Call) Return(_a0 int, _a1 error) *BorrowerRepository_UpdateAccountStatus_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *BorrowerRepository_UpdateAccountStatus_Call) RunAndReturn(run func(entity.BorrowerStatusChange) (int, error)) *BorrowerRepository_UpdateAccountStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewBorrowerRepository creates a new instance of BorrowerRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewBorrowerRepository(t interface {
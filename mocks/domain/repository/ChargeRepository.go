@@ -0,0 +1,325 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	entity "github.com/iqbalbachmid/billing-engine/domain/entity"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ChargeRepository is an autogenerated mock type for the ChargeRepository type
+type ChargeRepository struct {
+	mock.Mock
+}
+
+type ChargeRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *ChargeRepository) EXPECT() *ChargeRepository_Expecter {
+	return &ChargeRepository_Expecter{mock: &_m.Mock}
+}
+
+// CreateAdjustmentAndUpdateLoanSchedule provides a mock function with given fields: adjustment, charge, loanSchedule, loan
+func (_m *ChargeRepository) CreateAdjustmentAndUpdateLoanSchedule(adjustment entity.ChargeAdjustment, charge entity.Charge, loanSchedule entity.LoanSchedule, loan entity.Loan) (int, error) {
+	ret := _m.Called(adjustment, charge, loanSchedule, loan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAdjustmentAndUpdateLoanSchedule")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(entity.ChargeAdjustment, entity.Charge, entity.LoanSchedule, entity.Loan) (int, error)); ok {
+		return rf(adjustment, charge, loanSchedule, loan)
+	}
+	if rf, ok := ret.Get(0).(func(entity.ChargeAdjustment, entity.Charge, entity.LoanSchedule, entity.Loan) int); ok {
+		r0 = rf(adjustment, charge, loanSchedule, loan)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(entity.ChargeAdjustment, entity.Charge, entity.LoanSchedule, entity.Loan) error); ok {
+		r1 = rf(adjustment, charge, loanSchedule, loan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChargeRepository_CreateAdjustmentAndUpdateLoanSchedule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAdjustmentAndUpdateLoanSchedule'
+type ChargeRepository_CreateAdjustmentAndUpdateLoanSchedule_Call struct {
+	*mock.Call
+}
+
+// CreateAdjustmentAndUpdateLoanSchedule is a helper method to define mock.On call
+//   - adjustment entity.ChargeAdjustment
+//   - charge entity.Charge
+//   - loanSchedule entity.LoanSchedule
+//   - loan entity.Loan
+func (_e *ChargeRepository_Expecter) CreateAdjustmentAndUpdateLoanSchedule(adjustment interface{}, charge interface{}, loanSchedule interface{}, loan interface{}) *ChargeRepository_CreateAdjustmentAndUpdateLoanSchedule_Call {
+	return &ChargeRepository_CreateAdjustmentAndUpdateLoanSchedule_Call{Call: _e.mock.On("CreateAdjustmentAndUpdateLoanSchedule", adjustment, charge, loanSchedule, loan)}
+}
+
+func (_c *ChargeRepository_CreateAdjustmentAndUpdateLoanSchedule_Call) Run(run func(adjustment entity.ChargeAdjustment, charge entity.Charge, loanSchedule entity.LoanSchedule, loan entity.Loan)) *ChargeRepository_CreateAdjustmentAndUpdateLoanSchedule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(entity.ChargeAdjustment), args[1].(entity.Charge), args[2].(entity.LoanSchedule), args[3].(entity.Loan))
+	})
+	return _c
+}
+
+func (_c *ChargeRepository_CreateAdjustmentAndUpdateLoanSchedule_Call) Return(_a0 int, _a1 error) *ChargeRepository_CreateAdjustmentAndUpdateLoanSchedule_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ChargeRepository_CreateAdjustmentAndUpdateLoanSchedule_Call) RunAndReturn(run func(entity.ChargeAdjustment, entity.Charge, entity.LoanSchedule, entity.Loan) (int, error)) *ChargeRepository_CreateAdjustmentAndUpdateLoanSchedule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateChargesAndUpdateLoanSchedules provides a mock function with given fields: charges, loanSchedules
+func (_m *ChargeRepository) CreateChargesAndUpdateLoanSchedules(charges []entity.Charge, loanSchedules []entity.LoanSchedule) ([]int, error) {
+	ret := _m.Called(charges, loanSchedules)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateChargesAndUpdateLoanSchedules")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]entity.Charge, []entity.LoanSchedule) ([]int, error)); ok {
+		return rf(charges, loanSchedules)
+	}
+	if rf, ok := ret.Get(0).(func([]entity.Charge, []entity.LoanSchedule) []int); ok {
+		r0 = rf(charges, loanSchedules)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]entity.Charge, []entity.LoanSchedule) error); ok {
+		r1 = rf(charges, loanSchedules)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChargeRepository_CreateChargesAndUpdateLoanSchedules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateChargesAndUpdateLoanSchedules'
+type ChargeRepository_CreateChargesAndUpdateLoanSchedules_Call struct {
+	*mock.Call
+}
+
+// CreateChargesAndUpdateLoanSchedules is a helper method to define mock.On call
+//   - charges []entity.Charge
+//   - loanSchedules []entity.LoanSchedule
+func (_e *ChargeRepository_Expecter) CreateChargesAndUpdateLoanSchedules(charges interface{}, loanSchedules interface{}) *ChargeRepository_CreateChargesAndUpdateLoanSchedules_Call {
+	return &ChargeRepository_CreateChargesAndUpdateLoanSchedules_Call{Call: _e.mock.On("CreateChargesAndUpdateLoanSchedules", charges, loanSchedules)}
+}
+
+func (_c *ChargeRepository_CreateChargesAndUpdateLoanSchedules_Call) Run(run func(charges []entity.Charge, loanSchedules []entity.LoanSchedule)) *ChargeRepository_CreateChargesAndUpdateLoanSchedules_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]entity.Charge), args[1].([]entity.LoanSchedule))
+	})
+	return _c
+}
+
+func (_c *ChargeRepository_CreateChargesAndUpdateLoanSchedules_Call) Return(_a0 []int, _a1 error) *ChargeRepository_CreateChargesAndUpdateLoanSchedules_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ChargeRepository_CreateChargesAndUpdateLoanSchedules_Call) RunAndReturn(run func([]entity.Charge, []entity.LoanSchedule) ([]int, error)) *ChargeRepository_CreateChargesAndUpdateLoanSchedules_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAdjustmentsByLoanID provides a mock function with given fields: loanID
+func (_m *ChargeRepository) GetAdjustmentsByLoanID(loanID int) ([]entity.ChargeAdjustment, error) {
+	ret := _m.Called(loanID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAdjustmentsByLoanID")
+	}
+
+	var r0 []entity.ChargeAdjustment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]entity.ChargeAdjustment, error)); ok {
+		return rf(loanID)
+	}
+	if rf, ok := ret.Get(0).(func(int) []entity.ChargeAdjustment); ok {
+		r0 = rf(loanID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.ChargeAdjustment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(loanID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChargeRepository_GetAdjustmentsByLoanID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAdjustmentsByLoanID'
+type ChargeRepository_GetAdjustmentsByLoanID_Call struct {
+	*mock.Call
+}
+
+// GetAdjustmentsByLoanID is a helper method to define mock.On call
+//   - loanID int
+func (_e *ChargeRepository_Expecter) GetAdjustmentsByLoanID(loanID interface{}) *ChargeRepository_GetAdjustmentsByLoanID_Call {
+	return &ChargeRepository_GetAdjustmentsByLoanID_Call{Call: _e.mock.On("GetAdjustmentsByLoanID", loanID)}
+}
+
+func (_c *ChargeRepository_GetAdjustmentsByLoanID_Call) Run(run func(loanID int)) *ChargeRepository_GetAdjustmentsByLoanID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *ChargeRepository_GetAdjustmentsByLoanID_Call) Return(_a0 []entity.ChargeAdjustment, _a1 error) *ChargeRepository_GetAdjustmentsByLoanID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ChargeRepository_GetAdjustmentsByLoanID_Call) RunAndReturn(run func(int) ([]entity.ChargeAdjustment, error)) *ChargeRepository_GetAdjustmentsByLoanID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetByID provides a mock function with given fields: chargeID
+func (_m *ChargeRepository) GetByID(chargeID int) (entity.Charge, error) {
+	ret := _m.Called(chargeID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByID")
+	}
+
+	var r0 entity.Charge
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (entity.Charge, error)); ok {
+		return rf(chargeID)
+	}
+	if rf, ok := ret.Get(0).(func(int) entity.Charge); ok {
+		r0 = rf(chargeID)
+	} else {
+		r0 = ret.Get(0).(entity.Charge)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(chargeID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChargeRepository_GetByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetByID'
+type ChargeRepository_GetByID_Call struct {
+	*mock.Call
+}
+
+// GetByID is a helper method to define mock.On call
+//   - chargeID int
+func (_e *ChargeRepository_Expecter) GetByID(chargeID interface{}) *ChargeRepository_GetByID_Call {
+	return &ChargeRepository_GetByID_Call{Call: _e.mock.On("GetByID", chargeID)}
+}
+
+func (_c *ChargeRepository_GetByID_Call) Run(run func(chargeID int)) *ChargeRepository_GetByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *ChargeRepository_GetByID_Call) Return(_a0 entity.Charge, _a1 error) *ChargeRepository_GetByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ChargeRepository_GetByID_Call) RunAndReturn(run func(int) (entity.Charge, error)) *ChargeRepository_GetByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetByLoanID provides a mock function with given fields: loanID
+func (_m *ChargeRepository) GetByLoanID(loanID int) ([]entity.Charge, error) {
+	ret := _m.Called(loanID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByLoanID")
+	}
+
+	var r0 []entity.Charge
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]entity.Charge, error)); ok {
+		return rf(loanID)
+	}
+	if rf, ok := ret.Get(0).(func(int) []entity.Charge); ok {
+		r0 = rf(loanID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Charge)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(loanID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChargeRepository_GetByLoanID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetByLoanID'
+type ChargeRepository_GetByLoanID_Call struct {
+	*mock.Call
+}
+
+// GetByLoanID is a helper method to define mock.On call
+//   - loanID int
+func (_e *ChargeRepository_Expecter) GetByLoanID(loanID interface{}) *ChargeRepository_GetByLoanID_Call {
+	return &ChargeRepository_GetByLoanID_Call{Call: _e.mock.On("GetByLoanID", loanID)}
+}
+
+func (_c *ChargeRepository_GetByLoanID_Call) Run(run func(loanID int)) *ChargeRepository_GetByLoanID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *ChargeRepository_GetByLoanID_Call) Return(_a0 []entity.Charge, _a1 error) *ChargeRepository_GetByLoanID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ChargeRepository_GetByLoanID_Call) RunAndReturn(run func(int) ([]entity.Charge, error)) *ChargeRepository_GetByLoanID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewChargeRepository creates a new instance of ChargeRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewChargeRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ChargeRepository {
+	mock := &ChargeRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
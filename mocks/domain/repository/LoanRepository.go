@@ -1,10 +1,12 @@
-// Code generated by mockery v2.46.3. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
 import (
 	entity "github.com/iqbalbachmid/billing-engine/domain/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // LoanRepository is an autogenerated mock type for the LoanRepository type
@@ -122,7 +124,7 @@ func (_c *LoanRepository_Delete_Call) RunAndReturn(run func(int) error) *LoanRep
 	return _c
 }
 
-// GetAll provides a mock function with given fields:
+// GetAll provides a mock function with no fields
 func (_m *LoanRepository) GetAll() ([]entity.Loan, error) {
 	ret := _m.Called()
 
@@ -179,6 +181,64 @@ func (_c *LoanRepository_GetAll_Call) RunAndReturn(run func() ([]entity.Loan, er
 	return _c
 }
 
+// GetByBorrowerID provides a mock function with given fields: borrowerID
+func (_m *LoanRepository) GetByBorrowerID(borrowerID int) ([]entity.Loan, error) {
+	ret := _m.Called(borrowerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByBorrowerID")
+	}
+
+	var r0 []entity.Loan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]entity.Loan, error)); ok {
+		return rf(borrowerID)
+	}
+	if rf, ok := ret.Get(0).(func(int) []entity.Loan); ok {
+		r0 = rf(borrowerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Loan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(borrowerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoanRepository_GetByBorrowerID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetByBorrowerID'
+type LoanRepository_GetByBorrowerID_Call struct {
+	*mock.Call
+}
+
+// GetByBorrowerID is a helper method to define mock.On call
+//   - borrowerID int
+func (_e *LoanRepository_Expecter) GetByBorrowerID(borrowerID interface{}) *LoanRepository_GetByBorrowerID_Call {
+	return &LoanRepository_GetByBorrowerID_Call{Call: _e.mock.On("GetByBorrowerID", borrowerID)}
+}
+
+func (_c *LoanRepository_GetByBorrowerID_Call) Run(run func(borrowerID int)) *LoanRepository_GetByBorrowerID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *LoanRepository_GetByBorrowerID_Call) Return(_a0 []entity.Loan, _a1 error) *LoanRepository_GetByBorrowerID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LoanRepository_GetByBorrowerID_Call) RunAndReturn(run func(int) ([]entity.Loan, error)) *LoanRepository_GetByBorrowerID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetByID provides a mock function with given fields: id
 func (_m *LoanRepository) GetByID(id int) (entity.Loan, error) {
 	ret := _m.Called(id)
@@ -235,6 +295,65 @@ func (_c *LoanRepository_GetByID_Call) RunAndReturn(run func(int) (entity.Loan,
 	return _c
 }
 
+// GetClosedBetween provides a mock function with given fields: from, to
+func (_m *LoanRepository) GetClosedBetween(from time.Time, to time.Time) ([]entity.Loan, error) {
+	ret := _m.Called(from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetClosedBetween")
+	}
+
+	var r0 []entity.Loan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time, time.Time) ([]entity.Loan, error)); ok {
+		return rf(from, to)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time, time.Time) []entity.Loan); ok {
+		r0 = rf(from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Loan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time, time.Time) error); ok {
+		r1 = rf(from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoanRepository_GetClosedBetween_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetClosedBetween'
+type LoanRepository_GetClosedBetween_Call struct {
+	*mock.Call
+}
+
+// GetClosedBetween is a helper method to define mock.On call
+//   - from time.Time
+//   - to time.Time
+func (_e *LoanRepository_Expecter) GetClosedBetween(from interface{}, to interface{}) *LoanRepository_GetClosedBetween_Call {
+	return &LoanRepository_GetClosedBetween_Call{Call: _e.mock.On("GetClosedBetween", from, to)}
+}
+
+func (_c *LoanRepository_GetClosedBetween_Call) Run(run func(from time.Time, to time.Time)) *LoanRepository_GetClosedBetween_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *LoanRepository_GetClosedBetween_Call) Return(_a0 []entity.Loan, _a1 error) *LoanRepository_GetClosedBetween_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LoanRepository_GetClosedBetween_Call) RunAndReturn(run func(time.Time, time.Time) ([]entity.Loan, error)) *LoanRepository_GetClosedBetween_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Update provides a mock function with given fields: loan
 func (_m *LoanRepository) Update(loan entity.Loan) error {
 	ret := _m.Called(loan)
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.46.3. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
@@ -76,6 +76,170 @@ func (_c *LoanScheduleRepository_Create_Call) RunAndReturn(run func(entity.LoanS
 	return _c
 }
 
+// CreateLoanSchedulesAndUpdateLoan provides a mock function with given fields: loanSchedules, loan
+func (_m *LoanScheduleRepository) CreateLoanSchedulesAndUpdateLoan(loanSchedules []entity.LoanSchedule, loan entity.Loan) error {
+	ret := _m.Called(loanSchedules, loan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLoanSchedulesAndUpdateLoan")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]entity.LoanSchedule, entity.Loan) error); ok {
+		r0 = rf(loanSchedules, loan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateLoanSchedulesAndUpdateLoan'
+type LoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan_Call struct {
+	*mock.Call
+}
+
+// CreateLoanSchedulesAndUpdateLoan is a helper method to define mock.On call
+//   - loanSchedules []entity.LoanSchedule
+//   - loan entity.Loan
+func (_e *LoanScheduleRepository_Expecter) CreateLoanSchedulesAndUpdateLoan(loanSchedules interface{}, loan interface{}) *LoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan_Call {
+	return &LoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan_Call{Call: _e.mock.On("CreateLoanSchedulesAndUpdateLoan", loanSchedules, loan)}
+}
+
+func (_c *LoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan_Call) Run(run func(loanSchedules []entity.LoanSchedule, loan entity.Loan)) *LoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]entity.LoanSchedule), args[1].(entity.Loan))
+	})
+	return _c
+}
+
+func (_c *LoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan_Call) Return(_a0 error) *LoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan_Call) RunAndReturn(run func([]entity.LoanSchedule, entity.Loan) error) *LoanScheduleRepository_CreateLoanSchedulesAndUpdateLoan_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreatePaymentHoliday provides a mock function with given fields: holiday, loanSchedules, loan
+func (_m *LoanScheduleRepository) CreatePaymentHoliday(holiday entity.PaymentHoliday, loanSchedules []entity.LoanSchedule, loan entity.Loan) (int, error) {
+	ret := _m.Called(holiday, loanSchedules, loan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePaymentHoliday")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(entity.PaymentHoliday, []entity.LoanSchedule, entity.Loan) (int, error)); ok {
+		return rf(holiday, loanSchedules, loan)
+	}
+	if rf, ok := ret.Get(0).(func(entity.PaymentHoliday, []entity.LoanSchedule, entity.Loan) int); ok {
+		r0 = rf(holiday, loanSchedules, loan)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(entity.PaymentHoliday, []entity.LoanSchedule, entity.Loan) error); ok {
+		r1 = rf(holiday, loanSchedules, loan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoanScheduleRepository_CreatePaymentHoliday_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreatePaymentHoliday'
+type LoanScheduleRepository_CreatePaymentHoliday_Call struct {
+	*mock.Call
+}
+
+// CreatePaymentHoliday is a helper method to define mock.On call
+//   - holiday entity.PaymentHoliday
+//   - loanSchedules []entity.LoanSchedule
+//   - loan entity.Loan
+func (_e *LoanScheduleRepository_Expecter) CreatePaymentHoliday(holiday interface{}, loanSchedules interface{}, loan interface{}) *LoanScheduleRepository_CreatePaymentHoliday_Call {
+	return &LoanScheduleRepository_CreatePaymentHoliday_Call{Call: _e.mock.On("CreatePaymentHoliday", holiday, loanSchedules, loan)}
+}
+
+func (_c *LoanScheduleRepository_CreatePaymentHoliday_Call) Run(run func(holiday entity.PaymentHoliday, loanSchedules []entity.LoanSchedule, loan entity.Loan)) *LoanScheduleRepository_CreatePaymentHoliday_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(entity.PaymentHoliday), args[1].([]entity.LoanSchedule), args[2].(entity.Loan))
+	})
+	return _c
+}
+
+func (_c *LoanScheduleRepository_CreatePaymentHoliday_Call) Return(_a0 int, _a1 error) *LoanScheduleRepository_CreatePaymentHoliday_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LoanScheduleRepository_CreatePaymentHoliday_Call) RunAndReturn(run func(entity.PaymentHoliday, []entity.LoanSchedule, entity.Loan) (int, error)) *LoanScheduleRepository_CreatePaymentHoliday_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateRestructure provides a mock function with given fields: restructure, loanSchedules, charges, loan
+func (_m *LoanScheduleRepository) CreateRestructure(restructure entity.LoanRestructure, loanSchedules []entity.LoanSchedule, charges []entity.Charge, loan entity.Loan) (int, error) {
+	ret := _m.Called(restructure, loanSchedules, charges, loan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRestructure")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(entity.LoanRestructure, []entity.LoanSchedule, []entity.Charge, entity.Loan) (int, error)); ok {
+		return rf(restructure, loanSchedules, charges, loan)
+	}
+	if rf, ok := ret.Get(0).(func(entity.LoanRestructure, []entity.LoanSchedule, []entity.Charge, entity.Loan) int); ok {
+		r0 = rf(restructure, loanSchedules, charges, loan)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(entity.LoanRestructure, []entity.LoanSchedule, []entity.Charge, entity.Loan) error); ok {
+		r1 = rf(restructure, loanSchedules, charges, loan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoanScheduleRepository_CreateRestructure_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRestructure'
+type LoanScheduleRepository_CreateRestructure_Call struct {
+	*mock.Call
+}
+
+// CreateRestructure is a helper method to define mock.On call
+//   - restructure entity.LoanRestructure
+//   - loanSchedules []entity.LoanSchedule
+//   - charges []entity.Charge
+//   - loan entity.Loan
+func (_e *LoanScheduleRepository_Expecter) CreateRestructure(restructure interface{}, loanSchedules interface{}, charges interface{}, loan interface{}) *LoanScheduleRepository_CreateRestructure_Call {
+	return &LoanScheduleRepository_CreateRestructure_Call{Call: _e.mock.On("CreateRestructure", restructure, loanSchedules, charges, loan)}
+}
+
+func (_c *LoanScheduleRepository_CreateRestructure_Call) Run(run func(restructure entity.LoanRestructure, loanSchedules []entity.LoanSchedule, charges []entity.Charge, loan entity.Loan)) *LoanScheduleRepository_CreateRestructure_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(entity.LoanRestructure), args[1].([]entity.LoanSchedule), args[2].([]entity.Charge), args[3].(entity.Loan))
+	})
+	return _c
+}
+
+func (_c *LoanScheduleRepository_CreateRestructure_Call) Return(_a0 int, _a1 error) *LoanScheduleRepository_CreateRestructure_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LoanScheduleRepository_CreateRestructure_Call) RunAndReturn(run func(entity.LoanRestructure, []entity.LoanSchedule, []entity.Charge, entity.Loan) (int, error)) *LoanScheduleRepository_CreateRestructure_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetByLoanID provides a mock function with given fields: loanID
 func (_m *LoanScheduleRepository) GetByLoanID(loanID int) ([]entity.LoanSchedule, error) {
 	ret := _m.Called(loanID)
@@ -134,6 +298,122 @@ func (_c *LoanScheduleRepository_GetByLoanID_Call) RunAndReturn(run func(int) ([
 	return _c
 }
 
+// GetPaymentHolidaysByLoanID provides a mock function with given fields: loanID
+func (_m *LoanScheduleRepository) GetPaymentHolidaysByLoanID(loanID int) ([]entity.PaymentHoliday, error) {
+	ret := _m.Called(loanID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPaymentHolidaysByLoanID")
+	}
+
+	var r0 []entity.PaymentHoliday
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]entity.PaymentHoliday, error)); ok {
+		return rf(loanID)
+	}
+	if rf, ok := ret.Get(0).(func(int) []entity.PaymentHoliday); ok {
+		r0 = rf(loanID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.PaymentHoliday)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(loanID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoanScheduleRepository_GetPaymentHolidaysByLoanID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPaymentHolidaysByLoanID'
+type LoanScheduleRepository_GetPaymentHolidaysByLoanID_Call struct {
+	*mock.Call
+}
+
+// GetPaymentHolidaysByLoanID is a helper method to define mock.On call
+//   - loanID int
+func (_e *LoanScheduleRepository_Expecter) GetPaymentHolidaysByLoanID(loanID interface{}) *LoanScheduleRepository_GetPaymentHolidaysByLoanID_Call {
+	return &LoanScheduleRepository_GetPaymentHolidaysByLoanID_Call{Call: _e.mock.On("GetPaymentHolidaysByLoanID", loanID)}
+}
+
+func (_c *LoanScheduleRepository_GetPaymentHolidaysByLoanID_Call) Run(run func(loanID int)) *LoanScheduleRepository_GetPaymentHolidaysByLoanID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *LoanScheduleRepository_GetPaymentHolidaysByLoanID_Call) Return(_a0 []entity.PaymentHoliday, _a1 error) *LoanScheduleRepository_GetPaymentHolidaysByLoanID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LoanScheduleRepository_GetPaymentHolidaysByLoanID_Call) RunAndReturn(run func(int) ([]entity.PaymentHoliday, error)) *LoanScheduleRepository_GetPaymentHolidaysByLoanID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRestructuresByLoanID provides a mock function with given fields: loanID
+func (_m *LoanScheduleRepository) GetRestructuresByLoanID(loanID int) ([]entity.LoanRestructure, error) {
+	ret := _m.Called(loanID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRestructuresByLoanID")
+	}
+
+	var r0 []entity.LoanRestructure
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]entity.LoanRestructure, error)); ok {
+		return rf(loanID)
+	}
+	if rf, ok := ret.Get(0).(func(int) []entity.LoanRestructure); ok {
+		r0 = rf(loanID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.LoanRestructure)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(loanID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoanScheduleRepository_GetRestructuresByLoanID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRestructuresByLoanID'
+type LoanScheduleRepository_GetRestructuresByLoanID_Call struct {
+	*mock.Call
+}
+
+// GetRestructuresByLoanID is a helper method to define mock.On call
+//   - loanID int
+func (_e *LoanScheduleRepository_Expecter) GetRestructuresByLoanID(loanID interface{}) *LoanScheduleRepository_GetRestructuresByLoanID_Call {
+	return &LoanScheduleRepository_GetRestructuresByLoanID_Call{Call: _e.mock.On("GetRestructuresByLoanID", loanID)}
+}
+
+func (_c *LoanScheduleRepository_GetRestructuresByLoanID_Call) Run(run func(loanID int)) *LoanScheduleRepository_GetRestructuresByLoanID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *LoanScheduleRepository_GetRestructuresByLoanID_Call) Return(_a0 []entity.LoanRestructure, _a1 error) *LoanScheduleRepository_GetRestructuresByLoanID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LoanScheduleRepository_GetRestructuresByLoanID_Call) RunAndReturn(run func(int) ([]entity.LoanRestructure, error)) *LoanScheduleRepository_GetRestructuresByLoanID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Update provides a mock function with given fields: schedule
 func (_m *LoanScheduleRepository) Update(schedule entity.LoanSchedule) error {
 	ret := _m.Called(schedule)
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.46.3. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
@@ -20,9 +20,66 @@ func (_m *PaymentRepository) EXPECT() *PaymentRepository_Expecter {
 	return &PaymentRepository_Expecter{mock: &_m.Mock}
 }
 
-// CreatePaymentAndUpdateLoanSchedules provides a mock function with given fields: payment, loanSchedules
-func (_m *PaymentRepository) CreatePaymentAndUpdateLoanSchedules(payment entity.Payment, loanSchedules []entity.LoanSchedule) (int, error) {
-	ret := _m.Called(payment, loanSchedules)
+// CreateCreditRefund provides a mock function with given fields: refund, loan
+func (_m *PaymentRepository) CreateCreditRefund(refund entity.CreditRefund, loan entity.Loan) (int, error) {
+	ret := _m.Called(refund, loan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCreditRefund")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(entity.CreditRefund, entity.Loan) (int, error)); ok {
+		return rf(refund, loan)
+	}
+	if rf, ok := ret.Get(0).(func(entity.CreditRefund, entity.Loan) int); ok {
+		r0 = rf(refund, loan)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(entity.CreditRefund, entity.Loan) error); ok {
+		r1 = rf(refund, loan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_CreateCreditRefund_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateCreditRefund'
+type PaymentRepository_CreateCreditRefund_Call struct {
+	*mock.Call
+}
+
+// CreateCreditRefund is a helper method to define mock.On call
+//   - refund entity.CreditRefund
+//   - loan entity.Loan
+func (_e *PaymentRepository_Expecter) CreateCreditRefund(refund interface{}, loan interface{}) *PaymentRepository_CreateCreditRefund_Call {
+	return &PaymentRepository_CreateCreditRefund_Call{Call: _e.mock.On("CreateCreditRefund", refund, loan)}
+}
+
+func (_c *PaymentRepository_CreateCreditRefund_Call) Run(run func(refund entity.CreditRefund, loan entity.Loan)) *PaymentRepository_CreateCreditRefund_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(entity.CreditRefund), args[1].(entity.Loan))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_CreateCreditRefund_Call) Return(_a0 int, _a1 error) *PaymentRepository_CreateCreditRefund_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_CreateCreditRefund_Call) RunAndReturn(run func(entity.CreditRefund, entity.Loan) (int, error)) *PaymentRepository_CreateCreditRefund_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreatePaymentAndUpdateLoanSchedules provides a mock function with given fields: payment, loanSchedules, loan
+func (_m *PaymentRepository) CreatePaymentAndUpdateLoanSchedules(payment entity.Payment, loanSchedules []entity.LoanSchedule, loan entity.Loan) (int, error) {
+	ret := _m.Called(payment, loanSchedules, loan)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreatePaymentAndUpdateLoanSchedules")
@@ -30,17 +87,17 @@ func (_m *PaymentRepository) CreatePaymentAndUpdateLoanSchedules(payment entity.
 
 	var r0 int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(entity.Payment, []entity.LoanSchedule) (int, error)); ok {
-		return rf(payment, loanSchedules)
+	if rf, ok := ret.Get(0).(func(entity.Payment, []entity.LoanSchedule, entity.Loan) (int, error)); ok {
+		return rf(payment, loanSchedules, loan)
 	}
-	if rf, ok := ret.Get(0).(func(entity.Payment, []entity.LoanSchedule) int); ok {
-		r0 = rf(payment, loanSchedules)
+	if rf, ok := ret.Get(0).(func(entity.Payment, []entity.LoanSchedule, entity.Loan) int); ok {
+		r0 = rf(payment, loanSchedules, loan)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
-	if rf, ok := ret.Get(1).(func(entity.Payment, []entity.LoanSchedule) error); ok {
-		r1 = rf(payment, loanSchedules)
+	if rf, ok := ret.Get(1).(func(entity.Payment, []entity.LoanSchedule, entity.Loan) error); ok {
+		r1 = rf(payment, loanSchedules, loan)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -56,13 +113,14 @@ type PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call struct {
 // CreatePaymentAndUpdateLoanSchedules is a helper method to define mock.On call
 //   - payment entity.Payment
 //   - loanSchedules []entity.LoanSchedule
-func (_e *PaymentRepository_Expecter) CreatePaymentAndUpdateLoanSchedules(payment interface{}, loanSchedules interface{}) *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call {
-	return &PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call{Call: _e.mock.On("CreatePaymentAndUpdateLoanSchedules", payment, loanSchedules)}
+//   - loan entity.Loan
+func (_e *PaymentRepository_Expecter) CreatePaymentAndUpdateLoanSchedules(payment interface{}, loanSchedules interface{}, loan interface{}) *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call {
+	return &PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call{Call: _e.mock.On("CreatePaymentAndUpdateLoanSchedules", payment, loanSchedules, loan)}
 }
 
-func (_c *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call) Run(run func(payment entity.Payment, loanSchedules []entity.LoanSchedule)) *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call {
+func (_c *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call) Run(run func(payment entity.Payment, loanSchedules []entity.LoanSchedule, loan entity.Loan)) *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(entity.Payment), args[1].([]entity.LoanSchedule))
+		run(args[0].(entity.Payment), args[1].([]entity.LoanSchedule), args[2].(entity.Loan))
 	})
 	return _c
 }
@@ -72,7 +130,179 @@ func (_c *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call) Return(_a0
 	return _c
 }
 
-func (_c *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call) RunAndReturn(run func(entity.Payment, []entity.LoanSchedule) (int, error)) *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call {
+func (_c *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call) RunAndReturn(run func(entity.Payment, []entity.LoanSchedule, entity.Loan) (int, error)) *PaymentRepository_CreatePaymentAndUpdateLoanSchedules_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateReversalsAndUpdateLoanSchedules provides a mock function with given fields: reversals, loanSchedules, loan
+func (_m *PaymentRepository) CreateReversalsAndUpdateLoanSchedules(reversals []entity.PaymentReversal, loanSchedules []entity.LoanSchedule, loan entity.Loan) ([]int, error) {
+	ret := _m.Called(reversals, loanSchedules, loan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReversalsAndUpdateLoanSchedules")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]entity.PaymentReversal, []entity.LoanSchedule, entity.Loan) ([]int, error)); ok {
+		return rf(reversals, loanSchedules, loan)
+	}
+	if rf, ok := ret.Get(0).(func([]entity.PaymentReversal, []entity.LoanSchedule, entity.Loan) []int); ok {
+		r0 = rf(reversals, loanSchedules, loan)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]entity.PaymentReversal, []entity.LoanSchedule, entity.Loan) error); ok {
+		r1 = rf(reversals, loanSchedules, loan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_CreateReversalsAndUpdateLoanSchedules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateReversalsAndUpdateLoanSchedules'
+type PaymentRepository_CreateReversalsAndUpdateLoanSchedules_Call struct {
+	*mock.Call
+}
+
+// CreateReversalsAndUpdateLoanSchedules is a helper method to define mock.On call
+//   - reversals []entity.PaymentReversal
+//   - loanSchedules []entity.LoanSchedule
+//   - loan entity.Loan
+func (_e *PaymentRepository_Expecter) CreateReversalsAndUpdateLoanSchedules(reversals interface{}, loanSchedules interface{}, loan interface{}) *PaymentRepository_CreateReversalsAndUpdateLoanSchedules_Call {
+	return &PaymentRepository_CreateReversalsAndUpdateLoanSchedules_Call{Call: _e.mock.On("CreateReversalsAndUpdateLoanSchedules", reversals, loanSchedules, loan)}
+}
+
+func (_c *PaymentRepository_CreateReversalsAndUpdateLoanSchedules_Call) Run(run func(reversals []entity.PaymentReversal, loanSchedules []entity.LoanSchedule, loan entity.Loan)) *PaymentRepository_CreateReversalsAndUpdateLoanSchedules_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]entity.PaymentReversal), args[1].([]entity.LoanSchedule), args[2].(entity.Loan))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_CreateReversalsAndUpdateLoanSchedules_Call) Return(_a0 []int, _a1 error) *PaymentRepository_CreateReversalsAndUpdateLoanSchedules_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_CreateReversalsAndUpdateLoanSchedules_Call) RunAndReturn(run func([]entity.PaymentReversal, []entity.LoanSchedule, entity.Loan) ([]int, error)) *PaymentRepository_CreateReversalsAndUpdateLoanSchedules_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetByID provides a mock function with given fields: paymentID
+func (_m *PaymentRepository) GetByID(paymentID int) (entity.Payment, error) {
+	ret := _m.Called(paymentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByID")
+	}
+
+	var r0 entity.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (entity.Payment, error)); ok {
+		return rf(paymentID)
+	}
+	if rf, ok := ret.Get(0).(func(int) entity.Payment); ok {
+		r0 = rf(paymentID)
+	} else {
+		r0 = ret.Get(0).(entity.Payment)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(paymentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetByID'
+type PaymentRepository_GetByID_Call struct {
+	*mock.Call
+}
+
+// GetByID is a helper method to define mock.On call
+//   - paymentID int
+func (_e *PaymentRepository_Expecter) GetByID(paymentID interface{}) *PaymentRepository_GetByID_Call {
+	return &PaymentRepository_GetByID_Call{Call: _e.mock.On("GetByID", paymentID)}
+}
+
+func (_c *PaymentRepository_GetByID_Call) Run(run func(paymentID int)) *PaymentRepository_GetByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetByID_Call) Return(_a0 entity.Payment, _a1 error) *PaymentRepository_GetByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetByID_Call) RunAndReturn(run func(int) (entity.Payment, error)) *PaymentRepository_GetByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetByIdempotencyKey provides a mock function with given fields: idempotencyKey
+func (_m *PaymentRepository) GetByIdempotencyKey(idempotencyKey string) (entity.Payment, error) {
+	ret := _m.Called(idempotencyKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByIdempotencyKey")
+	}
+
+	var r0 entity.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (entity.Payment, error)); ok {
+		return rf(idempotencyKey)
+	}
+	if rf, ok := ret.Get(0).(func(string) entity.Payment); ok {
+		r0 = rf(idempotencyKey)
+	} else {
+		r0 = ret.Get(0).(entity.Payment)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(idempotencyKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetByIdempotencyKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetByIdempotencyKey'
+type PaymentRepository_GetByIdempotencyKey_Call struct {
+	*mock.Call
+}
+
+// GetByIdempotencyKey is a helper method to define mock.On call
+//   - idempotencyKey string
+func (_e *PaymentRepository_Expecter) GetByIdempotencyKey(idempotencyKey interface{}) *PaymentRepository_GetByIdempotencyKey_Call {
+	return &PaymentRepository_GetByIdempotencyKey_Call{Call: _e.mock.On("GetByIdempotencyKey", idempotencyKey)}
+}
+
+func (_c *PaymentRepository_GetByIdempotencyKey_Call) Run(run func(idempotencyKey string)) *PaymentRepository_GetByIdempotencyKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetByIdempotencyKey_Call) Return(_a0 entity.Payment, _a1 error) *PaymentRepository_GetByIdempotencyKey_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetByIdempotencyKey_Call) RunAndReturn(run func(string) (entity.Payment, error)) *PaymentRepository_GetByIdempotencyKey_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -135,6 +365,170 @@ func (_c *PaymentRepository_GetByLoanID_Call) RunAndReturn(run func(int) ([]enti
 	return _c
 }
 
+// GetCreditRefundsByLoanID provides a mock function with given fields: loanID
+func (_m *PaymentRepository) GetCreditRefundsByLoanID(loanID int) ([]entity.CreditRefund, error) {
+	ret := _m.Called(loanID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCreditRefundsByLoanID")
+	}
+
+	var r0 []entity.CreditRefund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]entity.CreditRefund, error)); ok {
+		return rf(loanID)
+	}
+	if rf, ok := ret.Get(0).(func(int) []entity.CreditRefund); ok {
+		r0 = rf(loanID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.CreditRefund)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(loanID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetCreditRefundsByLoanID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCreditRefundsByLoanID'
+type PaymentRepository_GetCreditRefundsByLoanID_Call struct {
+	*mock.Call
+}
+
+// GetCreditRefundsByLoanID is a helper method to define mock.On call
+//   - loanID int
+func (_e *PaymentRepository_Expecter) GetCreditRefundsByLoanID(loanID interface{}) *PaymentRepository_GetCreditRefundsByLoanID_Call {
+	return &PaymentRepository_GetCreditRefundsByLoanID_Call{Call: _e.mock.On("GetCreditRefundsByLoanID", loanID)}
+}
+
+func (_c *PaymentRepository_GetCreditRefundsByLoanID_Call) Run(run func(loanID int)) *PaymentRepository_GetCreditRefundsByLoanID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetCreditRefundsByLoanID_Call) Return(_a0 []entity.CreditRefund, _a1 error) *PaymentRepository_GetCreditRefundsByLoanID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetCreditRefundsByLoanID_Call) RunAndReturn(run func(int) ([]entity.CreditRefund, error)) *PaymentRepository_GetCreditRefundsByLoanID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetReversalsByLoanID provides a mock function with given fields: loanID
+func (_m *PaymentRepository) GetReversalsByLoanID(loanID int) ([]entity.PaymentReversal, error) {
+	ret := _m.Called(loanID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReversalsByLoanID")
+	}
+
+	var r0 []entity.PaymentReversal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]entity.PaymentReversal, error)); ok {
+		return rf(loanID)
+	}
+	if rf, ok := ret.Get(0).(func(int) []entity.PaymentReversal); ok {
+		r0 = rf(loanID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.PaymentReversal)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(loanID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetReversalsByLoanID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetReversalsByLoanID'
+type PaymentRepository_GetReversalsByLoanID_Call struct {
+	*mock.Call
+}
+
+// GetReversalsByLoanID is a helper method to define mock.On call
+//   - loanID int
+func (_e *PaymentRepository_Expecter) GetReversalsByLoanID(loanID interface{}) *PaymentRepository_GetReversalsByLoanID_Call {
+	return &PaymentRepository_GetReversalsByLoanID_Call{Call: _e.mock.On("GetReversalsByLoanID", loanID)}
+}
+
+func (_c *PaymentRepository_GetReversalsByLoanID_Call) Run(run func(loanID int)) *PaymentRepository_GetReversalsByLoanID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetReversalsByLoanID_Call) Return(_a0 []entity.PaymentReversal, _a1 error) *PaymentRepository_GetReversalsByLoanID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetReversalsByLoanID_Call) RunAndReturn(run func(int) ([]entity.PaymentReversal, error)) *PaymentRepository_GetReversalsByLoanID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SettlePayment provides a mock function with given fields: payment, loanSchedules, loan
+func (_m *PaymentRepository) SettlePayment(payment entity.Payment, loanSchedules []entity.LoanSchedule, loan entity.Loan) error {
+	ret := _m.Called(payment, loanSchedules, loan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SettlePayment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(entity.Payment, []entity.LoanSchedule, entity.Loan) error); ok {
+		r0 = rf(payment, loanSchedules, loan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PaymentRepository_SettlePayment_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SettlePayment'
+type PaymentRepository_SettlePayment_Call struct {
+	*mock.Call
+}
+
+// SettlePayment is a helper method to define mock.On call
+//   - payment entity.Payment
+//   - loanSchedules []entity.LoanSchedule
+//   - loan entity.Loan
+func (_e *PaymentRepository_Expecter) SettlePayment(payment interface{}, loanSchedules interface{}, loan interface{}) *PaymentRepository_SettlePayment_Call {
+	return &PaymentRepository_SettlePayment_Call{Call: _e.mock.On("SettlePayment", payment, loanSchedules, loan)}
+}
+
+func (_c *PaymentRepository_SettlePayment_Call) Run(run func(payment entity.Payment, loanSchedules []entity.LoanSchedule, loan entity.Loan)) *PaymentRepository_SettlePayment_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(entity.Payment), args[1].([]entity.LoanSchedule), args[2].(entity.Loan))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_SettlePayment_Call) Return(_a0 error) *PaymentRepository_SettlePayment_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *PaymentRepository_SettlePayment_Call) RunAndReturn(run func(entity.Payment, []entity.LoanSchedule, entity.Loan) error) *PaymentRepository_SettlePayment_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewPaymentRepository creates a new instance of PaymentRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewPaymentRepository(t interface {